@@ -8,7 +8,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"syscall/js"
 
 	"github.com/aliyun/infraguard/pkg/engine"
@@ -24,6 +26,30 @@ type modulePayload struct {
 	Modules    map[string]string `json:"modules"`
 }
 
+// The playground sends the same modules with every scan, so the policies compiled
+// for the last payload are kept and reused until the payload changes.
+var (
+	cachedPayload   string
+	cachedEvaluator *engine.Evaluator
+)
+
+// evaluatorFor returns the compiled policies for a modules payload.
+func evaluatorFor(modulesJSON string) (*engine.Evaluator, error) {
+	if cachedEvaluator != nil && modulesJSON == cachedPayload {
+		return cachedEvaluator, nil
+	}
+	var payload modulePayload
+	if err := json.Unmarshal([]byte(modulesJSON), &payload); err != nil {
+		return nil, fmt.Errorf("invalid modules payload: %w", err)
+	}
+	evaluator, err := engine.NewEvaluator(&engine.EvalOptions{Modules: payload.Modules, LibModules: payload.LibModules})
+	if err != nil {
+		return nil, err
+	}
+	cachedPayload, cachedEvaluator = modulesJSON, evaluator
+	return evaluator, nil
+}
+
 // scan(content, modulesJSON, lang?, iac?) -> JSON string {violations} | {error}
 // iac is "ros" (default) or "terraform".
 func scan(_ js.Value, args []js.Value) any {
@@ -31,9 +57,9 @@ func scan(_ js.Value, args []js.Value) any {
 		return errJSON("scan requires (content, modulesJSON)")
 	}
 	content := args[0].String()
-	var payload modulePayload
-	if err := json.Unmarshal([]byte(args[1].String()), &payload); err != nil {
-		return errJSON("invalid modules payload: " + err.Error())
+	evaluator, err := evaluatorFor(args[1].String())
+	if err != nil {
+		return errJSON(err.Error())
 	}
 	lang := "en"
 	if len(args) > 2 && args[2].Type() == js.TypeString {
@@ -44,15 +70,13 @@ func scan(_ js.Value, args []js.Value) any {
 		iac = args[3].String()
 	}
 
-	opts := &engine.EvalOptions{Modules: payload.Modules, LibModules: payload.LibModules}
-
 	var rich []models.RichViolation
 	if iac == "terraform" {
 		data, err := terraform.LoadContent("main.tf", content, nil)
 		if err != nil {
 			return errJSON(err.Error())
 		}
-		res, err := engine.EvaluateWithOpts(opts, data)
+		res, err := evaluator.Evaluate(context.Background(), data)
 		if err != nil {
 			return errJSON(err.Error())
 		}
@@ -64,7 +88,7 @@ func scan(_ js.Value, args []js.Value) any {
 		}
 		var root yaml.Node
 		_ = yaml.Unmarshal([]byte(content), &root)
-		res, err := engine.EvaluateWithOpts(opts, input)
+		res, err := evaluator.Evaluate(context.Background(), input)
 		if err != nil {
 			return errJSON(err.Error())
 		}
@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/engine"
+	"github.com/aliyun/infraguard/pkg/i18n"
+)
+
+// templateExplanation is the --explain outcome for one template.
+type templateExplanation struct {
+	File     string `json:"file"`
+	Resource string `json:"resource,omitempty"`
+	*engine.Explanation
+}
+
+// runExplain traces the --explain rule on each template, optionally limited to
+// the --explain-resource resource, and prints why the rule fired or passed
+// instead of the scan report.
+func runExplain(ctx context.Context, templateFiles []string, iacTypes map[string]string, inputParams map[string]interface{}, evaluators map[string]*engine.Evaluator) error {
+	msg := i18n.Msg()
+
+	var explained []templateExplanation
+	ruleFound := false
+	for _, templatePath := range templateFiles {
+		iacType := iacTypes[templatePath]
+		evaluator := evaluators[iacType]
+		if evaluator == nil || !evaluator.HasRule(scanExplain) {
+			continue
+		}
+		ruleFound = true
+
+		_, templateData, err := loadTemplateWithMode(templatePath, iacType, inputParams, scanMode)
+		if err != nil {
+			formatAndPrintError(templatePath, err, msg)
+			continue
+		}
+		var focus *engine.ExplainFocus
+		if scanExplainResource != "" {
+			if focus = resourceFocus(templateData, iacType, scanExplainResource); focus == nil {
+				continue
+			}
+		}
+
+		ex, err := evaluator.Explain(ctx, templateData, scanExplain, focus)
+		if err != nil {
+			return fmt.Errorf(msg.Scan.FileError, templatePath, fmt.Errorf(msg.Errors.EvaluatePolicies, err))
+		}
+		explained = append(explained, templateExplanation{File: templatePath, Resource: scanExplainResource, Explanation: ex})
+	}
+
+	if !ruleFound {
+		return fmt.Errorf(msg.Errors.ExplainRuleNotFound, scanExplain)
+	}
+	if len(explained) == 0 && scanExplainResource != "" {
+		return fmt.Errorf(msg.Errors.ExplainResourceNotFound, scanExplainResource, strings.Join(templateFiles, ", "))
+	}
+
+	writer := io.Writer(os.Stdout)
+	if scanOutput != "" {
+		f, err := os.Create(scanOutput)
+		if err != nil {
+			return fmt.Errorf(msg.Errors.RenderReport, err)
+		}
+		defer f.Close()
+		writer = f
+	}
+
+	if scanFormat == "json" {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explained)
+	}
+	for i, te := range explained {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+		renderExplanation(writer, te, msg)
+	}
+	return nil
+}
+
+// resourceFocus returns the explanation focus for the given resource of a
+// template's input: a logical ID for ROS, or a Terraform address such as
+// "alicloud_vswitch.main" or "module.network.alicloud_vswitch.main". Module
+// resources are resolved through their __meta__ address, as in scan reports.
+// It returns nil if the template has no such resource.
+func resourceFocus(input map[string]interface{}, iacType, resourceID string) *engine.ExplainFocus {
+	if iacType != "terraform" {
+		resources, _ := input["Resources"].(map[string]interface{})
+		resource, ok := resources[resourceID]
+		if !ok {
+			return nil
+		}
+		return &engine.ExplainFocus{Value: resource, Name: resourceID, ResourceIDs: []string{resourceID}}
+	}
+
+	resources, _ := input["resources"].(map[string]interface{})
+	for resType, byName := range resources {
+		byName, _ := byName.(map[string]interface{})
+		for name, resource := range byName {
+			// Rules report resources as "type.name"; the name of a module
+			// resource carries its module path, e.g. "module.network.main".
+			ruleID := resType + "." + name
+			address := ruleID
+			if res, ok := resource.(map[string]interface{}); ok {
+				if meta, ok := res["__meta__"].(map[string]interface{}); ok {
+					if addr, ok := meta["address"].(string); ok && addr != "" {
+						address = addr
+					}
+				}
+			}
+			if resourceID == address || resourceID == ruleID {
+				return &engine.ExplainFocus{Value: resource, Name: name, ResourceIDs: []string{ruleID, address}}
+			}
+		}
+	}
+	return nil
+}
+
+// renderExplanation writes an explanation as text: the result, the outcome of
+// each deny body, the values looked up, and the trace as an indented tree.
+func renderExplanation(w io.Writer, te templateExplanation, msg *i18n.Messages) {
+	displayPath := te.File
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, te.File); err == nil {
+			displayPath = rel
+		}
+	}
+
+	fmt.Fprintf(w, msg.Scan.ExplainHeader+"\n", te.RuleID, displayPath)
+	if te.Resource != "" {
+		fmt.Fprintf(w, msg.Scan.ExplainResource+"\n", te.Resource)
+	}
+	if len(te.Violations) > 0 {
+		fmt.Fprintf(w, msg.Scan.ExplainFired+"\n", len(te.Violations))
+	} else {
+		fmt.Fprintln(w, msg.Scan.ExplainPassed)
+	}
+	fmt.Fprintln(w)
+
+	if len(te.Bodies) == 0 {
+		fmt.Fprintln(w, "  "+msg.Scan.ExplainNoBody)
+	}
+	for _, body := range te.Bodies {
+		fmt.Fprintf(w, "  "+msg.Scan.ExplainBody+"\n", body.Location, body.Evaluated, body.Succeeded)
+		for _, failure := range body.Failures {
+			fmt.Fprintf(w, "    "+msg.Scan.ExplainFailedExpr+"\n", failure.Count, failure.Location, failure.Expr)
+			names := make([]string, 0, len(failure.Bindings))
+			for name := range failure.Bindings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(w, "      %s = %s\n", name, failure.Bindings[name])
+			}
+		}
+	}
+
+	if len(te.Lookups) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, msg.Scan.ExplainLookups)
+		for _, lookup := range te.Lookups {
+			fmt.Fprintf(w, "  %s = %s\n", lookup.Ref, lookup.Value)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, msg.Scan.ExplainTrace)
+	for _, step := range te.Trace {
+		line := fmt.Sprintf("  %s%-5s %s", strings.Repeat("| ", step.Depth), step.Op, step.Node)
+		if step.Location != "" {
+			line += "  (" + step.Location + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
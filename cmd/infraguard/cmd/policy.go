@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"sort"
@@ -271,11 +272,32 @@ func printRuleDetails(rule *models.Rule, lang string, msg *i18n.Messages) {
 	table.Append(msg.PolicyGet.Description, wrapText(rule.Description.Get(lang), 80))
 	table.Append(msg.PolicyGet.IaCTypes, formatIaCTypes(rule.IaCTypes))
 	table.Append(msg.PolicyGet.ResourceTypes, formatResourceTypes(rule))
+	if len(rule.Params) > 0 {
+		table.Append(msg.PolicyGet.Params, formatRuleParams(rule.Params, lang, msg))
+	}
 	table.Render()
 
 	fmt.Println()
 }
 
+// formatRuleParams formats rule parameters as one entry per parameter: its name,
+// type and default, followed by its description.
+func formatRuleParams(params []models.RuleParam, lang string, msg *i18n.Messages) string {
+	var entries []string
+	for _, param := range params {
+		def, err := json.Marshal(param.Default)
+		if err != nil {
+			def = []byte(fmt.Sprint(param.Default))
+		}
+		entry := fmt.Sprintf(msg.PolicyGet.ParamLine, param.Name, param.Type, def)
+		if desc := param.Description.Get(lang); desc != "" {
+			entry += "\n" + wrapText(desc, 80)
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, "\n\n")
+}
+
 func formatIaCTypes(iacTypes []string) string {
 	var parts []string
 	for _, t := range iacTypes {
@@ -138,6 +138,8 @@ func testDetail(c policytest.CaseResult) string {
 		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.PolicyTest.DetailLoadTF }), c.Detail)
 	case policytest.CodeEval:
 		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.PolicyTest.DetailEval }), c.Detail)
+	case policytest.CodeTimeout:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.PolicyTest.DetailTimeout }), c.Detail)
 	}
 	return ""
 }
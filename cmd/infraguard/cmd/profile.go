@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aliyun/infraguard/pkg/engine"
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// writeProfile prints the --profile statistics, slowest rule first, to stdout
+// or the --output file.
+func writeProfile(rules []engine.RuleProfile) error {
+	msg := i18n.Msg()
+
+	writer := io.Writer(os.Stdout)
+	if scanOutput != "" {
+		f, err := os.Create(scanOutput)
+		if err != nil {
+			return fmt.Errorf(msg.Errors.RenderReport, err)
+		}
+		defer f.Close()
+		writer = f
+	}
+
+	if scanFormat == "json" {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rules)
+	}
+	renderProfile(writer, rules, msg)
+	return nil
+}
+
+// renderProfile writes the profile as a table with one row per rule.
+func renderProfile(w io.Writer, rules []engine.RuleProfile, msg *i18n.Messages) {
+	fmt.Fprintf(w, "\n%s (%d)\n\n", boldColor.Sprint(msg.Scan.ProfileHeader), len(rules))
+
+	table := tablewriter.NewTable(w,
+		tablewriter.WithRendition(tw.Rendition{
+			Settings: tw.Settings{
+				Separators: tw.Separators{
+					BetweenRows: tw.On,
+				},
+			},
+		}),
+	)
+	table.Header(msg.Report.RuleID, msg.Scan.ProfileTotalTime, msg.Scan.ProfileEvaluations, msg.Scan.ProfileAvgTime, msg.Scan.ProfileSlowestExprs)
+
+	for _, rule := range rules {
+		evaluations := fmt.Sprintf("%d", rule.Evaluations)
+		if rule.TimedOut > 0 {
+			evaluations += "\n" + fmt.Sprintf(msg.Scan.ProfileTimedOut, rule.TimedOut)
+		}
+		var avg int64
+		if rule.Evaluations > 0 {
+			avg = rule.TotalTimeNs / int64(rule.Evaluations)
+		}
+
+		exprs := make([]string, 0, len(rule.SlowestExpr))
+		for _, expr := range rule.SlowestExpr {
+			exprs = append(exprs, fmt.Sprintf("%s  %s\n  %s", formatNanos(expr.TotalTimeNs), expr.Location, wrapText(expr.Expr, 60)))
+		}
+
+		table.Append(rule.RuleID, formatNanos(rule.TotalTimeNs), evaluations, formatNanos(avg), strings.Join(exprs, "\n"))
+	}
+
+	table.Render()
+	fmt.Fprintln(w)
+}
+
+// formatNanos formats a duration in nanoseconds, rounded to the microsecond.
+func formatNanos(ns int64) string {
+	return time.Duration(ns).Round(time.Microsecond).String()
+}
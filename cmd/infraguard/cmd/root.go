@@ -116,6 +116,10 @@ func updateCommandDescriptions() {
 	waiverCmd.Long = strings.TrimSpace(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Long }))
 	waiverListCmd.Short = i18n.Get(func(m *i18n.Messages) string { return m.Waiver.List.Short })
 	waiverLintCmd.Short = i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.Short })
+	waiverAddCmd.Short = i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Add.Short })
+	waiverAddCmd.Long = strings.TrimSpace(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Add.Long }))
+	waiverPruneCmd.Short = i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Prune.Short })
+	waiverPruneCmd.Long = strings.TrimSpace(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Prune.Long }))
 
 	updatePolicyNewFlagDescriptions()
 	updatePolicyTestFlagDescriptions()
@@ -256,6 +260,48 @@ func updateScanFlagDescriptions() {
 	if f := flags.Lookup("fail-on-expired"); f != nil {
 		f.Usage = msg.Scan.FailOnExpiredFlag
 	}
+	if f := flags.Lookup("jobs"); f != nil {
+		f.Usage = msg.Scan.JobsFlag
+	}
+	if f := flags.Lookup("eval-timeout"); f != nil {
+		f.Usage = msg.Scan.EvalTimeoutFlag
+	}
+	if f := flags.Lookup("explain"); f != nil {
+		f.Usage = msg.Scan.ExplainFlag
+	}
+	if f := flags.Lookup("explain-resource"); f != nil {
+		f.Usage = msg.Scan.ExplainResourceFlag
+	}
+	if f := flags.Lookup("profile"); f != nil {
+		f.Usage = msg.Scan.ProfileFlag
+	}
+	if f := flags.Lookup("params"); f != nil {
+		f.Usage = msg.Scan.ParamsFlag
+	}
+	if f := flags.Lookup("config"); f != nil {
+		f.Usage = msg.Scan.ConfigFlag
+	}
+	if f := flags.Lookup("fail-on"); f != nil {
+		f.Usage = msg.Scan.FailOnFlag
+	}
+	if f := flags.Lookup("min-severity"); f != nil {
+		f.Usage = msg.Scan.MinSeverityFlag
+	}
+	if f := flags.Lookup("baseline"); f != nil {
+		f.Usage = msg.Scan.BaselineFlag
+	}
+	if f := flags.Lookup("baseline-write"); f != nil {
+		f.Usage = msg.Scan.BaselineWriteFlag
+	}
+	if f := flags.Lookup("changed-since"); f != nil {
+		f.Usage = msg.Scan.ChangedSinceFlag
+	}
+	if f := flags.Lookup("changed-lines"); f != nil {
+		f.Usage = msg.Scan.ChangedLinesFlag
+	}
+	if f := flags.Lookup("markdown-max-size"); f != nil {
+		f.Usage = msg.Scan.MarkdownMaxSizeFlag
+	}
 }
 
 // setUsage updates a flag's usage text if the flag exists and text is non-empty.
@@ -306,6 +352,23 @@ func updateWaiverFlagDescriptions() {
 	if f := waiverLintCmd.Flags().Lookup("rules-dir"); f != nil {
 		f.Usage = msg.Waiver.RulesDirFlag
 	}
+	f := waiverAddCmd.Flags()
+	setUsage(f, "rule", msg.Waiver.Add.RuleFlag)
+	setUsage(f, "resource", msg.Waiver.Add.ResourceFlag)
+	setUsage(f, "file", msg.Waiver.Add.FileFlag)
+	setUsage(f, "fingerprint", msg.Waiver.Add.FingerprintFlag)
+	setUsage(f, "reason", msg.Waiver.Add.ReasonFlag)
+	setUsage(f, "expires", msg.Waiver.Add.ExpiresFlag)
+	setUsage(f, "owner", msg.Waiver.Add.OwnerFlag)
+	setUsage(f, "approved-by", msg.Waiver.Add.ApprovedByFlag)
+	setUsage(f, "ticket", msg.Waiver.Add.TicketFlag)
+	setUsage(f, "severity-ceiling", msg.Waiver.Add.SeverityCeilingFlag)
+	setUsage(f, "pack", msg.Waiver.Add.PackFlag)
+	setUsage(f, "max-severity", msg.Waiver.Add.MaxSeverityFlag)
+	setUsage(f, "report", msg.Waiver.Add.ReportFlag)
+	setUsage(f, "rules-dir", msg.Waiver.RulesDirFlag)
+	setUsage(waiverPruneCmd.Flags(), "report", msg.Waiver.Prune.ReportFlag)
+	setUsage(waiverPruneCmd.Flags(), "remove", msg.Waiver.Prune.RemoveFlag)
 }
 
 // updatePolicyUpdateFlagDescriptions updates policy update command flag descriptions.
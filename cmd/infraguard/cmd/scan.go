@@ -1,15 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aliyun/infraguard/pkg/baseline"
+	"github.com/aliyun/infraguard/pkg/config"
 	"github.com/aliyun/infraguard/pkg/engine"
+	"github.com/aliyun/infraguard/pkg/gitdiff"
 	"github.com/aliyun/infraguard/pkg/i18n"
 	"github.com/aliyun/infraguard/pkg/loader"
 	"github.com/aliyun/infraguard/pkg/mapper"
@@ -35,6 +41,27 @@ var (
 	scanNoWaivers     bool   // Ignore all waivers (inline and file)
 	scanShowWaived    bool   // Show waived violations in output
 	scanFailOnExpired bool   // Treat expired waivers as real violations
+
+	scanFailOn      string // Lowest severity that fails the scan, or "none"
+	scanMinSeverity string // Lowest severity reported (empty reports all)
+
+	scanBaseline      string // Baseline file of accepted violations, excluded from the exit code
+	scanBaselineWrite string // Write the current violations to this baseline file instead of reporting
+
+	scanChangedSince string // Only scan templates changed since this git ref
+	scanChangedLines bool   // Only report violations whose resource block overlaps a changed line
+
+	scanMarkdownMaxSize int // Size limit of a markdown report in bytes (0 disables the limit)
+
+	scanJobs        int           // Number of templates scanned in parallel
+	scanEvalTimeout time.Duration // Time limit for evaluating one rule against one template
+
+	scanExplain         string // Rule to explain instead of reporting violations
+	scanExplainResource string // Resource the explanation is limited to (optional)
+	scanProfile         bool   // Report per-rule evaluation statistics instead of violations
+
+	scanParams string // Path to a rule parameters file, applied over the project params
+	scanConfig string // Path to project configuration file (default: auto-detect .infraguard/config.yaml)
 )
 
 // Color functions for error output
@@ -57,9 +84,9 @@ func init() {
 	scanCmd.Flags().StringArrayVarP(&scanPolicies, "policy", "p", nil,
 		"Policy specification: rule ID, pack ID, .rego file, or directory (can be specified multiple times)")
 	scanCmd.Flags().StringVar(&scanFormat, "format", "table",
-		"Output format (table, json, or html)")
+		"Output format (table, json, html, sarif, junit, or markdown)")
 	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", "",
-		"Output file path (default: report.html for html format)")
+		"Output file path (default: stdout; report.html for html format)")
 	scanCmd.Flags().StringArrayVarP(&scanInput, "input", "i", nil,
 		"Parameter values in key=value, JSON format, or file path (can be specified multiple times)")
 	scanCmd.Flags().StringVarP(&scanMode, "mode", "m", "static",
@@ -72,8 +99,34 @@ func init() {
 		"Show waived violations in output instead of hiding them")
 	scanCmd.Flags().BoolVar(&scanFailOnExpired, "fail-on-expired", true,
 		"Treat expired waivers as real violations")
-
-	scanCmd.MarkFlagRequired("policy")
+	scanCmd.Flags().StringVar(&scanFailOn, "fail-on", models.SeverityLow,
+		"Lowest severity that fails the scan (high, medium, low, or none)")
+	scanCmd.Flags().StringVar(&scanMinSeverity, "min-severity", "",
+		"Only report violations of this severity or higher (high, medium, or low)")
+	scanCmd.Flags().StringVar(&scanBaseline, "baseline", "",
+		"Baseline file; violations in it are hidden and do not fail the scan")
+	scanCmd.Flags().StringVar(&scanBaselineWrite, "baseline-write", "",
+		"Write the current violations to a baseline file instead of reporting them")
+	scanCmd.Flags().StringVar(&scanChangedSince, "changed-since", "",
+		"Only scan templates changed since the given git ref (e.g. origin/main)")
+	scanCmd.Flags().BoolVar(&scanChangedLines, "changed-lines", false,
+		"With --changed-since, only report violations whose resource overlaps a changed line")
+	scanCmd.Flags().IntVar(&scanMarkdownMaxSize, "markdown-max-size", reporter.DefaultMarkdownMaxSize,
+		"Size limit of a markdown report in bytes; longer reports are truncated with a note (0 disables the limit)")
+	scanCmd.Flags().IntVarP(&scanJobs, "jobs", "j", runtime.NumCPU(),
+		"Number of templates to scan in parallel (default: number of CPUs)")
+	scanCmd.Flags().DurationVar(&scanEvalTimeout, "eval-timeout", engine.DefaultRuleTimeout,
+		"Time limit for evaluating one rule against one template, e.g. 30s or 2m (0 disables the limit)")
+	scanCmd.Flags().StringVar(&scanExplain, "explain", "",
+		"Explain why the given rule fired or passed, instead of reporting violations")
+	scanCmd.Flags().StringVar(&scanExplainResource, "explain-resource", "",
+		"Limit --explain to one resource (e.g. MyBucket or alicloud_oss_bucket.logs)")
+	scanCmd.Flags().BoolVar(&scanProfile, "profile", false,
+		"Report per-rule evaluation time and the slowest expressions instead of violations")
+	scanCmd.Flags().StringVar(&scanParams, "params", "",
+		"Path to a rule parameters file, applied over the params of the project configuration")
+	scanCmd.Flags().StringVar(&scanConfig, "config", "",
+		"Path to project configuration file (default: auto-detect .infraguard/config.yaml)")
 }
 
 // PolicySpec represents a parsed policy specification.
@@ -135,8 +188,20 @@ func runScan(cmd *cobra.Command, args []string) error {
 	msg := i18n.Msg()
 	lang := i18n.GetLanguage()
 
+	// Project defaults apply to the flags that were not given on the command line.
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+	if project != nil {
+		applyProjectDefaults(cmd, project)
+	}
+	if len(scanPolicies) == 0 {
+		return fmt.Errorf("%s", msg.Errors.PolicyRequired)
+	}
+
 	// Validate format
-	validFormats := map[string]bool{"table": true, "json": true, "html": true}
+	validFormats := map[string]bool{"table": true, "json": true, "html": true, "sarif": true, "junit": true, "markdown": true}
 	if !validFormats[scanFormat] {
 		return fmt.Errorf(msg.Errors.InvalidFormat, scanFormat)
 	}
@@ -147,6 +212,37 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf(msg.Errors.InvalidMode, scanMode)
 	}
 
+	if scanFailOn != models.SeverityNone && !contains(models.SeverityLevels(), scanFailOn) {
+		return fmt.Errorf(msg.Errors.InvalidFailOn, scanFailOn)
+	}
+	if scanMinSeverity != "" && !contains(models.SeverityLevels(), scanMinSeverity) {
+		return fmt.Errorf(msg.Errors.InvalidMinSeverity, scanMinSeverity)
+	}
+	if scanBaseline != "" && scanBaselineWrite != "" {
+		return fmt.Errorf("%s", msg.Errors.BaselineWithWrite)
+	}
+	if scanChangedLines && scanChangedSince == "" {
+		return fmt.Errorf("%s", msg.Errors.ChangedLinesRequiresSince)
+	}
+	if scanJobs < 1 {
+		return fmt.Errorf(msg.Errors.InvalidJobs, scanJobs)
+	}
+	if scanEvalTimeout < 0 {
+		return fmt.Errorf(msg.Errors.InvalidEvalTimeout, scanEvalTimeout)
+	}
+	if scanExplainResource != "" && scanExplain == "" {
+		return fmt.Errorf("%s", msg.Errors.ExplainResourceRequiresRule)
+	}
+	if scanExplain != "" && scanFormat != "table" && scanFormat != "json" {
+		return fmt.Errorf(msg.Errors.ExplainFormat, scanFormat)
+	}
+	if scanProfile && scanExplain != "" {
+		return fmt.Errorf("%s", msg.Errors.ProfileWithExplain)
+	}
+	if scanProfile && scanFormat != "table" && scanFormat != "json" {
+		return fmt.Errorf(msg.Errors.ProfileFormat, scanFormat)
+	}
+
 	// Validate global language flag if provided
 	if globalLang != "" {
 		supportedLangs := i18n.GetSupportedLanguages()
@@ -177,8 +273,17 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Load the policy index once: it resolves rule and pack IDs, and describes
+	// the rules for parameters, waivers and reports. policyErr is kept for the
+	// steps that cannot do without it.
+	policyLoader, policyErr := policy.LoadWithFallback()
+	var policyIndex *models.PolicyIndex
+	if policyLoader != nil {
+		policyIndex = policyLoader.GetIndex()
+	}
+
 	// Build evaluation options based on policy specs
-	evalOpts, ruleIaCMap, err := buildEvalOptions(policySpecs, msg)
+	evalOpts, ruleIaCMap, err := buildEvalOptions(policySpecs, policyLoader, msg)
 	if err != nil {
 		return err
 	}
@@ -189,93 +294,103 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	ruleParams, err := loadRuleParams(project)
+	if err != nil {
+		return err
+	}
+
 	// Collect all template files
-	templateFiles, err := collectTemplates(args)
+	templateFiles, iacTypes, err := collectTemplates(args)
 	if err != nil {
 		return err
 	}
 
+	if project != nil {
+		templateFiles = excludeTemplates(templateFiles, project)
+	}
+
 	if len(templateFiles) == 0 {
 		return fmt.Errorf("%s", msg.Scan.NoTemplatesFound)
 	}
 
-	var results []models.FileResult
-	warnedUnsupported := make(map[string]bool)
-	// resLinesByFile maps a source file path to the start lines of its resources,
-	// used to attribute inline waiver comments to resources.
-	resLinesByFile := make(map[string][]waiver.ResourceLine)
-
-	// Process each template
-	for _, templatePath := range templateFiles {
-		// Load template based on mode
-		var yamlRoot *yaml.Node
-		var templateData map[string]interface{}
-		var err error
-
-		// Use unified loading logic for both static and preview modes
-		yamlRoot, templateData, err = loadTemplateWithMode(templatePath, inputParams, scanMode)
+	var changes *gitdiff.Changes
+	if scanChangedSince != "" {
+		changes, err = gitdiff.Since(".", scanChangedSince)
 		if err != nil {
-			// Format and display error with color
-			formatAndPrintError(templatePath, err, msg)
-
-			// Skip this file and continue processing other files
-			// This applies to both static and preview modes
-			continue
+			return fmt.Errorf(msg.Scan.ChangedSinceError, scanChangedSince, err)
 		}
-
-		// Determine IaC type for this template
-		iacType := "ros"
-		if terraform.IsTerraformFile(templatePath) {
-			iacType = "terraform"
+		templateFiles = changedTemplates(templateFiles, iacTypes, changes)
+		if len(templateFiles) == 0 {
+			fmt.Fprintf(os.Stderr, msg.Scan.NoChangedTemplates+"\n", scanChangedSince)
+			return nil
 		}
+	}
 
-		// Filter eval options by IaC type
+	// Compile the policies once per IaC type, filtered in file order so
+	// unsupported-rule warnings are printed deterministically. A nil evaluator
+	// means no policy applies to that IaC type.
+	warnedUnsupported := make(map[string]bool)
+	evaluators := make(map[string]*engine.Evaluator)
+	var profile *engine.Profile
+	if scanProfile {
+		profile = engine.NewProfile()
+	}
+	for _, templatePath := range templateFiles {
+		iacType := iacTypes[templatePath]
+		if _, ok := evaluators[iacType]; ok {
+			continue
+		}
 		filteredOpts := filterEvalOptsByIaCType(evalOpts, ruleIaCMap, iacType, warnedUnsupported)
-
-		// Skip evaluation if no applicable policies after filtering
 		if len(filteredOpts.Modules) == 0 && len(filteredOpts.PolicyPaths) == 0 {
-			results = append(results, models.FileResult{
-				File:       templatePath,
-				Violations: nil,
-			})
+			evaluators[iacType] = nil
 			continue
 		}
-
-		// Load and evaluate policies
-		evalResult, err := engine.EvaluateWithOpts(filteredOpts, templateData)
+		filteredOpts.RuleTimeout = scanEvalTimeout
+		filteredOpts.Profile = profile
+		filteredOpts.Params = ruleParams
+		evaluator, err := engine.NewEvaluator(filteredOpts)
 		if err != nil {
-			return fmt.Errorf(msg.Scan.FileError, templatePath, fmt.Errorf(msg.Errors.EvaluatePolicies, err))
-		}
-
-		// Map violations to source locations with i18n support
-		var richViolations []models.RichViolation
-		if terraform.IsTerraformFile(templatePath) {
-			richViolations = mapper.MapTerraformViolations(evalResult.Violations, templateData, filepath.Dir(templatePath), lang)
-		} else {
-			richViolations = mapper.MapViolationsWithLang(evalResult.Violations, yamlRoot, templatePath, lang)
+			return fmt.Errorf(msg.Errors.EvaluatePolicies, err)
 		}
+		evaluators[iacType] = evaluator
+	}
+	warnRuleParams(ruleParams, policyIndex, evaluators)
 
-		// Sort violations by severity
-		sort.Slice(richViolations, func(i, j int) bool {
-			return models.SeverityOrder(richViolations[i].Severity) < models.SeverityOrder(richViolations[j].Severity)
-		})
+	if scanExplain != "" {
+		return runExplain(cmd.Context(), templateFiles, iacTypes, inputParams, evaluators)
+	}
 
-		// Collect resource start lines for inline waiver attribution.
-		if !scanNoWaivers {
-			if iacType == "terraform" {
-				for f, rls := range extractTFResourceLines(templateData, filepath.Dir(templatePath)) {
-					resLinesByFile[f] = append(resLinesByFile[f], rls...)
-				}
-			} else if yamlRoot != nil {
-				resLinesByFile[templatePath] = append(resLinesByFile[templatePath], extractROSResourceLines(yamlRoot)...)
-			}
+	// Scan templates concurrently. Outcomes are handled in the order of
+	// templateFiles as they complete, so errors are printed in file order.
+	scans := make([]templateScan, len(templateFiles))
+	var results []models.FileResult
+	// resLinesByFile maps a source file path to the start lines of its resources,
+	// used to attribute inline waiver comments to resources.
+	resLinesByFile := make(map[string][]waiver.ResourceLine)
+	err = forEachParallel(len(templateFiles), scanJobs, func(i int) {
+		scans[i] = scanTemplate(cmd.Context(), templateFiles[i], iacTypes[templateFiles[i]], inputParams, evaluators, lang)
+	}, func(i int) error {
+		scan, templatePath := scans[i], templateFiles[i]
+		if scan.loadErr != nil {
+			// Format and display error with color, then skip this file and continue
+			// processing other files. This applies to both static and preview modes.
+			formatAndPrintError(templatePath, scan.loadErr, msg)
+			return nil
 		}
-
-		// Append to results
-		results = append(results, models.FileResult{
-			File:       templatePath,
-			Violations: richViolations,
-		})
+		if scan.evalErr != nil {
+			return fmt.Errorf(msg.Scan.FileError, templatePath, fmt.Errorf(msg.Errors.EvaluatePolicies, scan.evalErr))
+		}
+		for _, ruleID := range scan.result.TimedOutRules {
+			fmt.Fprintf(os.Stderr, msg.Scan.RuleTimedOut+"\n", ruleID, templatePath, scanEvalTimeout)
+		}
+		for f, rls := range scan.resLines {
+			resLinesByFile[f] = append(resLinesByFile[f], rls...)
+		}
+		results = append(results, scan.result)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// If no templates were successfully processed, return error
@@ -284,19 +399,46 @@ func runScan(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("%s", msg.Scan.NoTemplatesProcessed)
 	}
 
+	if profile != nil {
+		return writeProfile(profile.Rules())
+	}
+
+	root := config.WorkspaceRoot(".")
+	if project != nil {
+		root = project.Root
+	}
+	assignFingerprints(results, iacTypes, root)
+
+	if scanChangedLines {
+		dropUnchangedViolations(results, resLinesByFile, changes)
+	}
+	if project != nil {
+		applySeverityOverrides(results, project)
+	}
+
 	// Apply waivers (inline comments + central waiver file) unless disabled.
 	if !scanNoWaivers {
-		if err := applyWaivers(results, resLinesByFile); err != nil {
+		if err := applyWaivers(results, resLinesByFile, policyIndex, policyErr); err != nil {
+			return err
+		}
+	}
+
+	if scanBaselineWrite != "" {
+		return writeBaseline(results, root)
+	}
+	if scanBaseline != "" {
+		if err := applyBaseline(results, iacTypes, root); err != nil {
 			return err
 		}
 	}
 
-	// Determine exit-code flags from non-suppressed violations.
+	// Determine exit-code flags from non-suppressed violations at or above the
+	// fail threshold.
 	hasViolations := false
 	hasHighSeverity := false
 	for _, fr := range results {
 		for _, v := range fr.Violations {
-			if v.IsSuppressed(scanFailOnExpired) {
+			if v.IsSuppressed(scanFailOnExpired) || !failsAt(v.Severity, scanFailOn) {
 				continue
 			}
 			hasViolations = true
@@ -311,11 +453,11 @@ func runScan(cmd *cobra.Command, args []string) error {
 	writer := os.Stdout
 	outputPath := scanOutput
 
-	if scanFormat == "html" {
-		// HTML format: write to file
-		if outputPath == "" {
-			outputPath = "report.html"
-		}
+	if scanFormat == "html" && outputPath == "" {
+		// HTML format always writes to a file
+		outputPath = "report.html"
+	}
+	if outputPath != "" {
 		var err error
 		outputFile, err = os.Create(outputPath)
 		if err != nil {
@@ -328,13 +470,20 @@ func runScan(cmd *cobra.Command, args []string) error {
 	// Render report
 	r := reporter.New(scanFormat, writer,
 		reporter.WithShowWaived(scanShowWaived),
-		reporter.WithFailOnExpired(scanFailOnExpired))
+		reporter.WithFailOnExpired(scanFailOnExpired),
+		reporter.WithFailOn(scanFailOn),
+		reporter.WithMinSeverity(scanMinSeverity),
+		reporter.WithChangedSince(scanChangedSince, scanChangedLines),
+		reporter.WithScope(scanPolicies, absPaths(args)),
+		reporter.WithMarkdownMaxSize(scanMarkdownMaxSize),
+		reporter.WithRules(reportRules(scanFormat, policyLoader)),
+		reporter.WithToolVersion(Version))
 	if err := r.Render(results); err != nil {
 		return fmt.Errorf(msg.Errors.RenderReport, err)
 	}
 
-	// Print message for HTML output
-	if scanFormat == "html" {
+	// Print message when the report went to a file
+	if outputFile != nil {
 		fmt.Fprintf(os.Stdout, msg.Scan.ReportWritten+"\n", outputPath)
 	}
 
@@ -349,8 +498,494 @@ func runScan(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// loadProject loads the project configuration from --config, or from the
+// workspace configuration file if there is one. Returns nil if there is none.
+func loadProject() (*config.Project, error) {
+	path := scanConfig
+	if path == "" {
+		path = config.FindProjectFile(".")
+	}
+	if path == "" {
+		return nil, nil
+	}
+	project, err := config.LoadProject(path)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Msg().Scan.ProjectLoadError, path, err)
+	}
+	return project, nil
+}
+
+// projectPolicies returns the policy specifications of the project, with local
+// policy paths resolved against the project root.
+func projectPolicies(project *config.Project) []string {
+	var specs []string
+	for _, spec := range project.Policies {
+		if !strings.HasPrefix(spec, "rule:") && !strings.HasPrefix(spec, "pack:") {
+			spec = project.ResolvePath(spec)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// applyProjectDefaults fills the scan flags from the project configuration.
+// Flags given on the command line win; project inputs are applied before
+// --input values, so those override them key by key.
+func applyProjectDefaults(cmd *cobra.Command, project *config.Project) {
+	flags := cmd.Flags()
+	if !flags.Changed("policy") {
+		scanPolicies = projectPolicies(project)
+	}
+	if !flags.Changed("format") && project.Format != "" {
+		scanFormat = project.Format
+	}
+	if !flags.Changed("fail-on") && project.FailOn != "" {
+		scanFailOn = project.FailOn
+	}
+	if len(project.Inputs) > 0 {
+		inputs := make([]string, 0, len(project.Inputs)+len(scanInput))
+		for _, input := range project.Inputs {
+			if path := project.ResolvePath(input); fileExists(path) {
+				input = path
+			}
+			inputs = append(inputs, input)
+		}
+		scanInput = append(inputs, scanInput...)
+	}
+}
+
+// changedTemplates keeps the templates that changed since the --changed-since
+// ref. A Terraform template stands for its directory and the local modules it
+// calls, so it is kept when any .tf file in those directories, or a variable
+// definitions file in its directory, changed.
+func changedTemplates(files []string, iacTypes map[string]string, changes *gitdiff.Changes) []string {
+	kept := files[:0]
+	for _, f := range files {
+		if templateChanged(f, iacTypes[f], changes) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+func templateChanged(path, iacType string, changes *gitdiff.Changes) bool {
+	if changes.HasFile(path) {
+		return true
+	}
+	if iacType != "terraform" {
+		return false
+	}
+	// Variable files are only loaded from the root directory.
+	dir := filepath.Dir(path)
+	if changes.HasDirFile(dir, isTerraformInput) {
+		return true
+	}
+	for _, d := range terraform.LocalModuleDirs(dir) {
+		if d != dir && changes.HasDirFile(d, terraform.IsTerraformFile) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerraformInput reports whether path is a .tf file or a variable definitions
+// file, which both feed the evaluation of a Terraform directory.
+func isTerraformInput(path string) bool {
+	return terraform.IsTerraformFile(path) || terraform.IsVarsFile(path)
+}
+
+// dropUnchangedViolations removes the violations whose resource block does not
+// overlap a line changed since the --changed-since ref.
+func dropUnchangedViolations(results []models.FileResult, resLinesByFile map[string][]waiver.ResourceLine, changes *gitdiff.Changes) {
+	for i := range results {
+		kept := results[i].Violations[:0]
+		for _, v := range results[i].Violations {
+			start, end := resourceBlock(v, resLinesByFile[v.File])
+			if changes.Overlaps(v.File, start, end) {
+				kept = append(kept, v)
+			}
+		}
+		results[i].Violations = kept
+	}
+}
+
+// resourceBlock returns the line range of the resource a violation belongs to:
+// from the resource's start line to the line before the next resource, with an
+// end of 0 for the end of the file. If the resource's start line is unknown,
+// the range is the violation's own line.
+func resourceBlock(v models.RichViolation, resources []waiver.ResourceLine) (start, end int) {
+	for _, r := range resources {
+		if r.ID == v.ResourceID {
+			start = r.Line
+			break
+		}
+	}
+	if start == 0 {
+		return v.Line, v.Line
+	}
+	for _, r := range resources {
+		if r.Line > start && (end == 0 || r.Line-1 < end) {
+			end = r.Line - 1
+		}
+	}
+	return start, end
+}
+
+// excludeTemplates drops the templates matching the project exclude globs.
+func excludeTemplates(files []string, project *config.Project) []string {
+	kept := files[:0]
+	for _, f := range files {
+		if !project.IsExcluded(f) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// applySeverityOverrides replaces the severity of violations of the rules that
+// the project configuration overrides.
+func applySeverityOverrides(results []models.FileResult, project *config.Project) {
+	if len(project.Severity) == 0 {
+		return
+	}
+	for i := range results {
+		for j := range results[i].Violations {
+			v := &results[i].Violations[j]
+			if severity := project.SeverityFor(v.ID); severity != "" {
+				v.Severity = severity
+			}
+		}
+	}
+}
+
+// assignFingerprints sets the fingerprint of every violation, taking file
+// paths relative to the workspace root.
+func assignFingerprints(results []models.FileResult, iacTypes map[string]string, root string) {
+	for i := range results {
+		iacType := iacTypes[results[i].File]
+		for j := range results[i].Violations {
+			v := &results[i].Violations[j]
+			v.Fingerprint = models.Fingerprint(v.ID, iacType, workspaceRelPath(root, v.File), v.ResourceID, v.ViolationPath)
+		}
+	}
+}
+
+// workspaceRelPath returns path relative to the workspace root, or path itself
+// if it lies outside the root.
+func workspaceRelPath(root, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// absPaths returns the absolute forms of paths, keeping any that cannot be
+// made absolute as they are.
+func absPaths(paths []string) []string {
+	abs := make([]string, len(paths))
+	for i, path := range paths {
+		if p, err := filepath.Abs(path); err == nil {
+			path = p
+		}
+		abs[i] = path
+	}
+	return abs
+}
+
+// failsAt reports whether a violation of the given severity fails a scan with
+// the fail threshold failOn.
+func failsAt(severity, failOn string) bool {
+	return failOn != models.SeverityNone && models.SeverityAtLeast(severity, failOn)
+}
+
+// fileExists reports whether path names an existing regular file.
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// writeBaseline records the violations that are not waived in the
+// --baseline-write file, with files relative to the workspace root.
+func writeBaseline(results []models.FileResult, root string) error {
+	msg := i18n.Msg()
+	b := baseline.FromResults(results, scanFailOnExpired, root)
+	if err := baseline.Save(scanBaselineWrite, b); err != nil {
+		return fmt.Errorf(msg.Scan.BaselineWriteError, scanBaselineWrite, err)
+	}
+	fmt.Fprintf(os.Stdout, msg.Scan.BaselineWritten+"\n", len(b.Entries), scanBaselineWrite)
+	return nil
+}
+
+// applyBaseline marks the violations found in the --baseline file and lists the
+// baseline entries of the scanned files that no longer occur, so the baseline
+// can be shrunk. Entry files are resolved against the workspace root.
+func applyBaseline(results []models.FileResult, iacTypes map[string]string, root string) error {
+	msg := i18n.Msg()
+	b, err := baseline.Load(scanBaseline)
+	if err != nil {
+		return fmt.Errorf(msg.Scan.BaselineLoadError, scanBaseline, err)
+	}
+	resolved := b.Apply(results, scanFailOnExpired, root, scannedSource(results, iacTypes))
+	if len(resolved) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, msg.Scan.BaselineResolved+"\n", len(resolved), scanBaseline)
+	for _, e := range resolved {
+		fmt.Fprintf(os.Stderr, "  - %s  %s  %s\n", e.Rule, e.File, e.Resource)
+	}
+	return nil
+}
+
+// scannedSource returns a function reporting whether a source file was covered
+// by the scanned templates: a ROS template itself, or a file of a scanned
+// Terraform directory or of a local module it calls.
+func scannedSource(results []models.FileResult, iacTypes map[string]string) func(path string) bool {
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, fr := range results {
+		files[fr.File] = true
+		if iacTypes[fr.File] == "terraform" {
+			dir := filepath.Dir(fr.File)
+			dirs[dir] = true
+			for _, d := range terraform.LocalModuleDirs(dir) {
+				dirs[d] = true
+			}
+		}
+	}
+	return func(path string) bool {
+		// A violation that could not be mapped to a file is reported on its directory.
+		return files[path] || dirs[path] || dirs[filepath.Dir(path)]
+	}
+}
+
+// loadRuleParams returns the rule parameter overrides: the params of the
+// project configuration, overridden by those of the --params file.
+func loadRuleParams(project *config.Project) (map[string]map[string]interface{}, error) {
+	var params map[string]map[string]interface{}
+	if project != nil {
+		params = project.Params
+	}
+	if scanParams == "" {
+		return params, nil
+	}
+	fileParams, err := config.LoadParams(scanParams)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Msg().Scan.ParamsLoadError, scanParams, err)
+	}
+	return config.MergeParams(params, fileParams), nil
+}
+
+// warnRuleParams warns about parameter overrides for unknown rules or naming
+// parameters their rule does not declare. Overrides for compiled rules are
+// already checked by the engine, so only the others are checked, against
+// the policy index.
+func warnRuleParams(params map[string]map[string]interface{}, index *models.PolicyIndex, evaluators map[string]*engine.Evaluator) {
+	msg := i18n.Msg()
+	for _, key := range sortedKeys(params) {
+		if evaluatorsHaveRule(evaluators, key) {
+			continue
+		}
+		rule := indexedRule(index, key)
+		if rule == nil {
+			fmt.Fprintf(os.Stderr, msg.Scan.ParamsUnknownRule+"\n", key)
+			continue
+		}
+		declared := make(map[string]bool, len(rule.Params))
+		for _, decl := range rule.Params {
+			declared[decl.Name] = true
+		}
+		for _, name := range sortedKeys(params[key]) {
+			if !declared[name] {
+				fmt.Fprintf(os.Stderr, msg.Scan.ParamsUnknownParam+"\n", key, name)
+			}
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// evaluatorsHaveRule reports whether any of the evaluators compiled the rule
+// with the given short or full ID.
+func evaluatorsHaveRule(evaluators map[string]*engine.Evaluator, ruleID string) bool {
+	for _, evaluator := range evaluators {
+		if evaluator != nil && evaluator.HasRule(ruleID) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexedRule returns the rule of the index with the given short or full ID,
+// or nil if there is none.
+func indexedRule(index *models.PolicyIndex, ruleID string) *models.Rule {
+	if index == nil {
+		return nil
+	}
+	if rule, ok := index.Rules[ruleID]; ok {
+		return rule
+	}
+	for _, rule := range index.RuleList {
+		if extractShortRuleID(rule.ID) == ruleID {
+			return rule
+		}
+	}
+	return nil
+}
+
+// templateScan is the outcome of scanning a single template.
+type templateScan struct {
+	result   models.FileResult
+	resLines map[string][]waiver.ResourceLine // Resource start lines by source file, for inline waivers
+	loadErr  error                            // The template could not be loaded; it is reported and skipped
+	evalErr  error                            // Policy evaluation failed; the scan is aborted
+}
+
+// templateIaCType classifies a template file by IaC type: "terraform" for a .tf
+// file standing for its directory or a plan JSON file, "ros" otherwise. Plan
+// detection reads the file, so templates are classified once when collected.
+func templateIaCType(templatePath string) string {
+	if terraform.IsTerraformFile(templatePath) || terraform.IsPlanFile(templatePath) {
+		return "terraform"
+	}
+	return "ros"
+}
+
+// scanTemplate loads a template, evaluates the policies for its IaC type, and maps
+// the violations to source locations. Rules that exceed --eval-timeout are recorded
+// in the result's TimedOutRules. It is safe to call concurrently.
+func scanTemplate(ctx context.Context, templatePath, iacType string, inputParams map[string]interface{}, evaluators map[string]*engine.Evaluator, lang string) templateScan {
+	// Use unified loading logic for both static and preview modes
+	yamlRoot, templateData, err := loadTemplateWithMode(templatePath, iacType, inputParams, scanMode)
+	if err != nil {
+		return templateScan{loadErr: err}
+	}
+
+	evaluator := evaluators[iacType]
+
+	// Skip evaluation if no applicable policies after filtering
+	if evaluator == nil {
+		return templateScan{result: models.FileResult{File: templatePath}}
+	}
+
+	// Evaluate policies
+	evalResult, err := evaluator.Evaluate(ctx, templateData)
+	if err != nil {
+		return templateScan{evalErr: err}
+	}
+
+	// Map violations to source locations with i18n support
+	var richViolations []models.RichViolation
+	if iacType == "terraform" {
+		richViolations = mapper.MapTerraformViolations(evalResult.Violations, templateData, filepath.Dir(templatePath), lang)
+	} else {
+		richViolations = mapper.MapViolationsWithLang(evalResult.Violations, yamlRoot, templatePath, lang)
+	}
+
+	// Sort violations by severity
+	sort.Slice(richViolations, func(i, j int) bool {
+		return models.SeverityOrder(richViolations[i].Severity) < models.SeverityOrder(richViolations[j].Severity)
+	})
+
+	// Collect resource start lines for inline waiver attribution.
+	var resLines map[string][]waiver.ResourceLine
+	if !scanNoWaivers {
+		resLines = templateResourceLines(templatePath, iacType, yamlRoot, templateData)
+	}
+
+	return templateScan{
+		result: models.FileResult{
+			File:           templatePath,
+			Violations:     richViolations,
+			EvaluatedRules: evalResult.EvaluatedRules,
+			TimedOutRules:  evalResult.TimedOutRules,
+		},
+		resLines: resLines,
+	}
+}
+
+// forEachParallel calls fn for every index in [0, n) using up to jobs goroutines.
+// It calls done for each index in index order, on the calling goroutine, as soon
+// as fn has finished for that index and all lower ones. If done returns an error,
+// the indexes not yet started are skipped and the error is returned once the
+// running calls have finished.
+func forEachParallel(n, jobs int, fn func(i int), done func(i int) error) error {
+	if jobs > n {
+		jobs = n
+	}
+	if jobs <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+			if err := done(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	finished := make([]chan struct{}, n)
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+	indexes := make(chan int)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+				close(finished[i])
+			}
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for i := 0; i < n; i++ {
+			select {
+			case indexes <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var err error
+	for i := 0; i < n && err == nil; i++ {
+		<-finished[i]
+		err = done(i)
+	}
+	close(stop)
+	wg.Wait()
+	return err
+}
+
+// reportRules returns rule metadata for the formats that describe rules (SARIF),
+// and nil for the others, from the loader of the scan. Rules from .rego files
+// outside the policy index are simply absent.
+func reportRules(format string, loader *policy.Loader) []*models.Rule {
+	if format != "sarif" || loader == nil {
+		return nil
+	}
+	return loader.GetAllRules()
+}
+
 // collectTemplates recursively finds all supported template files in the given paths.
-func collectTemplates(paths []string) ([]string, error) {
+// It also returns the IaC type of each template by path (see templateIaCType).
+func collectTemplates(paths []string) ([]string, map[string]string, error) {
 	var files []string
 	seen := make(map[string]bool)
 	seenTFDirs := make(map[string]bool)
@@ -358,13 +993,13 @@ func collectTemplates(paths []string) ([]string, error) {
 	for _, path := range paths {
 		info, err := os.Stat(path)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if !info.IsDir() {
 			absPath, err := filepath.Abs(path)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			if !seen[absPath] && isTemplateFile(absPath) {
 				if terraform.IsTerraformFile(absPath) {
@@ -385,6 +1020,10 @@ func collectTemplates(paths []string) ([]string, error) {
 			if err != nil {
 				return err
 			}
+			// .infraguard holds the project configuration, waivers and parameters
+			if d.IsDir() && d.Name() == ".infraguard" {
+				return filepath.SkipDir
+			}
 			if !d.IsDir() && isTemplateFile(p) {
 				absPath, err := filepath.Abs(p)
 				if err != nil {
@@ -405,13 +1044,70 @@ func collectTemplates(paths []string) ([]string, error) {
 			return nil
 		})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
-	return files, nil
+	files = dropCalledTFModules(files, seenTFDirs)
+
+	iacTypes := make(map[string]string, len(files))
+	for _, f := range files {
+		iacTypes[f] = templateIaCType(f)
+	}
+	return preferTFPlans(files, iacTypes), iacTypes, nil
+}
+
+// preferTFPlans drops the .tf template of a directory that also holds a plan JSON
+// file. The plan has the fully resolved values of the same configuration.
+func preferTFPlans(files []string, iacTypes map[string]string) []string {
+	planDirs := make(map[string]bool)
+	for _, f := range files {
+		if iacTypes[f] == "terraform" && !terraform.IsTerraformFile(f) {
+			planDirs[filepath.Dir(f)] = true
+		}
+	}
+	if len(planDirs) == 0 {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if terraform.IsTerraformFile(f) && planDirs[filepath.Dir(f)] {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// dropCalledTFModules removes Terraform templates whose directory is a local module
+// called by another scanned Terraform directory; the caller's scan covers them
+// under module-qualified resource IDs.
+func dropCalledTFModules(files []string, tfDirs map[string]bool) []string {
+	called := make(map[string]bool)
+	for dir := range tfDirs {
+		for _, m := range terraform.LocalModuleDirs(dir) {
+			if m != dir {
+				called[m] = true
+			}
+		}
+	}
+	if len(called) == 0 {
+		return files
+	}
+	kept := files[:0]
+	for _, f := range files {
+		if terraform.IsTerraformFile(f) && called[filepath.Dir(f)] {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
 }
 
 func isTemplateFile(path string) bool {
+	// terraform.tfvars.json holds variable values, not a template
+	if terraform.IsVarsFile(path) {
+		return false
+	}
 	ext := strings.ToLower(filepath.Ext(path))
 	return ext == ".json" || ext == ".yaml" || ext == ".yml" || ext == ".tf"
 }
@@ -439,9 +1135,10 @@ type ruleIaCInfo struct {
 	ruleID   string
 }
 
-// buildEvalOptions builds engine evaluation options from policy specs.
+// buildEvalOptions builds engine evaluation options from policy specs, resolving
+// IDs with policyLoader, which is nil if the policies could not be loaded.
 // Returns the eval options and a map from module file path to its IaC type info.
-func buildEvalOptions(specs []*PolicySpec, msg *i18n.Messages) (*engine.EvalOptions, map[string]*ruleIaCInfo, error) {
+func buildEvalOptions(specs []*PolicySpec, policyLoader *policy.Loader, msg *i18n.Messages) (*engine.EvalOptions, map[string]*ruleIaCInfo, error) {
 	opts := &engine.EvalOptions{
 		PolicyPaths: []string{},
 		RuleIDs:     []string{},
@@ -453,8 +1150,7 @@ func buildEvalOptions(specs []*PolicySpec, msg *i18n.Messages) (*engine.EvalOpti
 
 	// If no specs provided, try using fallback loader (embedded + local)
 	if len(specs) == 0 {
-		policyLoader, err := policy.LoadWithFallback()
-		if err == nil {
+		if policyLoader != nil {
 			opts.Modules = make(map[string]string)
 			opts.LibModules = make(map[string]string)
 			for _, rule := range policyLoader.GetAllRules() {
@@ -483,14 +1179,8 @@ func buildEvalOptions(specs []*PolicySpec, msg *i18n.Messages) (*engine.EvalOpti
 		return opts, iacMap, nil
 	}
 
-	// Load policy index for rule/pack ID resolution and helper loading
-	var policyLoader *policy.Loader
-	var err error
-	policyLoader, err = policy.LoadWithFallback()
-	if err != nil {
-		// Log error but continue if only local files are provided
-		// If ID specs are present, this will fail later anyway if they are not in local files
-	}
+	// Without a policy loader, only local files can be evaluated; rule and pack
+	// specs then match nothing.
 
 	// Build ID mapping and populate LibModules
 	if policyLoader != nil {
@@ -690,20 +1380,11 @@ func formatAndPrintError(templatePath string, err error, msg *i18n.Messages) {
 }
 
 // applyWaivers annotates violations in results with matching waivers from inline
-// comments and the central waiver file.
-func applyWaivers(results []models.FileResult, resLinesByFile map[string][]waiver.ResourceLine) error {
-	inlineByFile := make(map[string]map[string][]waiver.Inline)
-	for file, rls := range resLinesByFile {
-		content, err := os.ReadFile(file)
-		if err != nil {
-			continue
-		}
-		ins := waiver.ParseInline(file, string(content))
-		if len(ins) == 0 {
-			continue
-		}
-		inlineByFile[file] = waiver.AttributeInline(ins, rls)
-	}
+// comments and the central waiver file. index resolves pack waivers; if the
+// policies failed to load with indexErr, a waiver file with pack waivers is an
+// error rather than silently matching nothing.
+func applyWaivers(results []models.FileResult, resLinesByFile map[string][]waiver.ResourceLine, index *models.PolicyIndex, indexErr error) error {
+	inlineByFile, _ := parseInlineWaivers(resLinesByFile)
 
 	set := &waiver.Set{}
 	wpath := scanWaivers
@@ -716,12 +1397,60 @@ func applyWaivers(results []models.FileResult, resLinesByFile map[string][]waive
 			return fmt.Errorf(i18n.Get(func(m *i18n.Messages) string { return m.Scan.WaiverLoadError }), wpath, err)
 		}
 		set = loaded
+		set.Index = index
+		if indexErr != nil && hasPackWaivers(set) {
+			return fmt.Errorf(i18n.Msg().Scan.WaiverPacksUnresolved, wpath, indexErr)
+		}
 	}
 
 	set.Annotate(results, inlineByFile, time.Now())
 	return nil
 }
 
+// hasPackWaivers reports whether any waiver of set is limited to packs.
+func hasPackWaivers(set *waiver.Set) bool {
+	for _, w := range set.Waivers {
+		if w.Pack != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInlineWaivers parses the inline waiver directives of the source files in
+// resLinesByFile. It returns the directives attributed to resources, keyed by
+// file and resource ID, and the list of all directives.
+func parseInlineWaivers(resLinesByFile map[string][]waiver.ResourceLine) (map[string]map[string][]waiver.Inline, []waiver.Inline) {
+	inlineByFile := make(map[string]map[string][]waiver.Inline)
+	var all []waiver.Inline
+	for file, rls := range resLinesByFile {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		ins := waiver.ParseInline(file, string(content))
+		if len(ins) == 0 {
+			continue
+		}
+		inlineByFile[file] = waiver.AttributeInline(ins, rls)
+		all = append(all, ins...)
+	}
+	return inlineByFile, all
+}
+
+// templateResourceLines returns the start lines of the resources of a loaded
+// template by source file. A Terraform template also covers the other files in
+// its directory and its local modules.
+func templateResourceLines(templatePath, iacType string, yamlRoot *yaml.Node, templateData map[string]interface{}) map[string][]waiver.ResourceLine {
+	if iacType == "terraform" {
+		return extractTFResourceLines(templateData, filepath.Dir(templatePath))
+	}
+	if yamlRoot == nil {
+		return nil
+	}
+	return map[string][]waiver.ResourceLine{templatePath: extractROSResourceLines(yamlRoot)}
+}
+
 // extractROSResourceLines returns the start line of each top-level resource in a
 // ROS template, used to attribute inline waiver comments.
 func extractROSResourceLines(root *yaml.Node) []waiver.ResourceLine {
@@ -780,8 +1509,12 @@ func extractTFResourceLines(data map[string]interface{}, dir string) map[string]
 			if filename == "" || line <= 0 {
 				continue
 			}
+			id := resType + "." + name
+			if addr, ok := meta["address"].(string); ok && addr != "" {
+				id = addr // Resource from a child module
+			}
 			file := filepath.Join(dir, filename)
-			out[file] = append(out[file], waiver.ResourceLine{ID: resType + "." + name, Line: line})
+			out[file] = append(out[file], waiver.ResourceLine{ID: id, Line: line})
 		}
 	}
 	return out
@@ -799,13 +1532,23 @@ func toInt(v interface{}) int {
 	return 0
 }
 
-// loadTemplateWithMode loads a template using the specified mode (static or preview)
-func loadTemplateWithMode(templatePath string, inputParams map[string]interface{}, mode string) (*yaml.Node, map[string]interface{}, error) {
+// loadTemplateWithMode loads a template of the given IaC type using the specified
+// mode (static or preview)
+func loadTemplateWithMode(templatePath, iacType string, inputParams map[string]interface{}, mode string) (*yaml.Node, map[string]interface{}, error) {
 	msg := i18n.Msg()
 
+	// Terraform plan JSON (terraform show -json): fully resolved values
+	if iacType == "terraform" && !terraform.IsTerraformFile(templatePath) {
+		opaInput, err := terraform.LoadPlan(templatePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, opaInput, nil
+	}
+
 	// Terraform detection: .tf extension — load the entire directory as a Terraform module
 	if terraform.IsTerraformFile(templatePath) {
-		opaInput, err := terraform.Load(templatePath, inputParams)
+		opaInput, err := terraform.LoadWithOptions(templatePath, inputParams, terraform.LoadOptions{Env: os.Environ()})
 		if err != nil {
 			return nil, nil, err
 		}
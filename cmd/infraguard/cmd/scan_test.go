@@ -2,12 +2,22 @@ package cmd
 
 import (
 	"bytes"
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aliyun/infraguard/pkg/config"
+	"github.com/aliyun/infraguard/pkg/engine"
+	"github.com/aliyun/infraguard/pkg/gitdiff"
 	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/aliyun/infraguard/pkg/providers/terraform"
+	"github.com/aliyun/infraguard/pkg/waiver"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -221,6 +231,42 @@ func TestIsTemplateFile_TerraformSupport(t *testing.T) {
 		Convey("Non-template files should not be recognized", func() {
 			So(isTemplateFile("readme.md"), ShouldBeFalse)
 			So(isTemplateFile("main.go"), ShouldBeFalse)
+			So(isTemplateFile("terraform.tfvars.json"), ShouldBeFalse)
+			So(isTemplateFile("prod.auto.tfvars.json"), ShouldBeFalse)
+		})
+	})
+}
+
+func TestCollectTemplates_TerraformModules(t *testing.T) {
+	Convey("Given a Terraform root that calls a local module", t, func() {
+		dir := t.TempDir()
+		modDir := filepath.Join(dir, "modules", "vpc")
+		So(os.MkdirAll(modDir, 0755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte("module \"vpc\" {\n  source = \"./modules/vpc\"\n}\n"), 0644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(modDir, "main.tf"), []byte("resource \"alicloud_vpc\" \"main\" {}\n"), 0644), ShouldBeNil)
+
+		Convey("Scanning the directory should not scan the module on its own", func() {
+			files, _, err := collectTemplates([]string{dir})
+			So(err, ShouldBeNil)
+			So(files, ShouldHaveLength, 1)
+			So(filepath.Base(filepath.Dir(files[0])), ShouldEqual, filepath.Base(dir))
+		})
+	})
+}
+
+func TestCollectTemplates_TerraformPlan(t *testing.T) {
+	Convey("Given a Terraform directory with a plan JSON file", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"alicloud_vpc\" \"main\" {}\n"), 0644), ShouldBeNil)
+		plan := `{"format_version": "1.2", "planned_values": {"root_module": {}}}`
+		So(os.WriteFile(filepath.Join(dir, "plan.json"), []byte(plan), 0644), ShouldBeNil)
+
+		Convey("The plan should be scanned instead of the HCL", func() {
+			files, iacTypes, err := collectTemplates([]string{dir})
+			So(err, ShouldBeNil)
+			So(files, ShouldHaveLength, 1)
+			So(filepath.Base(files[0]), ShouldEqual, "plan.json")
+			So(iacTypes[files[0]], ShouldEqual, "terraform")
 		})
 	})
 }
@@ -310,3 +356,315 @@ func TestParsePolicySpec(t *testing.T) {
 		})
 	})
 }
+
+func TestForEachParallel(t *testing.T) {
+	Convey("Given work items scanned with a worker pool", t, func() {
+		Convey("Every index should be processed once with at most jobs in flight", func() {
+			var mu sync.Mutex
+			inFlight, maxInFlight := 0, 0
+			done := make([]int, 50)
+			forEachParallel(len(done), 4, func(i int) {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				done[i]++
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}, func(i int) error { return nil })
+			for _, n := range done {
+				So(n, ShouldEqual, 1)
+			}
+			So(maxInFlight, ShouldBeLessThanOrEqualTo, 4)
+		})
+
+		Convey("Outcomes should be handled in index order as they complete", func() {
+			var handled []int
+			err := forEachParallel(20, 4, func(i int) {
+				time.Sleep(time.Duration(20-i) * 100 * time.Microsecond)
+			}, func(i int) error {
+				handled = append(handled, i)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(handled, ShouldHaveLength, 20)
+			for i, n := range handled {
+				So(n, ShouldEqual, i)
+			}
+		})
+
+		Convey("An error should stop the indexes not yet started", func() {
+			var mu sync.Mutex
+			started := 0
+			stopErr := errors.New("stop")
+			err := forEachParallel(100, 2, func(i int) {
+				mu.Lock()
+				started++
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+			}, func(i int) error {
+				if i == 1 {
+					return stopErr
+				}
+				return nil
+			})
+			So(err, ShouldEqual, stopErr)
+			So(started, ShouldBeLessThan, 100)
+		})
+
+		Convey("A single job should run in order", func() {
+			var order []int
+			err := forEachParallel(5, 1, func(i int) { order = append(order, i) }, func(i int) error {
+				if i == 2 {
+					return errors.New("stop")
+				}
+				return nil
+			})
+			So(err, ShouldNotBeNil)
+			So(order, ShouldResemble, []int{0, 1, 2})
+		})
+	})
+}
+
+func TestResourceFocus(t *testing.T) {
+	Convey("Given template inputs with several resources", t, func() {
+		Convey("A ROS resource should be found by its logical ID", func() {
+			input := map[string]interface{}{
+				"ROSTemplateFormatVersion": "2015-09-01",
+				"Resources": map[string]interface{}{
+					"A": map[string]interface{}{"Type": "ALIYUN::OSS::Bucket"},
+					"B": map[string]interface{}{"Type": "ALIYUN::ECS::Instance"},
+				},
+			}
+			focus := resourceFocus(input, "ros", "A")
+			So(focus, ShouldNotBeNil)
+			So(focus.Name, ShouldEqual, "A")
+			So(focus.Value, ShouldResemble, map[string]interface{}{"Type": "ALIYUN::OSS::Bucket"})
+			So(focus.ResourceIDs, ShouldResemble, []string{"A"})
+
+			So(resourceFocus(input, "ros", "C"), ShouldBeNil)
+		})
+
+		Convey("A Terraform resource should be found by its address", func() {
+			dir := t.TempDir()
+			modDir := filepath.Join(dir, "modules", "network")
+			So(os.MkdirAll(modDir, 0755), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "alicloud_vswitch" "main" {
+  vswitch_name = "root"
+}
+
+module "network" {
+  source = "./modules/network"
+}
+`), 0644), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(modDir, "main.tf"), []byte(`resource "alicloud_vswitch" "main" {
+  vswitch_name = "module"
+}
+`), 0644), ShouldBeNil)
+			input, err := terraform.Load(filepath.Join(dir, "main.tf"), nil)
+			So(err, ShouldBeNil)
+
+			focus := resourceFocus(input, "terraform", "alicloud_vswitch.main")
+			So(focus, ShouldNotBeNil)
+			So(focus.Name, ShouldEqual, "main")
+			So(focus.Value.(map[string]interface{})["vswitch_name"], ShouldEqual, "root")
+
+			Convey("A module resource should be found by its module address", func() {
+				focus := resourceFocus(input, "terraform", "module.network.alicloud_vswitch.main")
+				So(focus, ShouldNotBeNil)
+				So(focus.Name, ShouldEqual, "module.network.main")
+				So(focus.Value.(map[string]interface{})["vswitch_name"], ShouldEqual, "module")
+				So(focus.ResourceIDs, ShouldContain, "alicloud_vswitch.module.network.main")
+				So(focus.ResourceIDs, ShouldContain, "module.network.alicloud_vswitch.main")
+			})
+
+			So(resourceFocus(input, "terraform", "alicloud_vswitch"), ShouldBeNil)
+			So(resourceFocus(input, "terraform", "alicloud_vpc.main"), ShouldBeNil)
+		})
+	})
+}
+
+func TestRenderProfile(t *testing.T) {
+	Convey("Given per-rule profile statistics", t, func() {
+		rules := []engine.RuleProfile{
+			{
+				RuleID:      "rule:aliyun:slow-rule",
+				TotalTimeNs: int64(3 * time.Millisecond),
+				Evaluations: 2,
+				TimedOut:    1,
+				SlowestExpr: []engine.ExprProfile{
+					{Location: "slow.rego:10", Expr: "x := input.a", TotalTimeNs: int64(2 * time.Millisecond), NumEval: 2},
+				},
+			},
+		}
+
+		Convey("The table should show times, counts and the slowest expressions", func() {
+			var buf bytes.Buffer
+			renderProfile(&buf, rules, i18n.Msg())
+			out := buf.String()
+			So(out, ShouldContainSubstring, "rule:aliyun:slow-rule")
+			So(out, ShouldContainSubstring, "3ms")
+			So(out, ShouldContainSubstring, "1.5ms")
+			So(out, ShouldContainSubstring, "1 timed out")
+			So(out, ShouldContainSubstring, "slow.rego:10")
+			So(out, ShouldContainSubstring, "x := input.a")
+		})
+	})
+}
+
+func TestFailsAt(t *testing.T) {
+	Convey("Given a fail threshold", t, func() {
+		So(failsAt("high", "none"), ShouldBeFalse)
+		So(failsAt("high", "medium"), ShouldBeTrue)
+		So(failsAt("medium", "medium"), ShouldBeTrue)
+		So(failsAt("low", "medium"), ShouldBeFalse)
+		So(failsAt("low", "low"), ShouldBeTrue)
+	})
+}
+
+func TestResourceBlock(t *testing.T) {
+	Convey("Given the start lines of the resources in a file", t, func() {
+		resources := []waiver.ResourceLine{{ID: "A", Line: 3}, {ID: "C", Line: 20}, {ID: "B", Line: 10}}
+
+		Convey("A resource should end before the next one", func() {
+			start, end := resourceBlock(models.RichViolation{ResourceID: "A", Line: 5}, resources)
+			So(start, ShouldEqual, 3)
+			So(end, ShouldEqual, 9)
+		})
+
+		Convey("The last resource should extend to the end of the file", func() {
+			start, end := resourceBlock(models.RichViolation{ResourceID: "C", Line: 22}, resources)
+			So(start, ShouldEqual, 20)
+			So(end, ShouldEqual, 0)
+		})
+
+		Convey("An unknown resource should fall back to the violation line", func() {
+			start, end := resourceBlock(models.RichViolation{ResourceID: "X", Line: 7}, resources)
+			So(start, ShouldEqual, 7)
+			So(end, ShouldEqual, 7)
+		})
+	})
+}
+
+func TestTemplateChanged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	Convey("Given a Terraform directory in a git repository", t, func() {
+		root := t.TempDir()
+		run := func(args ...string) {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = root
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+			So(cmd.Run(), ShouldBeNil)
+		}
+		write := func(name, content string) {
+			So(os.WriteFile(filepath.Join(root, name), []byte(content), 0644), ShouldBeNil)
+		}
+
+		run("init", "-q")
+		write("main.tf", "variable \"acl\" {}\n")
+		write("prod.auto.tfvars", "acl = \"private\"\n")
+		run("add", "-A")
+		run("commit", "-q", "-m", "init")
+		mainTF := filepath.Join(root, "main.tf")
+
+		Convey("An unchanged directory should not be scanned", func() {
+			changes, err := gitdiff.Since(root, "HEAD")
+			So(err, ShouldBeNil)
+			So(templateChanged(mainTF, "terraform", changes), ShouldBeFalse)
+		})
+
+		Convey("A changed variable file should select its directory", func() {
+			write("prod.auto.tfvars", "acl = \"public-read\"\n")
+			changes, err := gitdiff.Since(root, "HEAD")
+			So(err, ShouldBeNil)
+			So(templateChanged(mainTF, "terraform", changes), ShouldBeTrue)
+		})
+	})
+}
+
+func TestLoadRuleParams(t *testing.T) {
+	Convey("Given project params and a --params file", t, func() {
+		project := &config.Project{Params: map[string]map[string]interface{}{
+			"vswitch-available-ip-count": {"max_prefix_length": 26, "min_ip_count": 8},
+		}}
+		path := filepath.Join(t.TempDir(), "params.yaml")
+		content := "params:\n  vswitch-available-ip-count:\n    max_prefix_length: 24\n"
+		So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+
+		saved := scanParams
+		defer func() { scanParams = saved }()
+
+		Convey("Without --params the project params should be used", func() {
+			scanParams = ""
+			params, err := loadRuleParams(project)
+			So(err, ShouldBeNil)
+			So(params["vswitch-available-ip-count"]["max_prefix_length"], ShouldEqual, 26)
+		})
+
+		Convey("The --params file should override them parameter by parameter", func() {
+			scanParams = path
+			params, err := loadRuleParams(project)
+			So(err, ShouldBeNil)
+			So(params["vswitch-available-ip-count"]["max_prefix_length"], ShouldEqual, 24)
+			So(params["vswitch-available-ip-count"]["min_ip_count"], ShouldEqual, 8)
+		})
+	})
+}
+
+func TestIndexedRule(t *testing.T) {
+	Convey("Given a policy index", t, func() {
+		rule := &models.Rule{ID: "rule:aliyun:vswitch-available-ip-count"}
+		index := &models.PolicyIndex{
+			Rules:    map[string]*models.Rule{rule.ID: rule},
+			RuleList: []*models.Rule{rule},
+		}
+
+		Convey("Rules should be found by short or full ID", func() {
+			So(indexedRule(index, rule.ID), ShouldEqual, rule)
+			So(indexedRule(index, "vswitch-available-ip-count"), ShouldEqual, rule)
+		})
+
+		Convey("Unknown rules should not be found", func() {
+			So(indexedRule(index, "no-such-rule"), ShouldBeNil)
+			So(indexedRule(nil, rule.ID), ShouldBeNil)
+		})
+	})
+}
+
+func TestApplyWaiversPolicyLoadError(t *testing.T) {
+	Convey("Given policies that failed to load", t, func() {
+		loadErr := errors.New("no policies found")
+		path := filepath.Join(t.TempDir(), "waivers.yaml")
+		saved := scanWaivers
+		defer func() { scanWaivers = saved }()
+		scanWaivers = path
+		results := []models.FileResult{{
+			File:       "t.yaml",
+			Violations: []models.RichViolation{{ID: "rule:aliyun:a", ResourceID: "A", File: "t.yaml"}},
+		}}
+
+		Convey("A waiver file with pack waivers should fail", func() {
+			content := "version: 1\nwaivers:\n  - pack: quick-start-compliance-pack\n    reason: sandbox\n"
+			So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			err := applyWaivers(results, nil, nil, loadErr)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, loadErr.Error())
+		})
+
+		Convey("A waiver file without pack waivers should still apply", func() {
+			content := "version: 1\nwaivers:\n  - rule: a\n    reason: known\n"
+			So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			So(applyWaivers(results, nil, nil, loadErr), ShouldBeNil)
+			So(results[0].Violations[0].Waiver, ShouldNotBeNil)
+		})
+	})
+}
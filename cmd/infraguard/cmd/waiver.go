@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
 	"github.com/aliyun/infraguard/pkg/policy"
 	"github.com/aliyun/infraguard/pkg/waiver"
 	"github.com/olekukonko/tablewriter"
@@ -83,21 +86,39 @@ func runWaiverList(cmd *cobra.Command, args []string) error {
 			Settings: tw.Settings{Separators: tw.Separators{BetweenRows: tw.On}},
 		}),
 	)
-	table.Header(msg.Waiver.List.HeaderRule, msg.Waiver.List.HeaderResource, msg.Waiver.List.HeaderStatus, msg.Waiver.List.HeaderExpires, msg.Waiver.List.HeaderReason)
+	table.Header(msg.Waiver.List.HeaderRule, msg.Waiver.List.HeaderResource, msg.Waiver.List.HeaderStatus,
+		msg.Waiver.List.HeaderCreated, msg.Waiver.List.HeaderExpires, msg.Waiver.List.HeaderApprovedBy,
+		msg.Waiver.List.HeaderTicket, msg.Waiver.List.HeaderReason)
 	for _, s := range statuses {
+		rule := s.Waiver.Rule
+		if rule == "" && (s.Waiver.Pack != "" || s.Waiver.MaxSeverity != "") {
+			rule = "*"
+		}
+		if rule == "" {
+			rule = s.Waiver.Fingerprint
+		}
+		rule = wrapText(rule, 32)
+		if s.Waiver.Pack != "" {
+			rule += "\n" + fmt.Sprintf(msg.Waiver.List.Pack, s.Waiver.Pack)
+		}
+		if s.Waiver.MaxSeverity != "" {
+			rule += "\n" + fmt.Sprintf(msg.Waiver.List.MaxSeverity, s.Waiver.MaxSeverity)
+		}
+		if s.Waiver.SeverityCeiling != "" {
+			rule += "\n" + fmt.Sprintf(msg.Waiver.List.Ceiling, s.Waiver.SeverityCeiling)
+		}
 		resource := s.Waiver.Resource
 		if resource == "" {
 			resource = "*"
 		}
-		expires := s.Expires
-		if expires == "" {
-			expires = "-"
-		}
 		table.Append(
-			wrapText(s.Waiver.Rule, 30),
+			rule,
 			wrapText(resource, 20),
 			colorWaiverState(s.State),
-			expires,
+			orDash(s.Waiver.Created),
+			orDash(s.Expires),
+			wrapText(orDash(s.Waiver.ApprovedBy), 20),
+			wrapText(orDash(s.Waiver.Ticket), 20),
 			wrapText(s.Waiver.Reason, 40),
 		)
 	}
@@ -106,6 +127,14 @@ func runWaiverList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// orDash returns s, or "-" if it is empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
 func colorWaiverState(state string) string {
 	msg := i18n.Msg()
 	switch state {
@@ -131,19 +160,46 @@ func customRuleDirs() []string {
 	return dirs
 }
 
-func runWaiverLint(cmd *cobra.Command, args []string) error {
-	set, err := resolveWaiverSet()
+// policyIndex returns the policy index that resolves pack waivers, or nil if
+// no policies are loadable.
+func policyIndex() *models.PolicyIndex {
+	loader, err := policy.LoadWithFallback()
 	if err != nil {
-		return err
+		return nil
+	}
+	return loader.GetIndex()
+}
+
+// waiverPolicies is what waiver entries are checked against, loaded once per
+// command.
+type waiverPolicies struct {
+	// Index resolves pack waivers; nil if no policies are loadable.
+	Index *models.PolicyIndex
+	// Severities is the severity of every known rule by short rule ID; nil if
+	// no rules are loadable.
+	Severities map[string]string
+}
+
+// loadWaiverPolicies loads the policy set once and returns its index and the
+// severities of the known rules.
+func loadWaiverPolicies() waiverPolicies {
+	loader, err := policy.LoadWithFallback()
+	if err != nil {
+		return waiverPolicies{Severities: knownRuleSeverities(nil)}
 	}
+	return waiverPolicies{Index: loader.GetIndex(), Severities: knownRuleSeverities(loader)}
+}
 
-	// Build the set of known short rule IDs for the unknown-rule check, combining
-	// built-in rules with any custom rules discovered in the workspace.
-	knownRules := make(map[string]bool)
+// knownRuleSeverities returns the severity of every known rule by short rule
+// ID, combining the rules of loader, which may be nil, with any custom rules
+// discovered in the workspace. It returns nil if no rules are loadable, in
+// which case rule IDs cannot be checked.
+func knownRuleSeverities(loader *policy.Loader) map[string]string {
+	known := make(map[string]string)
 	var extraModules []policy.RegoModule
-	if loader, err := policy.LoadWithFallback(); err == nil {
+	if loader != nil {
 		for _, rule := range loader.GetAllRules() {
-			knownRules[waiver.ShortRuleID(rule.ID)] = true
+			known[waiver.ShortRuleID(rule.ID)] = models.NormalizeSeverity(rule.Severity)
 		}
 		for name, content := range loader.GetLibModules() {
 			extraModules = append(extraModules, policy.RegoModule{Path: name, Content: content})
@@ -152,15 +208,42 @@ func runWaiverLint(cmd *cobra.Command, args []string) error {
 	for _, dir := range customRuleDirs() {
 		if rules, err := policy.DiscoverRulesWithExtraModules(dir, extraModules); err == nil {
 			for _, rule := range rules {
-				knownRules[waiver.ShortRuleID(rule.ID)] = true
+				known[waiver.ShortRuleID(rule.ID)] = models.NormalizeSeverity(rule.Severity)
 			}
 		}
 	}
-	if len(knownRules) == 0 {
-		knownRules = nil // Skip the unknown-rule check if nothing is loadable.
+	if len(known) == 0 {
+		return nil
 	}
+	return known
+}
 
-	issues := set.Lint(knownRules, time.Now())
+func runWaiverLint(cmd *cobra.Command, args []string) error {
+	set, err := resolveWaiverSet()
+	if err != nil {
+		return err
+	}
+
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+	policies := loadWaiverPolicies()
+	set.Index = policies.Index
+	severities := policies.Severities
+	var knownRules map[string]bool
+	if severities != nil {
+		knownRules = make(map[string]bool, len(severities))
+		for id := range severities {
+			knownRules[id] = true
+		}
+	}
+	now := time.Now()
+	issues := set.Lint(knownRules, now)
+	if project != nil {
+		issues = append(issues, set.Govern(project.Waivers.Require, severities, now)...)
+		sort.SliceStable(issues, func(i, j int) bool { return issues[i].Index < issues[j].Index })
+	}
 	if len(issues) == 0 {
 		greenColor.Printf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.Valid })+"\n", len(set.Waivers), set.Path)
 		return nil
@@ -200,6 +283,22 @@ func lintMessage(issue waiver.Issue) string {
 		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.Expired }), issue.Detail)
 	case waiver.CodePermanent:
 		return i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.Permanent })
+	case waiver.CodeInvalidCreated:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.InvalidCreated }), issue.Detail)
+	case waiver.CodeInvalidCeiling:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.InvalidCeiling }), issue.Detail, strings.Join(models.SeverityLevels(), ", "))
+	case waiver.CodeInvalidMaxSeverity:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.InvalidMaxSeverity }), issue.Detail, strings.Join(models.SeverityLevels(), ", "))
+	case waiver.CodeUnknownPack:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.UnknownPack }), issue.Detail)
+	case waiver.CodePackHigh:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.PackCoversHigh }), issue.Detail)
+	case waiver.CodeMissingApprover:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.MissingApprover }), issue.Detail)
+	case waiver.CodeMissingTicket:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.MissingTicket }), issue.Detail)
+	case waiver.CodeLifetime:
+		return fmt.Sprintf(i18n.Get(func(m *i18n.Messages) string { return m.Waiver.Lint.Lifetime }), issue.Detail)
 	}
 	return ""
 }
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aliyun/infraguard/pkg/config"
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/aliyun/infraguard/pkg/waiver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addRule         string
+	addResource     string
+	addFiles        []string
+	addFingerprints []string
+	addReason       string
+	addExpires      string
+	addOwner        string
+	addApprovedBy   string
+	addTicket       string
+	addCeiling      string
+	addPack         string
+	addMaxSeverity  string
+	addReport       string
+)
+
+var waiverAddCmd = &cobra.Command{
+	Use:          "add",
+	Short:        "", // Set dynamically
+	Long:         "", // Set dynamically
+	Args:         cobra.NoArgs,
+	RunE:         runWaiverAdd,
+	SilenceUsage: true,
+}
+
+func init() {
+	waiverCmd.AddCommand(waiverAddCmd)
+
+	f := waiverAddCmd.Flags()
+	f.StringVar(&addRule, "rule", "", "Rule ID to waive; with --report, select violations of this rule")
+	f.StringVar(&addResource, "resource", "", "Resource ID or glob; with --report, select violations of matching resources")
+	f.StringArrayVar(&addFiles, "file", nil, "File path glob (repeatable); with --report, select violations in matching files")
+	f.StringArrayVar(&addFingerprints, "fingerprint", nil, "Violation fingerprint (repeatable); with --report, select these violations")
+	f.StringVar(&addReason, "reason", "", "Justification for the waiver (required)")
+	f.StringVar(&addExpires, "expires", "", "Expiry date (YYYY-MM-DD)")
+	f.StringVar(&addOwner, "owner", "", "Person responsible for the waiver")
+	f.StringVar(&addApprovedBy, "approved-by", "", "Person who approved the exception")
+	f.StringVar(&addTicket, "ticket", "", "Tracking ticket, ID or URL")
+	f.StringVar(&addCeiling, "severity-ceiling", "", "Highest severity the waiver may cover: high, medium, or low")
+	f.StringVar(&addPack, "pack", "", "Pack ID or glob; waive only the rules of matching packs")
+	f.StringVar(&addMaxSeverity, "max-severity", "", "Waive only violations up to this severity: high, medium, or low")
+	f.StringVar(&addReport, "report", "", "JSON scan report to select violations from")
+	f.StringVar(&waiverRulesDir, "rules-dir", "", "Also treat rules under this directory as known (for custom rules)")
+}
+
+func runWaiverAdd(cmd *cobra.Command, args []string) error {
+	msg := i18n.Msg()
+	if addReason == "" {
+		return fmt.Errorf("%s", msg.Waiver.Add.ReasonRequired)
+	}
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+	maxDays := 0
+	if project != nil {
+		maxDays = project.Waivers.MaxExpiryDays
+	}
+	if err := checkWaiverExpiry(addExpires, maxDays, time.Now()); err != nil {
+		return err
+	}
+
+	policies := loadWaiverPolicies()
+	var waivers []waiver.Waiver
+	if addReport != "" {
+		waivers, err = waiversFromReport(addReport)
+	} else {
+		waivers, err = explicitWaivers(policies)
+	}
+	if err != nil {
+		return err
+	}
+	if err := checkWaiverPolicy(waivers, project, policies); err != nil {
+		return err
+	}
+
+	path := waiverFile
+	if path == "" {
+		path = waiver.FindFile(".")
+	}
+	if path == "" {
+		path = waiver.WorkspacePath(config.WorkspaceRoot("."))
+	}
+	added, err := waiver.Append(path, waivers)
+	if err != nil {
+		return err
+	}
+	greenColor.Printf(msg.Waiver.Add.Added+"\n", len(added), path)
+	if skipped := len(waivers) - len(added); skipped > 0 {
+		yellowColor.Printf(msg.Waiver.Add.Skipped+"\n", skipped, path)
+	}
+	return nil
+}
+
+// newWaiver returns a waiver with the fields shared by every added entry,
+// created today.
+func newWaiver() waiver.Waiver {
+	return waiver.Waiver{
+		Reason:          addReason,
+		Expires:         addExpires,
+		Owner:           addOwner,
+		ApprovedBy:      addApprovedBy,
+		Ticket:          addTicket,
+		Created:         time.Now().Format("2006-01-02"),
+		SeverityCeiling: addCeiling,
+	}
+}
+
+// checkWaiverPolicy lints the new waivers and checks them against the
+// project's governance requirements. The first error is returned.
+func checkWaiverPolicy(waivers []waiver.Waiver, project *config.Project, policies waiverPolicies) error {
+	set := &waiver.Set{Waivers: waivers, Index: policies.Index}
+	now := time.Now()
+	issues := set.Lint(nil, now)
+	if project != nil && len(project.Waivers.Require) > 0 {
+		issues = append(issues, set.Govern(project.Waivers.Require, policies.Severities, now)...)
+	}
+	for _, issue := range issues {
+		if issue.Severity == waiver.IssueError {
+			return fmt.Errorf(i18n.Msg().Waiver.Add.PolicyViolation, lintMessage(issue))
+		}
+	}
+	return nil
+}
+
+// explicitWaivers builds the waivers described by the flags: one per
+// --fingerprint, or else one for --rule, --pack and --max-severity. The rule
+// and pack must be known.
+func explicitWaivers(policies waiverPolicies) ([]waiver.Waiver, error) {
+	msg := i18n.Msg()
+	if addRule == "" && addPack == "" && addMaxSeverity == "" && len(addFingerprints) == 0 {
+		return nil, fmt.Errorf("%s", msg.Waiver.Add.TargetRequired)
+	}
+	rule := waiver.ShortRuleID(addRule)
+	if rule != "" && rule != "*" {
+		if policies.Severities != nil && policies.Severities[rule] == "" {
+			return nil, fmt.Errorf(msg.Waiver.Add.UnknownRule, addRule)
+		}
+	}
+	if addPack != "" && !(&waiver.Set{Index: policies.Index}).HasPack(addPack) {
+		return nil, fmt.Errorf(msg.Waiver.Add.UnknownPack, addPack)
+	}
+	base := newWaiver()
+	base.Rule = rule
+	if len(addFingerprints) == 0 {
+		base.Pack = addPack
+		base.MaxSeverity = addMaxSeverity
+		base.Resource = addResource
+		base.Files = addFiles
+		return []waiver.Waiver{base}, nil
+	}
+	var waivers []waiver.Waiver
+	for _, fp := range addFingerprints {
+		w := base
+		w.Fingerprint = fp
+		waivers = append(waivers, w)
+	}
+	return waivers, nil
+}
+
+// waiversFromReport builds a fingerprint waiver for each violation in a JSON
+// scan report that matches the selection flags and is not already waived.
+// Flags that widen a waiver beyond its fingerprint are rejected.
+func waiversFromReport(path string) ([]waiver.Waiver, error) {
+	msg := i18n.Msg()
+	for _, flag := range []struct{ name, value string }{
+		{"--pack", addPack}, {"--max-severity", addMaxSeverity}, {"--severity-ceiling", addCeiling},
+	} {
+		if flag.value != "" {
+			return nil, fmt.Errorf(msg.Waiver.Add.ReportFlagConflict, flag.name)
+		}
+	}
+	if addRule == "" && addResource == "" && len(addFiles) == 0 && len(addFingerprints) == 0 {
+		return nil, fmt.Errorf("%s", msg.Waiver.Add.NoSelection)
+	}
+	report, err := loadScanReport(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var waivers []waiver.Waiver
+	for _, fr := range report.Results {
+		for _, v := range fr.Violations {
+			if v.IsSuppressed(true) || !selectedViolation(v) {
+				continue
+			}
+			w := newWaiver()
+			w.Rule = waiver.ShortRuleID(v.ID)
+			w.Resource = v.ResourceID
+			w.Fingerprint = v.Fingerprint
+			waivers = append(waivers, w)
+		}
+	}
+	if len(waivers) == 0 {
+		return nil, fmt.Errorf(msg.Waiver.Add.NoMatch, path)
+	}
+	return waivers, nil
+}
+
+// loadScanReport reads a JSON scan report.
+func loadScanReport(path string) (*models.Report, error) {
+	msg := i18n.Msg()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(msg.Waiver.Add.ReadReport, path, err)
+	}
+	var report models.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf(msg.Waiver.Add.ReadReport, path, err)
+	}
+	return &report, nil
+}
+
+// selectedViolation reports whether a violation matches all of the given
+// selection flags.
+func selectedViolation(v models.RichViolation) bool {
+	if len(addFingerprints) > 0 && !contains(addFingerprints, v.Fingerprint) {
+		return false
+	}
+	if addRule != "" && waiver.ShortRuleID(addRule) != waiver.ShortRuleID(v.ID) {
+		return false
+	}
+	if addResource != "" && !waiver.GlobMatch(addResource, v.ResourceID) {
+		return false
+	}
+	if len(addFiles) > 0 && !waiver.AnyFileMatch(addFiles, v.File) {
+		return false
+	}
+	return true
+}
+
+// checkWaiverExpiry validates an expiry date against today and the project's
+// maximum waiver lifetime in days, where 0 means no maximum.
+func checkWaiverExpiry(expires string, maxDays int, now time.Time) error {
+	msg := i18n.Msg()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if expires == "" {
+		if maxDays > 0 {
+			return fmt.Errorf(msg.Waiver.Add.ExpiresRequired, maxDays)
+		}
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", expires)
+	if err != nil {
+		return fmt.Errorf(msg.Waiver.Add.InvalidExpires, expires)
+	}
+	if t.Before(today) {
+		return fmt.Errorf(msg.Waiver.Add.ExpiresPast, expires)
+	}
+	if latest := today.AddDate(0, 0, maxDays); maxDays > 0 && t.After(latest) {
+		return fmt.Errorf(msg.Waiver.Add.ExpiresTooLate, expires, maxDays, latest.Format("2006-01-02"))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckWaiverExpiry(t *testing.T) {
+	Convey("Given today's date", t, func() {
+		now := time.Date(2026, 6, 22, 15, 0, 0, 0, time.UTC)
+
+		Convey("Without a maximum, any future date or none should pass", func() {
+			So(checkWaiverExpiry("", 0, now), ShouldBeNil)
+			So(checkWaiverExpiry("2030-01-01", 0, now), ShouldBeNil)
+			So(checkWaiverExpiry("2026-06-22", 0, now), ShouldBeNil)
+		})
+
+		Convey("Invalid and past dates should fail", func() {
+			So(checkWaiverExpiry("22/06/2026", 0, now), ShouldNotBeNil)
+			So(checkWaiverExpiry("2026-06-21", 0, now), ShouldNotBeNil)
+		})
+
+		Convey("With a maximum, the date should be required and within it", func() {
+			So(checkWaiverExpiry("", 90, now), ShouldNotBeNil)
+			So(checkWaiverExpiry("2026-09-20", 90, now), ShouldBeNil)
+			So(checkWaiverExpiry("2026-09-21", 90, now), ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWaiversFromReportRejectsWideningFlags(t *testing.T) {
+	Convey("Given a selection from a report", t, func() {
+		addRule = "ecs-instance-no-public-ip"
+		defer func() { addRule, addPack, addMaxSeverity, addCeiling = "", "", "", "" }()
+
+		Convey("--pack, --max-severity and --severity-ceiling should be rejected", func() {
+			for _, set := range []func(){
+				func() { addPack = "quick-start-compliance-pack" },
+				func() { addMaxSeverity = "low" },
+				func() { addCeiling = "medium" },
+			} {
+				addPack, addMaxSeverity, addCeiling = "", "", ""
+				set()
+				_, err := waiversFromReport("no-such-report.json")
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "--report")
+			}
+		})
+	})
+}
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aliyun/infraguard/pkg/config"
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/aliyun/infraguard/pkg/policy"
+	"github.com/aliyun/infraguard/pkg/waiver"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneReport string
+	pruneRemove bool
+)
+
+var waiverPruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "", // Set dynamically
+	Long:         "", // Set dynamically
+	Args:         cobra.NoArgs,
+	RunE:         runWaiverPrune,
+	SilenceUsage: true,
+}
+
+func init() {
+	waiverCmd.AddCommand(waiverPruneCmd)
+
+	waiverPruneCmd.Flags().StringVar(&pruneReport, "report", "", "JSON scan report to check the waivers against (required)")
+	waiverPruneCmd.Flags().BoolVar(&pruneRemove, "remove", false, "Remove unused entries from the waiver file")
+}
+
+func runWaiverPrune(cmd *cobra.Command, args []string) error {
+	msg := i18n.Msg()
+	if pruneReport == "" {
+		return fmt.Errorf("%s", msg.Waiver.Prune.ReportRequired)
+	}
+	report, err := loadScanReport(pruneReport)
+	if err != nil {
+		return err
+	}
+	// Violations left out of a filtered report would make the waivers that
+	// match them look unused.
+	if filters := reportFilters(report.Summary); len(filters) > 0 {
+		return fmt.Errorf(msg.Waiver.Prune.FilteredReport, pruneReport, strings.Join(filters, ", "))
+	}
+
+	// A repository may rely on inline directives only.
+	index := policyIndex()
+	set := &waiver.Set{}
+	if waiverFile != "" || waiver.FindFile(".") != "" {
+		if set, err = resolveWaiverSet(); err != nil {
+			return err
+		}
+		set.Index = index
+	}
+
+	// Reload the scanned templates to attribute their inline directives.
+	resLinesByFile := make(map[string][]waiver.ResourceLine)
+	for _, fr := range report.Results {
+		iacType := templateIaCType(fr.File)
+		yamlRoot, templateData, err := loadTemplateWithMode(fr.File, iacType, nil, "static")
+		if err != nil {
+			continue
+		}
+		for f, rls := range templateResourceLines(fr.File, iacType, yamlRoot, templateData) {
+			resLinesByFile[f] = append(resLinesByFile[f], rls...)
+		}
+	}
+	inlineByFile, inlines := parseInlineWaivers(resLinesByFile)
+
+	// Match the waivers against every violation the scan found.
+	results := report.Results
+	for i := range results {
+		for j := range results[i].Violations {
+			results[i].Violations[j].Waiver = nil
+		}
+	}
+	usage := set.Annotate(results, inlineByFile, time.Now())
+	unusedInline := waiver.UnusedInline(inlines, usage)
+
+	// Waivers for rules or files the scan did not cover are not known to be
+	// unused, so they are listed apart and never removed.
+	project, err := loadProject()
+	if err != nil {
+		return err
+	}
+	var policies []string
+	if project != nil {
+		policies = projectPolicies(project)
+	}
+	scope := reportScope(report.Summary, index, config.WorkspaceRoot("."), policies)
+	var unused, unchecked []int
+	for _, i := range set.Unused(usage) {
+		if set.InScope(set.Waivers[i], scope) {
+			unused = append(unused, i)
+		} else {
+			unchecked = append(unchecked, i)
+		}
+	}
+
+	if len(unused) == 0 && len(unchecked) == 0 && len(unusedInline) == 0 {
+		greenColor.Printf(msg.Waiver.Prune.AllUsed+"\n", len(set.Waivers), len(inlines))
+		return nil
+	}
+
+	if len(unused) > 0 {
+		yellowColor.Printf(msg.Waiver.Prune.UnusedTitle+"\n", len(unused), set.Path)
+		printPruneEntries(set, unused)
+	}
+	if len(unchecked) > 0 {
+		yellowColor.Printf(msg.Waiver.Prune.UncheckedTitle+"\n", len(unchecked), set.Path)
+		printPruneEntries(set, unchecked)
+	}
+	if len(unusedInline) > 0 {
+		yellowColor.Printf(msg.Waiver.Prune.UnusedInlineTitle+"\n", len(unusedInline))
+		for _, in := range unusedInline {
+			fmt.Printf("  %s:%d infraguard:ignore=%s\n", in.File, in.Line, strings.Join(in.Rules, ","))
+		}
+		fmt.Println()
+	}
+
+	if !pruneRemove {
+		if len(unused) > 0 {
+			fmt.Println(msg.Waiver.Prune.RemoveHint)
+		}
+		return nil
+	}
+	if len(unused) > 0 {
+		if err := waiver.Remove(set.Path, unused); err != nil {
+			return err
+		}
+		greenColor.Printf(msg.Waiver.Prune.Removed+"\n", len(unused), set.Path)
+	}
+	if len(unusedInline) > 0 {
+		fmt.Println(msg.Waiver.Prune.InlineHint)
+	}
+	return nil
+}
+
+// printPruneEntries lists the waivers at the given indexes, followed by a blank
+// line.
+func printPruneEntries(set *waiver.Set, indexes []int) {
+	msg := i18n.Msg()
+	for _, i := range indexes {
+		w := set.Waivers[i]
+		target := w.Target()
+		if w.Resource != "" {
+			target += " " + w.Resource
+		}
+		fmt.Printf(msg.Waiver.Prune.UnusedEntry+"\n", i+1, target, w.Reason)
+	}
+	fmt.Println()
+}
+
+// reportScope returns the part of the workspace the scan of a report covered.
+// A scan with the policies of the project configuration covers every rule the
+// workspace is checked for. Parts a report does not record are taken as fully
+// covered.
+func reportScope(summary models.ReportSummary, index *models.PolicyIndex, root string, projectPolicies []string) waiver.Scope {
+	var scope waiver.Scope
+	if len(summary.Policies) > 0 && !sameStrings(summary.Policies, projectPolicies) {
+		rules, local := scannedRules(summary.Policies, index)
+		scope.Rules = func(shortID string) bool {
+			// Rules unknown to the index may come from local policy files.
+			return rules[shortID] || local && indexedRule(index, shortID) == nil
+		}
+	}
+	if len(summary.Paths) > 0 {
+		scope.Roots = summary.Paths
+		for _, path := range summary.Paths {
+			if rel, err := filepath.Rel(path, root); err == nil && !strings.HasPrefix(rel, "..") {
+				scope.Roots = nil
+				break
+			}
+		}
+	}
+	return scope
+}
+
+// sameStrings reports whether a and b hold the same strings in any order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = append([]string(nil), a...), append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// scannedRules returns the short IDs of the rules selected by policy
+// specifications, and whether they also select local policy files, whose rules
+// cannot be told without loading them.
+func scannedRules(specs []string, index *models.PolicyIndex) (map[string]bool, bool) {
+	rules := make(map[string]bool)
+	local := false
+	for _, spec := range specs {
+		switch {
+		case strings.HasPrefix(spec, "rule:"):
+			if !strings.Contains(spec, "*") {
+				rules[extractShortRuleID(spec)] = true
+			} else if index != nil {
+				for _, rule := range index.RuleList {
+					if policy.MatchPattern(spec, rule.ID) {
+						rules[extractShortRuleID(rule.ID)] = true
+					}
+				}
+			}
+		case strings.HasPrefix(spec, "pack:"):
+			if index == nil {
+				continue
+			}
+			for _, pack := range index.PackList {
+				if !policy.MatchPattern(spec, pack.ID) {
+					continue
+				}
+				for _, id := range pack.RuleIDs {
+					rules[extractShortRuleID(id)] = true
+				}
+			}
+		default:
+			local = true
+		}
+	}
+	return rules, local
+}
+
+// reportFilters returns the scan flags that limited which violations a report
+// contains, as given on the command line.
+func reportFilters(summary models.ReportSummary) []string {
+	var filters []string
+	if summary.MinSeverity != "" {
+		filters = append(filters, "--min-severity "+summary.MinSeverity)
+	}
+	if summary.ChangedSince != "" {
+		filters = append(filters, "--changed-since "+summary.ChangedSince)
+	}
+	if summary.ChangedLines {
+		filters = append(filters, "--changed-lines")
+	}
+	return filters
+}
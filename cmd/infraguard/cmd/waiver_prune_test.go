@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aliyun/infraguard/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReportFilters(t *testing.T) {
+	Convey("Given report summaries", t, func() {
+		Convey("A full scan should have no filters", func() {
+			So(reportFilters(models.ReportSummary{FailOn: models.SeverityHigh}), ShouldBeEmpty)
+		})
+
+		Convey("A filtered scan should list the flags that filtered it", func() {
+			summary := models.ReportSummary{
+				MinSeverity:  models.SeverityMedium,
+				ChangedSince: "origin/main",
+				ChangedLines: true,
+			}
+			So(reportFilters(summary), ShouldResemble, []string{
+				"--min-severity medium", "--changed-since origin/main", "--changed-lines",
+			})
+		})
+	})
+}
+
+func TestReportScope(t *testing.T) {
+	Convey("Given a policy index and a workspace root", t, func() {
+		index := &models.PolicyIndex{Rules: map[string]*models.Rule{}, Packs: map[string]*models.Pack{}}
+		for _, id := range []string{"rule:aliyun:a", "rule:aliyun:b", "rule:aliyun:c"} {
+			index.AddRule(&models.Rule{ID: id})
+		}
+		index.AddPack(&models.Pack{ID: "pack:aliyun:p", RuleIDs: []string{"rule:aliyun:a", "rule:aliyun:b"}})
+		root := filepath.Join(t.TempDir(), "ws")
+
+		Convey("A report without a recorded scope should cover everything", func() {
+			scope := reportScope(models.ReportSummary{}, index, root, nil)
+			So(scope.Rules, ShouldBeNil)
+			So(scope.Roots, ShouldBeNil)
+		})
+
+		Convey("The rules should be those of the policy specifications", func() {
+			scope := reportScope(models.ReportSummary{Policies: []string{"pack:aliyun:p"}}, index, root, nil)
+			So(scope.Rules("a"), ShouldBeTrue)
+			So(scope.Rules("c"), ShouldBeFalse)
+			So(scope.Rules("custom"), ShouldBeFalse)
+		})
+
+		Convey("The policies of the project configuration should cover every rule", func() {
+			summary := models.ReportSummary{Policies: []string{"pack:aliyun:p", "rule:aliyun:c"}}
+			scope := reportScope(summary, index, root, []string{"rule:aliyun:c", "pack:aliyun:p"})
+			So(scope.Rules, ShouldBeNil)
+		})
+
+		Convey("Local policy files should cover the rules unknown to the index", func() {
+			scope := reportScope(models.ReportSummary{Policies: []string{"rule:aliyun:c*", "policies"}}, index, root, nil)
+			So(scope.Rules("c"), ShouldBeTrue)
+			So(scope.Rules("custom"), ShouldBeTrue)
+			So(scope.Rules("a"), ShouldBeFalse)
+		})
+
+		Convey("The roots should be kept unless a path covers the workspace root", func() {
+			stacks := filepath.Join(root, "stacks")
+			scope := reportScope(models.ReportSummary{Paths: []string{stacks}}, index, root, nil)
+			So(scope.Roots, ShouldResemble, []string{stacks})
+
+			scope = reportScope(models.ReportSummary{Paths: []string{stacks, root}}, index, root, nil)
+			So(scope.Roots, ShouldBeNil)
+		})
+	})
+}
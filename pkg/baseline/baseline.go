@@ -0,0 +1,179 @@
+// Package baseline records the violations a project has already accepted, so a
+// scan can fail only on new violations.
+//
+// Each violation is identified by its fingerprint (see models.Fingerprint),
+// which leaves out line numbers, so unrelated edits that move a resource do not
+// turn an accepted violation into a new one.
+package baseline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry is a single accepted violation in the baseline file. Everything but the
+// fingerprint is informational, for reviewing and reporting resolved entries.
+// File is relative to the workspace root, like the path in the fingerprint, so
+// the baseline reads the same whichever directory the scan runs from.
+type Entry struct {
+	Fingerprint string `yaml:"fingerprint"`
+	Rule        string `yaml:"rule"`
+	File        string `yaml:"file"`
+	Resource    string `yaml:"resource,omitempty"`
+	Path        string `yaml:"path,omitempty"`
+}
+
+// fileDoc is the on-disk structure of the baseline file.
+type fileDoc struct {
+	Version int     `yaml:"version"`
+	Entries []Entry `yaml:"entries"`
+}
+
+// Baseline is a loaded collection of accepted violations.
+type Baseline struct {
+	Path    string
+	Entries []Entry
+}
+
+// FromResults builds a baseline of the violations in results that are not
+// suppressed by a waiver, with files relative to the workspace root. Entries
+// are sorted by file, rule and resource so the file diffs cleanly when it is
+// rewritten.
+func FromResults(results []models.FileResult, failOnExpired bool, root string) *Baseline {
+	seen := make(map[string]bool)
+	b := &Baseline{}
+	for _, fr := range results {
+		for _, v := range fr.Violations {
+			if v.IsSuppressed(failOnExpired) {
+				continue
+			}
+			fp := v.Fingerprint
+			if seen[fp] {
+				continue
+			}
+			seen[fp] = true
+			b.Entries = append(b.Entries, Entry{
+				Fingerprint: fp,
+				Rule:        v.ID,
+				File:        relPath(root, v.File),
+				Resource:    v.ResourceID,
+				Path:        strings.Join(v.ViolationPath, "."),
+			})
+		}
+	}
+	sort.Slice(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.File != c.File {
+			return a.File < c.File
+		}
+		if a.Rule != c.Rule {
+			return a.Rule < c.Rule
+		}
+		if a.Resource != c.Resource {
+			return a.Resource < c.Resource
+		}
+		return a.Path < c.Path
+	})
+	return b
+}
+
+// Save writes the baseline to path in the standard file format.
+func Save(path string, b *Baseline) error {
+	doc := fileDoc{Version: 1, Entries: b.Entries}
+	if doc.Entries == nil {
+		doc.Entries = []Entry{}
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Load reads and parses a baseline file.
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc fileDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse baseline file %s: %w", path, err)
+	}
+	return &Baseline{Path: path, Entries: doc.Entries}, nil
+}
+
+// Apply marks the violations whose fingerprint is in the baseline, and returns
+// the entries that no longer match any violation. Violations already
+// suppressed by a waiver are left alone. Only the entries of files for which
+// scanned returns true can be resolved: the others were not checked. scanned
+// is given the entry's file as an absolute path, resolved against the
+// workspace root; a nil scanned accepts all.
+func (b *Baseline) Apply(results []models.FileResult, failOnExpired bool, root string, scanned func(path string) bool) []Entry {
+	known := make(map[string]bool, len(b.Entries))
+	for _, e := range b.Entries {
+		known[e.Fingerprint] = true
+	}
+	matched := make(map[string]bool)
+	for i := range results {
+		for j := range results[i].Violations {
+			v := &results[i].Violations[j]
+			if v.IsSuppressed(failOnExpired) {
+				continue
+			}
+			fp := v.Fingerprint
+			if known[fp] {
+				v.Baseline = true
+				matched[fp] = true
+			}
+		}
+	}
+
+	var resolved []Entry
+	for _, e := range b.Entries {
+		if matched[e.Fingerprint] {
+			continue
+		}
+		if scanned != nil {
+			path, err := absPath(root, e.File)
+			if err != nil || !scanned(path) {
+				continue
+			}
+		}
+		resolved = append(resolved, e)
+	}
+	return resolved
+}
+
+// relPath returns path relative to the workspace root with forward slashes, or
+// path itself if it lies outside the root.
+func relPath(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// absPath returns the absolute path of an entry file, resolving relative files
+// against the workspace root.
+func absPath(root, file string) (string, error) {
+	path := filepath.FromSlash(file)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	return filepath.Abs(path)
+}
@@ -0,0 +1,76 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/aliyun/infraguard/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBaseline(t *testing.T) {
+	Convey("Given scan results", t, func() {
+		root := t.TempDir()
+		file := filepath.Join(root, "stacks", "a.yaml")
+		results := func() []models.FileResult {
+			return []models.FileResult{{
+				File: file,
+				Violations: []models.RichViolation{
+					{ID: "rule:x", ResourceID: "A", File: file, Fingerprint: "fx"},
+					{ID: "rule:y", ResourceID: "B", File: file, Fingerprint: "fy"},
+					{ID: "rule:z", ResourceID: "C", File: file, Fingerprint: "fz",
+						Waiver: &models.WaiverInfo{Status: models.WaiverStatusActive}},
+				},
+			}}
+		}
+
+		Convey("FromResults should skip waived violations and sort entries", func() {
+			b := FromResults(results(), true, root)
+			So(len(b.Entries), ShouldEqual, 2)
+			So(b.Entries[0].Rule, ShouldEqual, "rule:x")
+			So(b.Entries[1].Rule, ShouldEqual, "rule:y")
+		})
+
+		Convey("FromResults should record files relative to the workspace root", func() {
+			b := FromResults(results(), true, root)
+			So(b.Entries[0].File, ShouldEqual, "stacks/a.yaml")
+		})
+
+		Convey("Save and Load should round-trip", func() {
+			path := filepath.Join(t.TempDir(), ".infraguard", "baseline.yaml")
+			So(Save(path, FromResults(results(), true, root)), ShouldBeNil)
+			loaded, err := Load(path)
+			So(err, ShouldBeNil)
+			So(loaded.Path, ShouldEqual, path)
+			So(len(loaded.Entries), ShouldEqual, 2)
+		})
+
+		Convey("Apply should mark known violations and return resolved entries", func() {
+			b := FromResults(results(), true, root)
+			b.Entries = append(b.Entries, Entry{Fingerprint: "gone", Rule: "rule:old"})
+
+			current := results()
+			current[0].Violations = current[0].Violations[1:] // rule:x was fixed
+			current[0].Violations = append(current[0].Violations, models.RichViolation{ID: "rule:new", ResourceID: "D", File: file, Fingerprint: "fnew"})
+			resolved := b.Apply(current, true, root, nil)
+
+			So(current[0].Violations[0].Baseline, ShouldBeTrue)  // rule:y
+			So(current[0].Violations[1].Baseline, ShouldBeFalse) // rule:z is waived
+			So(current[0].Violations[2].Baseline, ShouldBeFalse) // rule:new
+			So(current[0].Violations[0].IsSuppressed(true), ShouldBeTrue)
+			So(len(resolved), ShouldEqual, 2)
+			So(resolved[0].Rule, ShouldEqual, "rule:x")
+			So(resolved[1].Rule, ShouldEqual, "rule:old")
+		})
+
+		Convey("Apply should only resolve entries of scanned files", func() {
+			b := FromResults(results(), true, root)
+			b.Entries = append(b.Entries, Entry{Fingerprint: "other", Rule: "rule:other", File: "other.yaml"})
+
+			resolved := b.Apply(nil, true, root, func(path string) bool { return path == file })
+			So(len(resolved), ShouldEqual, 2)
+			So(resolved[0].File, ShouldEqual, "stacks/a.yaml")
+			So(resolved[1].File, ShouldEqual, "stacks/a.yaml")
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// paramsDoc is the part of a YAML file read for rule parameters, the params
+// key of the project configuration:
+//
+//	params:
+//	  vswitch-available-ip-count:
+//	    max_prefix_length: 26
+type paramsDoc struct {
+	Params map[string]map[string]interface{} `yaml:"params"`
+}
+
+// LoadParams reads the rule parameters of a file with a params key, such as a
+// project configuration file. The result maps rule IDs (short or full) to
+// parameter names and values.
+func LoadParams(path string) (map[string]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc paramsDoc
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse parameters file %s: %w", path, err)
+	}
+	return doc.Params, nil
+}
+
+// MergeParams returns the parameters of base overridden, parameter by
+// parameter, by those of override.
+func MergeParams(base, override map[string]map[string]interface{}) map[string]map[string]interface{} {
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]map[string]interface{}, len(base)+len(override))
+	for _, params := range []map[string]map[string]interface{}{base, override} {
+		for rule, values := range params {
+			if merged[rule] == nil {
+				merged[rule] = make(map[string]interface{}, len(values))
+			}
+			for name, value := range values {
+				merged[rule][name] = value
+			}
+		}
+	}
+	return merged
+}
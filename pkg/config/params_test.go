@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParams(t *testing.T) {
+	Convey("Rule parameters", t, func() {
+		root := t.TempDir()
+
+		Convey("LoadParams should return the values by rule ID", func() {
+			path := filepath.Join(root, "params.yaml")
+			content := "params:\n  vswitch-available-ip-count:\n    max_prefix_length: 26\n"
+			So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+			params, err := LoadParams(path)
+			So(err, ShouldBeNil)
+			So(params["vswitch-available-ip-count"]["max_prefix_length"], ShouldEqual, 26)
+		})
+
+		Convey("LoadParams should fail on malformed YAML", func() {
+			path := filepath.Join(root, "bad.yaml")
+			So(os.WriteFile(path, []byte("params: [\n"), 0644), ShouldBeNil)
+			_, err := LoadParams(path)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("MergeParams should override parameter by parameter", func() {
+			base := map[string]map[string]interface{}{
+				"rule-a": {"x": 1, "y": 2},
+				"rule-b": {"z": 3},
+			}
+			override := map[string]map[string]interface{}{
+				"rule-a": {"y": 5},
+			}
+			merged := MergeParams(base, override)
+			So(merged["rule-a"], ShouldResemble, map[string]interface{}{"x": 1, "y": 5})
+			So(merged["rule-b"], ShouldResemble, map[string]interface{}{"z": 3})
+			So(base["rule-a"]["y"], ShouldEqual, 2)
+		})
+	})
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/aliyun/infraguard/pkg/waiver"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectRelPath is the conventional workspace location of the project
+// configuration file.
+const ProjectRelPath = ".infraguard/config.yaml"
+
+// Project is the workspace configuration of scan defaults:
+//
+//	version: 1
+//	policies:
+//	  - pack:aliyun:quick-start-compliance-pack
+//	exclude:
+//	  - examples/**
+//	inputs:
+//	  - params/prod.yaml
+//	format: sarif
+//	severity:
+//	  oss-bucket-logging-enabled: low
+//	fail_on: medium
+//	params:
+//	  vswitch-available-ip-count:
+//	    max_prefix_length: 26
+//	waivers:
+//	  max_expiry_days: 365
+//	  require:
+//	    high:
+//	      approver: true
+//	      ticket: true
+//	      max_days: 90
+//
+// Paths in policies, exclude and inputs are relative to the workspace root, the
+// directory that contains .infraguard.
+type Project struct {
+	Version  int               `yaml:"version"`
+	Policies []string          `yaml:"policies,omitempty"` // Policy specifications, as for --policy
+	Exclude  []string          `yaml:"exclude,omitempty"`  // Template path globs not to scan
+	Inputs   []string          `yaml:"inputs,omitempty"`   // Parameter values, as for --input
+	Format   string            `yaml:"format,omitempty"`   // Output format, as for --format
+	Severity map[string]string `yaml:"severity,omitempty"` // Severity overrides by short or full rule ID
+	FailOn   string            `yaml:"fail_on,omitempty"`  // Lowest severity that fails the scan, or "none"
+	Waivers  WaiverPolicy      `yaml:"waivers,omitempty"`  // Rules for new waivers
+
+	// Params overrides rule parameters, keyed by short or full rule ID and
+	// then parameter name.
+	Params map[string]map[string]interface{} `yaml:"params,omitempty"`
+
+	// Root is the workspace root the file was loaded from.
+	Root string `yaml:"-"`
+}
+
+// WaiverPolicy holds the project rules for waivers.
+type WaiverPolicy struct {
+	// MaxExpiryDays is the longest a waiver added with "infraguard waiver add"
+	// may last. 0 means no limit, which also allows permanent waivers.
+	MaxExpiryDays int `yaml:"max_expiry_days,omitempty"`
+
+	// Require holds the governance requirements for the waivers that cover each
+	// severity, checked by "infraguard waiver lint" and "infraguard waiver add".
+	Require map[string]waiver.Requirement `yaml:"require,omitempty"`
+}
+
+// FindProjectFile locates the project configuration file, searching startDir
+// and its ancestors. The user configuration file in the home directory, which
+// has the same name, is skipped. Returns "" if none is found.
+func FindProjectFile(startDir string) string {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		dir = startDir
+	}
+	userPath, _ := ConfigPath()
+	for {
+		candidate := filepath.Join(dir, ProjectRelPath)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() && candidate != userPath {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// WorkspaceRoot returns the workspace root of startDir: the nearest directory
+// at or above it that contains an .infraguard directory, other than the user
+// configuration directory in the home directory. Falls back to startDir.
+func WorkspaceRoot(startDir string) string {
+	start, err := filepath.Abs(startDir)
+	if err != nil {
+		return startDir
+	}
+	userDir, _ := DefaultConfigDir()
+	for dir := start; ; {
+		candidate := filepath.Join(dir, ".infraguard")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() && candidate != userDir {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return start
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads and validates a project configuration file.
+func LoadProject(path string) (*Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var p Project
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf(i18n.Msg().Errors.ParseConfigFile, err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	p.Root = filepath.Dir(filepath.Dir(abs))
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// validate checks the severity overrides, the fail threshold and the waiver
+// policy.
+func (p *Project) validate() error {
+	msg := i18n.Msg()
+	levels := models.SeverityLevels()
+	for rule, severity := range p.Severity {
+		if !contains(levels, severity) {
+			return fmt.Errorf(msg.Errors.ProjectInvalidSeverity, severity, rule, strings.Join(levels, ", "))
+		}
+	}
+	if p.FailOn != "" && p.FailOn != models.SeverityNone && !contains(levels, p.FailOn) {
+		return fmt.Errorf(msg.Errors.ProjectInvalidFailOn, p.FailOn, strings.Join(append(levels, models.SeverityNone), ", "))
+	}
+	if p.Waivers.MaxExpiryDays < 0 {
+		return fmt.Errorf(msg.Errors.ProjectInvalidMaxExpiry, p.Waivers.MaxExpiryDays)
+	}
+	for severity, req := range p.Waivers.Require {
+		if !contains(levels, severity) {
+			return fmt.Errorf(msg.Errors.ProjectInvalidWaiverSeverity, severity, strings.Join(levels, ", "))
+		}
+		if req.MaxDays < 0 {
+			return fmt.Errorf(msg.Errors.ProjectInvalidMaxDays, req.MaxDays, severity)
+		}
+	}
+	return nil
+}
+
+// ResolvePath resolves a path from the configuration against the workspace
+// root. Absolute paths are returned unchanged.
+func (p *Project) ResolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(p.Root, path)
+}
+
+// IsExcluded reports whether a template path matches one of the exclude globs.
+// Globs are matched against the path relative to the workspace root and against
+// each of its parent directories, so "examples" excludes everything below it.
+func (p *Project) IsExcluded(path string) bool {
+	if len(p.Exclude) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(p.Root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range p.Exclude {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		for candidate := rel; candidate != "."; candidate = filepath.ToSlash(filepath.Dir(candidate)) {
+			if waiver.GlobMatch(pattern, candidate) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SeverityFor returns the severity override of a rule, looked up by full and
+// then short rule ID, or "" if there is none.
+func (p *Project) SeverityFor(ruleID string) string {
+	if severity, ok := p.Severity[ruleID]; ok {
+		return severity
+	}
+	return p.Severity[waiver.ShortRuleID(ruleID)]
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
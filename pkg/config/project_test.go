@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProject(t *testing.T) {
+	Convey("Project configuration file", t, func() {
+		root := t.TempDir()
+		sub := filepath.Join(root, "stacks", "prod")
+		So(os.MkdirAll(sub, 0755), ShouldBeNil)
+		path := filepath.Join(root, ProjectRelPath)
+		So(os.MkdirAll(filepath.Dir(path), 0755), ShouldBeNil)
+
+		write := func(content string) {
+			So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+		}
+
+		Convey("FindProjectFile should find it from a subdirectory", func() {
+			write("version: 1\n")
+			So(FindProjectFile(sub), ShouldEqual, path)
+		})
+
+		Convey("LoadProject should read the scan defaults", func() {
+			write(`version: 1
+policies:
+  - pack:aliyun:quick-start-compliance-pack
+  - policies/custom
+exclude:
+  - examples
+  - "**/*.generated.yaml"
+inputs:
+  - params.yaml
+format: sarif
+severity:
+  oss-bucket-logging-enabled: low
+fail_on: medium
+params:
+  vswitch-available-ip-count:
+    max_prefix_length: 26
+`)
+			p, err := LoadProject(path)
+			So(err, ShouldBeNil)
+			So(p.Policies, ShouldResemble, []string{"pack:aliyun:quick-start-compliance-pack", "policies/custom"})
+			So(p.Format, ShouldEqual, "sarif")
+			So(p.FailOn, ShouldEqual, "medium")
+			So(p.Params["vswitch-available-ip-count"]["max_prefix_length"], ShouldEqual, 26)
+
+			Convey("Root should be the directory that contains .infraguard", func() {
+				wantRoot, _ := filepath.Abs(root)
+				So(p.Root, ShouldEqual, wantRoot)
+				So(p.ResolvePath("params.yaml"), ShouldEqual, filepath.Join(wantRoot, "params.yaml"))
+			})
+
+			Convey("IsExcluded should match the path and its parent directories", func() {
+				So(p.IsExcluded(filepath.Join(root, "examples", "a", "main.tf")), ShouldBeTrue)
+				So(p.IsExcluded(filepath.Join(root, "stacks", "x.generated.yaml")), ShouldBeTrue)
+				So(p.IsExcluded(filepath.Join(root, "stacks", "prod", "template.yaml")), ShouldBeFalse)
+			})
+
+			Convey("SeverityFor should look up short and full rule IDs", func() {
+				So(p.SeverityFor("rule:aliyun:oss-bucket-logging-enabled"), ShouldEqual, "low")
+				So(p.SeverityFor("oss-bucket-logging-enabled"), ShouldEqual, "low")
+				So(p.SeverityFor("other"), ShouldBeEmpty)
+			})
+		})
+
+		Convey("LoadProject should reject an invalid severity override", func() {
+			write("severity:\n  some-rule: critical\n")
+			_, err := LoadProject(path)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "critical")
+		})
+
+		Convey("LoadProject should reject an invalid fail threshold", func() {
+			write("fail_on: sometimes\n")
+			_, err := LoadProject(path)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("LoadProject should accept fail_on none", func() {
+			write("fail_on: none\n")
+			_, err := LoadProject(path)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("LoadProject should read the waiver policy", func() {
+			write("waivers:\n  max_expiry_days: 90\n")
+			p, err := LoadProject(path)
+			So(err, ShouldBeNil)
+			So(p.Waivers.MaxExpiryDays, ShouldEqual, 90)
+
+			write("waivers:\n  max_expiry_days: -1\n")
+			_, err = LoadProject(path)
+			So(err, ShouldNotBeNil)
+
+			write("waivers:\n  require:\n    high:\n      approver: true\n      ticket: true\n      max_days: 90\n")
+			p, err = LoadProject(path)
+			So(err, ShouldBeNil)
+			So(p.Waivers.Require["high"].Approver, ShouldBeTrue)
+			So(p.Waivers.Require["high"].Ticket, ShouldBeTrue)
+			So(p.Waivers.Require["high"].MaxDays, ShouldEqual, 90)
+
+			write("waivers:\n  require:\n    urgent:\n      approver: true\n")
+			_, err = LoadProject(path)
+			So(err, ShouldNotBeNil)
+
+			write("waivers:\n  require:\n    high:\n      max_days: -5\n")
+			_, err = LoadProject(path)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
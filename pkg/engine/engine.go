@@ -4,15 +4,23 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/aliyun/infraguard/pkg/i18n"
 	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/profiler"
 	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
 	"github.com/open-policy-agent/opa/v1/topdown/print"
+	"github.com/open-policy-agent/opa/v1/util"
 )
 
 // Query is the OPA evaluation entry point that collects deny results from all packages.
@@ -35,10 +43,20 @@ func (h *printHook) Print(ctx print.Context, msg string) error {
 	return nil
 }
 
+// DefaultRuleTimeout is the default time limit for evaluating one rule against one input.
+const DefaultRuleTimeout = 30 * time.Second
+
 // EvalResult contains the evaluation results including violations and rule statistics.
 type EvalResult struct {
 	Violations      []models.OPAViolation
 	TotalRulesCount int
+	// EvaluatedRules lists the IDs of the declared rules that were evaluated,
+	// expanded and filtered the same way as violation IDs. Rules without a
+	// rule_meta.id are not included.
+	EvaluatedRules []string
+	// TimedOutRules lists the rules whose evaluation exceeded the rule timeout.
+	// They are not part of EvaluatedRules and reported no violations.
+	TimedOutRules []string
 }
 
 // EvalOptions contains options for policy evaluation.
@@ -49,6 +67,11 @@ type EvalOptions struct {
 	IDMapping   map[string]string // Mapping from short ID to full ID (e.g., "xxx" -> "rule:aliyun:xxx")
 	Modules     map[string]string // Pre-loaded modules (name -> content)
 	LibModules  map[string]string // Pre-loaded lib modules (name -> content)
+	RuleTimeout time.Duration     // Time limit for evaluating one rule against one input (optional, 0 = none)
+	Profile     *Profile          // Collects per-rule evaluation statistics (optional)
+	// Params overrides rule parameters, keyed by rule ID (short or full) and then
+	// parameter name. Parameters that are not overridden take their declared default.
+	Params map[string]map[string]interface{}
 }
 
 // Evaluate loads policies from a path (directory or single .rego file) and evaluates them against input data.
@@ -65,146 +88,374 @@ func EvaluateWithStats(policyPath string, input map[string]interface{}) (*EvalRe
 	opts := &EvalOptions{
 		PolicyPaths: []string{policyPath},
 	}
-	return EvaluateWithOpts(opts, input)
+	return EvaluateWithOpts(context.Background(), opts, input)
+}
+
+// EvaluateWithOpts evaluates policies with flexible options. It compiles the
+// policies for a single input; use NewEvaluator to evaluate many inputs.
+func EvaluateWithOpts(ctx context.Context, opts *EvalOptions, input map[string]interface{}) (*EvalResult, error) {
+	e, err := NewEvaluator(opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.Evaluate(ctx, input)
 }
 
-// EvaluateWithOpts evaluates policies with flexible options.
-func EvaluateWithOpts(opts *EvalOptions, input map[string]interface{}) (*EvalResult, error) {
+// Evaluator evaluates a fixed set of policies against many inputs. The policies
+// are compiled once by NewEvaluator, and Evaluate is safe for concurrent use.
+type Evaluator struct {
+	rules       []ruleQuery       // Deny queries, one per rule package
+	ruleIDs     []string          // Declared rule IDs (rule_meta.id), before expansion and filtering
+	idMapping   map[string]string // Mapping from short ID to full ID
+	filterIDs   []string          // Rule IDs to keep (optional)
+	ruleTimeout time.Duration     // Time limit per rule and input (0 = none)
+	profile     *Profile          // Per-rule statistics are recorded here if set
+}
+
+// ruleQuery is the prepared deny query of a single rule package. Rules are
+// evaluated one at a time so that a slow rule can be timed out on its own.
+type ruleQuery struct {
+	id   string // rule_meta.id, or the package path if the rule declares none
+	deny rego.PreparedEvalQuery
+}
+
+// NewEvaluator loads and compiles the policies described by opts.
+func NewEvaluator(opts *EvalOptions) (*Evaluator, error) {
 	msg := i18n.Msg()
 	if opts == nil || (len(opts.PolicyPaths) == 0 && len(opts.Modules) == 0) {
 		return nil, fmt.Errorf("%s", msg.Errors.NoPolicyPaths)
 	}
 
-	// Discover all .rego files from all paths
-	var allRegoFiles []string
-	for _, path := range opts.PolicyPaths {
-		files, err := discoverRegoFiles(path)
-		if err != nil {
-			return nil, fmt.Errorf(msg.Errors.DiscoverRegoFiles, path, err)
-		}
-		allRegoFiles = append(allRegoFiles, files...)
+	modules, err := loadModules(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Load all rego modules
-	modules := make(map[string]string)
-	if opts.Modules != nil {
-		for k, v := range opts.Modules {
-			modules[k] = v
-		}
+	// Compile the modules once; every query below shares the compiler. Print
+	// statements are enabled for debugging and go to stderr.
+	compiler, err := ast.CompileModulesWithOpt(modules, ast.CompileOpts{EnablePrintStatements: true})
+	if err != nil {
+		return nil, fmt.Errorf(msg.Errors.PrepareRegoQuery, err)
 	}
 
-	// Add lib modules (provided via LibModules)
-	if opts.LibModules != nil {
-		for k, v := range opts.LibModules {
-			// Use a special prefix for library modules to avoid collision with policy paths
-			modules["_lib_/"+k] = v
+	ctx := context.Background()
+	var store storage.Store // Holds data.infraguard.params once the parameters are resolved
+	prepare := func(query string) (rego.PreparedEvalQuery, error) {
+		options := []func(*rego.Rego){
+			rego.Query(query),
+			rego.Compiler(compiler),
+			rego.EnablePrintStatements(true),
+			rego.PrintHook(&printHook{}),
 		}
-	}
-
-	for _, file := range allRegoFiles {
-		// Skip if already in modules
-		if _, ok := modules[file]; ok {
-			continue
+		if store != nil {
+			options = append(options, rego.Store(store))
 		}
+		return rego.New(options...).PrepareForEval(ctx)
+	}
 
-		var content []byte
-		var err error
+	e := &Evaluator{
+		idMapping:   opts.IDMapping,
+		filterIDs:   opts.RuleIDs,
+		ruleTimeout: opts.RuleTimeout,
+		profile:     opts.Profile,
+	}
 
-		// Check if it's an embedded path
-		// Legacy support for embedded paths removed
-		content, err = os.ReadFile(file)
+	// Read the ID and parameters of each rule package, and provide the resolved
+	// parameter values to the rules as data.infraguard.params[<rule-id>].
+	pkgs := denyPackages(compiler)
+	ids := make([]string, len(pkgs))
+	params := make(map[string]interface{})
+	for i, pkg := range pkgs {
+		ids[i] = pkg
+		meta := make(map[string]interface{})
+		if query, err := prepare(pkg + ".rule_meta"); err == nil {
+			if results, err := query.Eval(ctx); err == nil && len(results) > 0 && len(results[0].Expressions) > 0 {
+				if m, ok := results[0].Expressions[0].Value.(map[string]interface{}); ok {
+					meta = m
+				}
+			}
+		}
+		if id, ok := meta["id"].(string); ok {
+			ids[i] = id
+		}
+		values, err := resolveParams(ids[i], models.ParseRuleParams(meta["params"]), opts)
 		if err != nil {
-			return nil, fmt.Errorf(msg.Errors.ReadRegoFile, file, err)
+			return nil, err
 		}
-		// Use path as module name (filesystem uses absolute path)
-		moduleName := file
-		absPath, _ := filepath.Abs(file)
-		if absPath != "" {
-			moduleName = absPath
+		if values != nil {
+			params[ids[i]] = values
 		}
-		modules[moduleName] = string(content)
 	}
-
-	if len(modules) == 0 {
-		return nil, fmt.Errorf("%s", msg.Errors.NoRegoFilesInPaths)
+	if len(params) > 0 {
+		var data interface{} = map[string]interface{}{"infraguard": map[string]interface{}{"params": params}}
+		if err := util.RoundTrip(&data); err != nil {
+			return nil, fmt.Errorf(msg.Errors.PrepareRegoQuery, err)
+		}
+		store = inmem.NewFromObject(data.(map[string]interface{}))
 	}
 
-	ctx := context.Background()
+	// Query for violations (deny rules) package by package. Since only the
+	// requested modules are loaded, these are exactly the packages matched by Query.
+	for i, pkg := range pkgs {
+		deny, err := prepare(fmt.Sprintf("[v | v := %s.deny[_]]", pkg))
+		if err != nil {
+			return nil, fmt.Errorf(msg.Errors.PrepareRegoQuery, err)
+		}
+		e.rules = append(e.rules, ruleQuery{id: ids[i], deny: deny})
+	}
 
-	// Build module options (shared between queries)
-	moduleOpts := make([]func(*rego.Rego), 0, len(modules)+2)
-	for name, content := range modules {
-		moduleOpts = append(moduleOpts, rego.Module(name, content))
+	// Query for declared rules (optional - policies may not define this). Rule
+	// metadata does not depend on the input, so it is read once.
+	if rules, err := prepare(RulesQuery); err == nil {
+		if results, err := rules.Eval(ctx); err == nil {
+			e.ruleIDs = parseRuleIDs(results)
+		}
 	}
 
-	// Enable print output to stderr for debugging
-	moduleOpts = append(moduleOpts, rego.EnablePrintStatements(true))
-	moduleOpts = append(moduleOpts, rego.PrintHook(&printHook{}))
+	return e, nil
+}
 
-	// Determine targeted query if specific rules are requested
-	evaluationQuery := Query
-	// If RuleIDs are provided and we have the modules/package info, we could target the query further.
-	// But since we only load the requested modules into OPA in scan.go, the generic query already targets them.
+// resolveParams returns the parameter values of a rule: the declared defaults,
+// overridden by opts.Params for the rule's short or full ID. It returns nil if
+// the rule declares no parameters, and an error if an override names an
+// undeclared parameter or has the wrong type.
+func resolveParams(ruleID string, decls []models.RuleParam, opts *EvalOptions) (map[string]interface{}, error) {
+	msg := i18n.Msg()
 
-	// Query for violations (deny rules)
-	denyOpts := append([]func(*rego.Rego){
-		rego.Query(evaluationQuery),
-		rego.Input(input),
-	}, moduleOpts...)
+	declared := make(map[string]models.RuleParam, len(decls))
+	values := make(map[string]interface{}, len(decls))
+	for _, decl := range decls {
+		declared[decl.Name] = decl
+		values[decl.Name] = decl.Default
+	}
 
-	r := rego.New(denyOpts...)
-	query, err := r.PrepareForEval(ctx)
-	if err != nil {
-		return nil, fmt.Errorf(msg.Errors.PrepareRegoQuery, err)
+	for _, key := range []string{ruleID, opts.IDMapping[ruleID]} {
+		overrides, ok := opts.Params[key]
+		if key == "" || !ok {
+			continue
+		}
+		for name, value := range overrides {
+			decl, ok := declared[name]
+			if !ok {
+				return nil, fmt.Errorf(msg.Errors.ParamUnknown, ruleID, name)
+			}
+			if !models.ParamValueHasType(decl.Type, value) {
+				return nil, fmt.Errorf(msg.Errors.ParamTypeMismatch, name, ruleID, decl.Type)
+			}
+			values[name] = value
+		}
 	}
 
-	results, err := query.Eval(ctx)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
+
+// denyPackages returns the paths of the rule packages (data.infraguard.rules.<provider>.<name>)
+// that define a deny rule, in sorted order.
+func denyPackages(compiler *ast.Compiler) []string {
+	prefix := ast.MustParseRef("data.infraguard.rules")
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, module := range compiler.Modules {
+		path := module.Package.Path
+		if len(path) != len(prefix)+2 || !path.HasPrefix(prefix) {
+			continue
+		}
+		for _, rule := range module.Rules {
+			if ref := rule.Head.Ref(); len(ref) == 0 || ref[0].Value.Compare(ast.Var("deny")) != 0 {
+				continue
+			}
+			if pkg := path.String(); !seen[pkg] {
+				seen[pkg] = true
+				pkgs = append(pkgs, pkg)
+			}
+			break
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs
+}
+
+// Evaluate evaluates the compiled policies against input. A rule that exceeds
+// the rule timeout is reported in TimedOutRules rather than failing the
+// evaluation; if ctx itself is done, its error is returned.
+func (e *Evaluator) Evaluate(ctx context.Context, input map[string]interface{}) (*EvalResult, error) {
+	msg := i18n.Msg()
+
+	// Convert the input once rather than once per rule query
+	value, err := inputValue(input)
 	if err != nil {
 		return nil, fmt.Errorf(msg.Errors.EvaluatePoliciesInternal, err)
 	}
 
-	violations, err := parseResults(results)
-	if err != nil {
-		return nil, err
+	var violations []models.OPAViolation
+	timedOut := make(map[string]bool)
+	for _, rule := range e.rules {
+		results, err := e.evalRule(ctx, rule, value)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if errors.Is(err, context.DeadlineExceeded) {
+				timedOut[rule.id] = true
+				continue
+			}
+			return nil, fmt.Errorf(msg.Errors.EvaluatePoliciesInternal, err)
+		}
+		ruleViolations, err := parseResults(results)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, ruleViolations...)
 	}
 
 	// Expand short IDs to full IDs using the mapping
-	if len(opts.IDMapping) > 0 {
-		violations = expandViolationIDs(violations, opts.IDMapping)
+	if len(e.idMapping) > 0 {
+		violations = expandViolationIDs(violations, e.idMapping)
 	}
 
 	// Filter violations by rule IDs if specified (now using exact match)
-	if len(opts.RuleIDs) > 0 {
-		violations = filterViolationsByRuleIDs(violations, opts.RuleIDs)
-	}
-
-	// Query for declared rules (optional - policies may not define this)
-	totalRulesCount := 0
-	rulesOpts := append([]func(*rego.Rego){
-		rego.Query(RulesQuery),
-		rego.Input(input),
-	}, moduleOpts...)
-
-	rulesRego := rego.New(rulesOpts...)
-	rulesQuery, err := rulesRego.PrepareForEval(ctx)
-	if err == nil {
-		rulesResults, err := rulesQuery.Eval(ctx)
-		if err == nil {
-			totalRulesCount = parseRulesCount(rulesResults)
+	if len(e.filterIDs) > 0 {
+		violations = filterViolationsByRuleIDs(violations, e.filterIDs)
+	}
+
+	evaluatedRules := make([]string, 0, len(e.ruleIDs))
+	for _, id := range e.ruleIDs {
+		if !timedOut[id] {
+			evaluatedRules = append(evaluatedRules, id)
 		}
 	}
+	evaluatedRules = e.reportedRuleIDs(evaluatedRules)
 
-	// Adjust total rules count if filtering
-	if len(opts.RuleIDs) > 0 {
-		totalRulesCount = len(opts.RuleIDs)
+	var timedOutRules []string
+	for id := range timedOut {
+		timedOutRules = append(timedOutRules, id)
+	}
+	timedOutRules = e.reportedRuleIDs(timedOutRules)
+	if len(timedOutRules) == 0 {
+		timedOutRules = nil
 	}
 
 	return &EvalResult{
 		Violations:      violations,
-		TotalRulesCount: totalRulesCount,
+		TotalRulesCount: len(evaluatedRules),
+		EvaluatedRules:  evaluatedRules,
+		TimedOutRules:   timedOutRules,
 	}, nil
 }
 
+// inputValue converts an input document to an OPA value, as rego.EvalInput does.
+func inputValue(input map[string]interface{}) (ast.Value, error) {
+	var raw interface{} = input
+	if err := util.RoundTrip(&raw); err != nil {
+		return nil, err
+	}
+	return ast.InterfaceToValue(raw)
+}
+
+// evalRule evaluates the deny query of one rule. If the rule timeout is
+// exceeded, the returned error wraps context.DeadlineExceeded. The evaluation is
+// recorded in the profile, if any.
+func (e *Evaluator) evalRule(ctx context.Context, rule ruleQuery, input ast.Value) (rego.ResultSet, error) {
+	if e.ruleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.ruleTimeout)
+		defer cancel()
+	}
+
+	evalOpts := []rego.EvalOption{rego.EvalParsedInput(input)}
+	var prof *profiler.Profiler
+	if e.profile != nil {
+		prof = profiler.New()
+		evalOpts = append(evalOpts, rego.EvalQueryTracer(prof))
+	}
+	start := time.Now()
+	results, err := rule.deny.Eval(ctx, evalOpts...)
+	timedOut := err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if prof != nil {
+		id := rule.id
+		if fullID, ok := e.idMapping[id]; ok {
+			id = fullID
+		}
+		e.profile.add(id, time.Since(start), timedOut, prof)
+	}
+
+	if timedOut {
+		return nil, fmt.Errorf("rule %s: %w", rule.id, context.DeadlineExceeded)
+	}
+	return results, err
+}
+
+// reportedRuleIDs expands, filters, deduplicates and sorts rule IDs the same
+// way as violation IDs.
+func (e *Evaluator) reportedRuleIDs(ids []string) []string {
+	if len(e.idMapping) > 0 {
+		ids = expandRuleIDs(ids, e.idMapping)
+	}
+
+	// Only rules that passed the filter are reported
+	if len(e.filterIDs) > 0 {
+		ids = filterRuleIDs(ids, e.filterIDs)
+	}
+	ids = uniqueStrings(ids)
+	sort.Strings(ids)
+	return ids
+}
+
+// loadModules collects the Rego modules named by opts: pre-loaded modules, lib
+// modules, and the .rego files found under the policy paths.
+func loadModules(opts *EvalOptions) (map[string]string, error) {
+	msg := i18n.Msg()
+
+	// Discover all .rego files from all paths
+	var allRegoFiles []string
+	for _, path := range opts.PolicyPaths {
+		files, err := discoverRegoFiles(path)
+		if err != nil {
+			return nil, fmt.Errorf(msg.Errors.DiscoverRegoFiles, path, err)
+		}
+		allRegoFiles = append(allRegoFiles, files...)
+	}
+
+	// Load all rego modules
+	modules := make(map[string]string)
+	for k, v := range opts.Modules {
+		modules[k] = v
+	}
+
+	// Add lib modules (provided via LibModules)
+	for k, v := range opts.LibModules {
+		// Use a special prefix for library modules to avoid collision with policy paths
+		modules["_lib_/"+k] = v
+	}
+
+	for _, file := range allRegoFiles {
+		// Skip if already in modules
+		if _, ok := modules[file]; ok {
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf(msg.Errors.ReadRegoFile, file, err)
+		}
+		// Use path as module name (filesystem uses absolute path)
+		moduleName := file
+		absPath, _ := filepath.Abs(file)
+		if absPath != "" {
+			moduleName = absPath
+		}
+		modules[moduleName] = string(content)
+	}
+
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("%s", msg.Errors.NoRegoFilesInPaths)
+	}
+	return modules, nil
+}
+
 // expandViolationIDs expands short IDs in violations to full IDs using the provided mapping.
 func expandViolationIDs(violations []models.OPAViolation, idMapping map[string]string) []models.OPAViolation {
 	for i := range violations {
@@ -236,6 +487,32 @@ func filterViolationsByRuleIDs(violations []models.OPAViolation, ruleIDs []strin
 	return filtered
 }
 
+// expandRuleIDs expands short rule IDs to full IDs using the provided mapping.
+func expandRuleIDs(ids []string, idMapping map[string]string) []string {
+	for i, id := range ids {
+		if fullID, ok := idMapping[id]; ok {
+			ids[i] = fullID
+		}
+	}
+	return ids
+}
+
+// filterRuleIDs keeps only the IDs that appear in ruleIDs.
+func filterRuleIDs(ids []string, ruleIDs []string) []string {
+	ruleIDSet := make(map[string]bool)
+	for _, id := range ruleIDs {
+		ruleIDSet[id] = true
+	}
+
+	var filtered []string
+	for _, id := range ids {
+		if ruleIDSet[id] {
+			filtered = append(filtered, id)
+		}
+	}
+	return filtered
+}
+
 // uniqueStrings removes duplicates from a string slice.
 func uniqueStrings(slice []string) []string {
 	seen := make(map[string]bool)
@@ -336,20 +613,27 @@ func parseViolation(item interface{}) (models.OPAViolation, error) {
 	return v, nil
 }
 
-// parseRulesCount extracts the count of declared rules from OPA results.
+// parseRuleIDs extracts the declared rule IDs from OPA results.
 // The rules query should return a set of rule IDs.
-func parseRulesCount(results rego.ResultSet) int {
+func parseRuleIDs(results rego.ResultSet) []string {
+	var ids []string
 	for _, result := range results {
 		for _, expr := range result.Expressions {
 			// The rules should be a set of rule IDs
 			switch items := expr.Value.(type) {
 			case []interface{}:
-				return len(items)
+				for _, item := range items {
+					if id, ok := item.(string); ok {
+						ids = append(ids, id)
+					}
+				}
 			case map[string]interface{}:
 				// OPA sets are sometimes returned as maps
-				return len(items)
+				for id := range items {
+					ids = append(ids, id)
+				}
 			}
 		}
 	}
-	return 0
+	return ids
 }
@@ -3,10 +3,14 @@ package engine
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aliyun/infraguard/pkg/models"
 	"github.com/open-policy-agent/opa/v1/ast"
@@ -196,7 +200,7 @@ make_violation(id, resource, reason) = violation if {
 					"helpers.rego": helperPolicy,
 				},
 			}
-			evalResult, err := EvaluateWithOpts(opts, input)
+			evalResult, err := EvaluateWithOpts(context.Background(), opts, input)
 			So(err, ShouldBeNil)
 			violations := evalResult.Violations
 
@@ -694,3 +698,454 @@ deny contains violation if {
 		})
 	})
 }
+
+func TestEvaluatedRules(t *testing.T) {
+	Convey("Given policies that declare rule_meta", t, func() {
+		modules := map[string]string{
+			"a.rego": `package infraguard.rules.test.rule_a
+
+rule_meta := {"id": "rule-a"}
+
+deny contains violation if {
+    input.bad == true
+    violation := {"id": "rule-a", "resource_id": "r", "violation_path": [], "meta": {"severity": "high", "reason": "bad"}}
+}
+`,
+			"b.rego": `package infraguard.rules.test.rule_b
+
+rule_meta := {"id": "rule-b"}
+
+deny contains violation if {
+    false
+    violation := {"id": "rule-b"}
+}
+`,
+		}
+		idMapping := map[string]string{
+			"rule-a": "rule:test:rule-a",
+			"rule-b": "rule:test:rule-b",
+		}
+
+		Convey("When evaluating without a rule filter", func() {
+			result, err := EvaluateWithOpts(context.Background(), &EvalOptions{Modules: modules, IDMapping: idMapping}, map[string]interface{}{"bad": true})
+
+			Convey("It should list every declared rule, passing or not", func() {
+				So(err, ShouldBeNil)
+				So(result.EvaluatedRules, ShouldResemble, []string{"rule:test:rule-a", "rule:test:rule-b"})
+				So(result.TotalRulesCount, ShouldEqual, 2)
+				So(len(result.Violations), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When evaluating with a rule filter", func() {
+			result, err := EvaluateWithOpts(context.Background(), &EvalOptions{
+				Modules:   modules,
+				IDMapping: idMapping,
+				RuleIDs:   []string{"rule:test:rule-b", "rule:test:not-loaded"},
+			}, map[string]interface{}{"bad": true})
+
+			Convey("It should only list loaded rules that pass the filter", func() {
+				So(err, ShouldBeNil)
+				So(result.EvaluatedRules, ShouldResemble, []string{"rule:test:rule-b"})
+				So(result.TotalRulesCount, ShouldEqual, 1)
+				So(len(result.Violations), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestEvaluator(t *testing.T) {
+	Convey("Given an evaluator compiled once", t, func() {
+		e, err := NewEvaluator(&EvalOptions{Modules: map[string]string{
+			"a.rego": `package infraguard.rules.test.rule_a
+
+rule_meta := {"id": "rule-a"}
+
+deny contains violation if {
+    input.bad == true
+    violation := {"id": "rule-a", "resource_id": input.name, "violation_path": [], "meta": {"severity": "high", "reason": "bad"}}
+}
+`,
+		}})
+		So(err, ShouldBeNil)
+
+		Convey("It should evaluate many inputs concurrently", func() {
+			const n = 20
+			results := make([]*EvalResult, n)
+			errs := make([]error, n)
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					input := map[string]interface{}{"bad": i%2 == 0, "name": fmt.Sprintf("r%d", i)}
+					results[i], errs[i] = e.Evaluate(context.Background(), input)
+				}(i)
+			}
+			wg.Wait()
+
+			for i := 0; i < n; i++ {
+				So(errs[i], ShouldBeNil)
+				So(results[i].EvaluatedRules, ShouldResemble, []string{"rule-a"})
+				if i%2 == 0 {
+					So(len(results[i].Violations), ShouldEqual, 1)
+					So(results[i].Violations[0].ResourceID, ShouldEqual, fmt.Sprintf("r%d", i))
+				} else {
+					So(results[i].Violations, ShouldBeEmpty)
+				}
+			}
+		})
+	})
+
+	Convey("Given invalid Rego", t, func() {
+		_, err := NewEvaluator(&EvalOptions{Modules: map[string]string{"bad.rego": "package x\n\ndeny contains"}})
+
+		Convey("Compilation should fail up front", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEvaluatorTimeout(t *testing.T) {
+	modules := map[string]string{
+		"fast.rego": `package infraguard.rules.test.fast
+
+rule_meta := {"id": "fast"}
+
+deny contains violation if {
+    input.bad == true
+    violation := {"id": "fast", "resource_id": "r", "violation_path": [], "meta": {"severity": "low", "reason": "bad"}}
+}
+`,
+		"slow.rego": `package infraguard.rules.test.slow
+
+rule_meta := {"id": "slow"}
+
+deny contains violation if {
+    some i in numbers.range(1, 100000)
+    some j in numbers.range(1, 100000)
+    i * j < 0
+    violation := {"id": "slow", "resource_id": "r", "violation_path": [], "meta": {"severity": "low", "reason": "never"}}
+}
+`,
+	}
+
+	Convey("Given a rule that runs past the rule timeout", t, func() {
+		e, err := NewEvaluator(&EvalOptions{Modules: modules, RuleTimeout: 50 * time.Millisecond})
+		So(err, ShouldBeNil)
+
+		result, err := e.Evaluate(context.Background(), map[string]interface{}{"bad": true})
+
+		Convey("It should be reported as timed out while other rules still run", func() {
+			So(err, ShouldBeNil)
+			So(result.TimedOutRules, ShouldResemble, []string{"slow"})
+			So(result.EvaluatedRules, ShouldResemble, []string{"fast"})
+			So(len(result.Violations), ShouldEqual, 1)
+			So(result.Violations[0].ID, ShouldEqual, "fast")
+		})
+	})
+
+	Convey("Given a cancelled context", t, func() {
+		e, err := NewEvaluator(&EvalOptions{Modules: modules})
+		So(err, ShouldBeNil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+		_, err = e.Evaluate(ctx, map[string]interface{}{"bad": true})
+
+		Convey("Evaluation should stop with the context error", func() {
+			So(errors.Is(err, context.Canceled), ShouldBeTrue)
+		})
+	})
+}
+
+func TestEvaluatorExplain(t *testing.T) {
+	modules := map[string]string{
+		"bucket.rego": `package infraguard.rules.test.bucket
+
+rule_meta := {"id": "bucket-private"}
+
+deny contains violation if {
+    some name
+    resource := input.Resources[name]
+    resource.Type == "ALIYUN::OSS::Bucket"
+    resource.Properties.AccessControl != "private"
+    violation := {"id": "bucket-private", "resource_id": name, "violation_path": ["Properties", "AccessControl"], "meta": {"severity": "high", "reason": "public"}}
+}
+`,
+	}
+	input := map[string]interface{}{
+		"Resources": map[string]interface{}{
+			"Public":  map[string]interface{}{"Type": "ALIYUN::OSS::Bucket", "Properties": map[string]interface{}{"AccessControl": "public-read"}},
+			"Private": map[string]interface{}{"Type": "ALIYUN::OSS::Bucket", "Properties": map[string]interface{}{"AccessControl": "private"}},
+		},
+	}
+
+	Convey("Given an evaluator holding a single rule", t, func() {
+		e, err := NewEvaluator(&EvalOptions{Modules: modules})
+		So(err, ShouldBeNil)
+		So(e.HasRule("bucket-private"), ShouldBeTrue)
+		So(e.HasRule("other"), ShouldBeFalse)
+
+		Convey("When explaining the rule", func() {
+			ex, err := e.Explain(context.Background(), input, "bucket-private", nil)
+			So(err, ShouldBeNil)
+
+			Convey("It should report the violation and the body outcome", func() {
+				So(ex.RuleID, ShouldEqual, "bucket-private")
+				So(len(ex.Violations), ShouldEqual, 1)
+				So(ex.Violations[0].ResourceID, ShouldEqual, "Public")
+				So(len(ex.Bodies), ShouldEqual, 1)
+				So(ex.Bodies[0].Location, ShouldEqual, "bucket.rego:5")
+				So(ex.Bodies[0].Evaluated, ShouldEqual, 1)
+				So(ex.Bodies[0].Succeeded, ShouldEqual, 1)
+			})
+
+			Convey("It should report the failed comparison with its bindings", func() {
+				So(len(ex.Bodies[0].Failures), ShouldEqual, 1)
+				failure := ex.Bodies[0].Failures[0]
+				So(failure.Location, ShouldEqual, "bucket.rego:9")
+				So(failure.Count, ShouldEqual, 1)
+				So(failure.Expr, ShouldContainSubstring, `"private"`)
+				var values []string
+				for _, v := range failure.Bindings {
+					values = append(values, v)
+				}
+				So(values, ShouldContain, `"private"`)
+			})
+
+			Convey("It should report the input values looked up", func() {
+				So(ex.Lookups, ShouldContain, Lookup{Ref: "resource.Properties.AccessControl", Value: `"public-read"`})
+				So(ex.Lookups, ShouldContain, Lookup{Ref: "resource.Properties.AccessControl", Value: `"private"`})
+			})
+
+			Convey("It should include the trace", func() {
+				So(len(ex.Trace), ShouldBeGreaterThan, 0)
+				So(ex.Trace[0].Op, ShouldEqual, "Enter")
+				So(ex.Trace[0].Depth, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When explaining the rule for one resource", func() {
+			focus := func(name string) *ExplainFocus {
+				resources := input["Resources"].(map[string]interface{})
+				return &ExplainFocus{Value: resources[name], Name: name, ResourceIDs: []string{name}}
+			}
+
+			Convey("A passing resource should keep only its own failures and lookups", func() {
+				ex, err := e.Explain(context.Background(), input, "bucket-private", focus("Private"))
+				So(err, ShouldBeNil)
+				So(ex.Violations, ShouldBeEmpty)
+				So(ex.Bodies[0].Evaluated, ShouldEqual, 1)
+				So(ex.Bodies[0].Succeeded, ShouldEqual, 0)
+				So(len(ex.Bodies[0].Failures), ShouldEqual, 1)
+				So(ex.Bodies[0].Failures[0].Location, ShouldEqual, "bucket.rego:9")
+				So(ex.Lookups, ShouldContain, Lookup{Ref: "resource.Properties.AccessControl", Value: `"private"`})
+				So(ex.Lookups, ShouldNotContain, Lookup{Ref: "resource.Properties.AccessControl", Value: `"public-read"`})
+			})
+
+			Convey("A failing resource should keep only its own violation", func() {
+				ex, err := e.Explain(context.Background(), input, "bucket-private", focus("Public"))
+				So(err, ShouldBeNil)
+				So(len(ex.Violations), ShouldEqual, 1)
+				So(ex.Violations[0].ResourceID, ShouldEqual, "Public")
+				So(ex.Bodies[0].Evaluated, ShouldEqual, 1)
+				So(ex.Bodies[0].Succeeded, ShouldEqual, 1)
+				So(ex.Bodies[0].Failures, ShouldBeEmpty)
+			})
+		})
+
+		Convey("An empty rule ID should select the only rule", func() {
+			ex, err := e.Explain(context.Background(), input, "", nil)
+			So(err, ShouldBeNil)
+			So(ex.RuleID, ShouldEqual, "bucket-private")
+		})
+
+		Convey("An unknown rule ID should return an error", func() {
+			_, err := e.Explain(context.Background(), input, "other", nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEvaluatorProfile(t *testing.T) {
+	modules := map[string]string{
+		"bucket.rego": `package infraguard.rules.test.bucket
+
+rule_meta := {"id": "bucket-private"}
+
+deny contains violation if {
+    some name
+    resource := input.Resources[name]
+    resource.Properties.AccessControl != "private"
+    violation := {"id": "bucket-private", "resource_id": name, "violation_path": [], "meta": {"severity": "high", "reason": "public"}}
+}
+`,
+		"other.rego": `package infraguard.rules.test.other
+
+rule_meta := {"id": "other"}
+
+deny contains violation if {
+    input.never == true
+    violation := {"id": "other", "resource_id": "r", "violation_path": [], "meta": {"severity": "low", "reason": "never"}}
+}
+`,
+	}
+	input := map[string]interface{}{
+		"Resources": map[string]interface{}{
+			"A": map[string]interface{}{"Properties": map[string]interface{}{"AccessControl": "public-read"}},
+		},
+	}
+
+	Convey("Given an evaluator with a profile", t, func() {
+		profile := NewProfile()
+		e, err := NewEvaluator(&EvalOptions{
+			Modules:   modules,
+			IDMapping: map[string]string{"bucket-private": "rule:test:bucket-private"},
+			Profile:   profile,
+		})
+		So(err, ShouldBeNil)
+
+		for i := 0; i < 3; i++ {
+			_, err := e.Evaluate(context.Background(), input)
+			So(err, ShouldBeNil)
+		}
+		rules := profile.Rules()
+
+		Convey("It should accumulate statistics per rule under the full rule ID", func() {
+			So(len(rules), ShouldEqual, 2)
+			var ids []string
+			for _, rule := range rules {
+				ids = append(ids, rule.RuleID)
+				So(rule.Evaluations, ShouldEqual, 3)
+				So(rule.TotalTimeNs, ShouldBeGreaterThan, 0)
+				So(len(rule.SlowestExpr), ShouldBeLessThanOrEqualTo, MaxProfileExprs)
+			}
+			So(ids, ShouldContain, "rule:test:bucket-private")
+			So(ids, ShouldContain, "other")
+			So(rules[0].TotalTimeNs, ShouldBeGreaterThanOrEqualTo, rules[1].TotalTimeNs)
+		})
+
+		Convey("It should report the expressions of the rule by location", func() {
+			var bucket RuleProfile
+			for _, rule := range rules {
+				if rule.RuleID == "rule:test:bucket-private" {
+					bucket = rule
+				}
+			}
+			var locations []string
+			for _, expr := range bucket.SlowestExpr {
+				locations = append(locations, expr.Location)
+				if expr.Location == "bucket.rego:8" {
+					So(expr.Expr, ShouldContainSubstring, "resource.Properties.AccessControl")
+					So(expr.NumEval, ShouldBeGreaterThanOrEqualTo, 3)
+				}
+			}
+			So(locations, ShouldContain, "bucket.rego:8")
+		})
+	})
+}
+
+func TestEvaluatorParams(t *testing.T) {
+	modules := map[string]string{
+		"prefix.rego": `package infraguard.rules.test.prefix
+
+rule_meta := {
+    "id": "max-prefix",
+    "params": {"max_prefix_length": {"type": "number", "default": 28}},
+}
+
+max_prefix := v if {
+    v := data.infraguard.params[rule_meta.id].max_prefix_length
+}
+
+deny contains violation if {
+    some name
+    resource := input.Resources[name]
+    resource.Prefix > max_prefix
+    violation := {"id": "max-prefix", "resource_id": name, "violation_path": [], "meta": {"severity": "medium", "reason": "too small"}}
+}
+`,
+	}
+	input := map[string]interface{}{
+		"Resources": map[string]interface{}{
+			"A": map[string]interface{}{"Prefix": 24},
+			"B": map[string]interface{}{"Prefix": 29},
+		},
+	}
+	idMapping := map[string]string{"max-prefix": "rule:test:max-prefix"}
+
+	evaluate := func(params map[string]map[string]interface{}) ([]string, error) {
+		e, err := NewEvaluator(&EvalOptions{Modules: modules, IDMapping: idMapping, Params: params})
+		if err != nil {
+			return nil, err
+		}
+		result, err := e.Evaluate(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		var ids []string
+		for _, v := range result.Violations {
+			ids = append(ids, v.ResourceID)
+		}
+		return ids, nil
+	}
+
+	Convey("Given a rule that declares a parameter", t, func() {
+		Convey("When no override is given", func() {
+			ids, err := evaluate(nil)
+
+			Convey("It should use the declared default", func() {
+				So(err, ShouldBeNil)
+				So(ids, ShouldResemble, []string{"B"})
+			})
+		})
+
+		Convey("When the parameter is overridden by short rule ID", func() {
+			ids, err := evaluate(map[string]map[string]interface{}{
+				"max-prefix": {"max_prefix_length": 20},
+			})
+
+			Convey("It should use the override", func() {
+				So(err, ShouldBeNil)
+				So(len(ids), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When the parameter is overridden by full rule ID", func() {
+			ids, err := evaluate(map[string]map[string]interface{}{
+				"rule:test:max-prefix": {"max_prefix_length": 30},
+			})
+
+			Convey("It should use the override", func() {
+				So(err, ShouldBeNil)
+				So(ids, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When an unknown parameter is given", func() {
+			_, err := evaluate(map[string]map[string]interface{}{
+				"max-prefix": {"min_prefix_length": 20},
+			})
+
+			Convey("It should return an error naming the parameter", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "min_prefix_length")
+			})
+		})
+
+		Convey("When a parameter has the wrong type", func() {
+			_, err := evaluate(map[string]map[string]interface{}{
+				"max-prefix": {"max_prefix_length": "big"},
+			})
+
+			Convey("It should return a type error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "max_prefix_length")
+			})
+		})
+	})
+}
@@ -0,0 +1,465 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/topdown"
+)
+
+// Limits that keep an explanation readable.
+const (
+	maxExplainValueLen = 200 // Longer values are truncated
+	maxExplainLookups  = 200 // Further lookups are dropped
+)
+
+// Explanation describes how one rule evaluated against one input: the outcome
+// of each of its deny bodies, the values it looked up, and the evaluation trace.
+type Explanation struct {
+	RuleID     string                `json:"rule_id"`
+	Violations []models.OPAViolation `json:"violations"`
+	Bodies     []BodyOutcome         `json:"bodies"`
+	Lookups    []Lookup              `json:"lookups"`
+	Trace      []TraceStep           `json:"trace"`
+}
+
+// BodyOutcome summarizes the evaluation of one deny rule body.
+type BodyOutcome struct {
+	Location  string        `json:"location"`  // Where the deny rule is declared
+	Evaluated int           `json:"evaluated"` // Times the body was entered
+	Succeeded int           `json:"succeeded"` // Times the body produced a violation
+	Failures  []ExprFailure `json:"failures,omitempty"`
+}
+
+// ExprFailure is an expression of a deny body that evaluated to false, ending
+// that attempt of the body.
+type ExprFailure struct {
+	Location string            `json:"location"`
+	Expr     string            `json:"expr"`
+	Count    int               `json:"count"`
+	Bindings map[string]string `json:"bindings,omitempty"` // Variable values at the first failure
+}
+
+// Lookup is a reference into the input, or into a value taken from it, and the
+// value it resolved to ("undefined" if it did not resolve).
+type Lookup struct {
+	Ref   string `json:"ref"`
+	Value string `json:"value"`
+}
+
+// ExplainFocus narrows an explanation to one resource of the input. The rule
+// is still evaluated against the whole input, so rules that look at other
+// resources behave as in a scan; only the violations, body outcomes and lookups
+// that concern the resource are kept.
+type ExplainFocus struct {
+	Value       interface{} // The resource as it appears in the input
+	Name        string      // Key of the resource in the input, e.g. a logical ID
+	ResourceIDs []string    // IDs the rule may report the resource's violations under
+}
+
+// TraceStep is one event of the evaluation trace.
+type TraceStep struct {
+	Op       string            `json:"op"`
+	Depth    int               `json:"depth"`
+	Location string            `json:"location,omitempty"`
+	Node     string            `json:"node"`
+	Locals   map[string]string `json:"locals,omitempty"`
+}
+
+// Explain evaluates a single rule against input with OPA tracing enabled.
+// ruleID is a rule_meta.id or its full ID; it may be empty if the evaluator
+// holds exactly one rule. If several loaded packages declare the rule (e.g.
+// its ROS and Terraform implementations), all of them are traced. If focus is
+// not nil, the explanation is narrowed to that resource. The rule timeout does
+// not apply.
+func (e *Evaluator) Explain(ctx context.Context, input map[string]interface{}, ruleID string, focus *ExplainFocus) (*Explanation, error) {
+	msg := i18n.Msg()
+
+	rules := e.findRules(ruleID)
+	if len(rules) == 0 {
+		return nil, fmt.Errorf(msg.Errors.ExplainRuleNotFound, ruleID)
+	}
+
+	value, err := inputValue(input)
+	if err != nil {
+		return nil, fmt.Errorf(msg.Errors.EvaluatePoliciesInternal, err)
+	}
+	var matcher *focusMatcher
+	if focus != nil {
+		if matcher, err = newFocusMatcher(focus); err != nil {
+			return nil, fmt.Errorf(msg.Errors.EvaluatePoliciesInternal, err)
+		}
+	}
+
+	id := rules[0].id
+	if fullID, ok := e.idMapping[id]; ok {
+		id = fullID
+	}
+	ex := &Explanation{
+		RuleID:     id,
+		Violations: []models.OPAViolation{},
+		Bodies:     []BodyOutcome{},
+		Lookups:    []Lookup{},
+		Trace:      []TraceStep{},
+	}
+	for _, rule := range rules {
+		tracer := topdown.NewBufferTracer()
+		results, err := rule.deny.Eval(ctx, rego.EvalParsedInput(value), rego.EvalQueryTracer(tracer))
+		if err != nil {
+			return nil, fmt.Errorf(msg.Errors.EvaluatePoliciesInternal, err)
+		}
+		violations, err := parseResults(results)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range expandViolationIDs(violations, e.idMapping) {
+			if focus == nil || containsID(focus.ResourceIDs, v.ResourceID) {
+				ex.Violations = append(ex.Violations, v)
+			}
+		}
+		ex.addTrace(*tracer, value, matcher)
+	}
+	return ex, nil
+}
+
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// focusMatcher tells which trace events concern the focused resource: those of
+// a query with a variable bound to the resource or to its name, and those of
+// the queries it calls meanwhile (e.g. helper functions).
+type focusMatcher struct {
+	value   ast.Value
+	name    ast.Value
+	parents map[uint64]uint64 // Query ID -> parent query ID
+	about   map[uint64]bool   // Query ID -> whether its latest event concerned the resource
+}
+
+func newFocusMatcher(focus *ExplainFocus) (*focusMatcher, error) {
+	value, err := ast.InterfaceToValue(focus.Value)
+	if err != nil {
+		return nil, err
+	}
+	return &focusMatcher{
+		value:   value,
+		name:    ast.String(focus.Name),
+		parents: make(map[uint64]uint64),
+		about:   make(map[uint64]bool),
+	}, nil
+}
+
+// concerns reports whether evt concerns the resource. It must see every event
+// in order. A nil matcher concerns every event.
+func (m *focusMatcher) concerns(evt *topdown.Event) bool {
+	if m == nil {
+		return true
+	}
+	if _, ok := m.parents[evt.QueryID]; !ok {
+		m.parents[evt.QueryID] = evt.ParentID
+	}
+	about := false
+	if evt.Locals != nil {
+		evt.Locals.Iter(func(_, v ast.Value) bool {
+			about = v.Compare(m.name) == 0 || v.Compare(m.value) == 0
+			return about
+		})
+	}
+	m.about[evt.QueryID] = about
+	for q := evt.QueryID; !about; {
+		parent, ok := m.parents[q]
+		if !ok || parent == q {
+			break
+		}
+		q = parent
+		about = m.about[q]
+	}
+	return about
+}
+
+// HasRule reports whether the evaluator holds the rule with the given ID
+// (a rule_meta.id or its full ID).
+func (e *Evaluator) HasRule(ruleID string) bool {
+	return len(e.findRules(ruleID)) > 0
+}
+
+// findRules returns the rule packages declaring the given ID, or the only rule
+// package if ruleID is empty.
+func (e *Evaluator) findRules(ruleID string) []ruleQuery {
+	if ruleID == "" {
+		if len(e.rules) == 1 {
+			return e.rules
+		}
+		return nil
+	}
+	var rules []ruleQuery
+	for _, rule := range e.rules {
+		if rule.id == ruleID || e.idMapping[rule.id] == ruleID {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// addTrace adds the body outcomes, lookups and trace steps of one traced query.
+// Events of the generated query that wraps the deny rule are left out of the trace.
+// With a focus matcher, the body outcomes count the attempts of each body on
+// the resource, and only the failures and lookups concerning it are kept.
+func (ex *Explanation) addTrace(events []*topdown.Event, input ast.Value, focus *focusMatcher) {
+	first := len(ex.Trace)
+	minDepth := -1
+	depths := make(map[uint64]int)
+	bodies := make(map[uint64]*BodyOutcome) // Query ID of a deny body -> its outcome
+	byLocation := make(map[string]*BodyOutcome)
+	var order []string
+	seenLookups := make(map[string]bool)
+	for _, lookup := range ex.Lookups {
+		seenLookups[lookup.Ref+"\x00"+lookup.Value] = true
+	}
+
+	for _, evt := range events {
+		if _, ok := depths[evt.QueryID]; !ok {
+			if d, ok := depths[evt.ParentID]; ok {
+				depths[evt.QueryID] = d + 1
+			} else {
+				depths[evt.QueryID] = 0
+			}
+		}
+		concerned := focus.concerns(evt)
+		if evt.Location == nil || evt.Location.File == "" {
+			continue
+		}
+
+		names := localNames(evt)
+		step := TraceStep{
+			Op:       string(evt.Op),
+			Depth:    depths[evt.QueryID],
+			Location: evt.Location.String(),
+			Node:     nodeString(evt.Node, names),
+			Locals:   bindings(evt, names, nil),
+		}
+		if minDepth < 0 || step.Depth < minDepth {
+			minDepth = step.Depth
+		}
+		ex.Trace = append(ex.Trace, step)
+
+		switch node := evt.Node.(type) {
+		case *ast.Rule:
+			if !isDenyRule(node) {
+				continue
+			}
+			loc := step.Location
+			outcome, ok := byLocation[loc]
+			if !ok {
+				outcome = &BodyOutcome{Location: loc}
+				byLocation[loc] = outcome
+				order = append(order, loc)
+			}
+			switch evt.Op {
+			case topdown.EnterOp:
+				bodies[evt.QueryID] = outcome
+				if focus == nil {
+					outcome.Evaluated++
+				}
+			case topdown.ExitOp:
+				if bodies[evt.QueryID] == outcome && concerned {
+					outcome.Succeeded++
+					if focus != nil {
+						outcome.Evaluated++
+					}
+				}
+			}
+		case *ast.Expr:
+			if !concerned {
+				continue
+			}
+			if evt.Op == topdown.FailOp {
+				if outcome, ok := bodies[evt.QueryID]; ok {
+					outcome.addFailure(evt, node, names, step)
+					if focus != nil {
+						outcome.Evaluated++
+					}
+				}
+			}
+			if evt.Op == topdown.EvalOp {
+				ex.addLookups(evt, node, names, input, seenLookups)
+			}
+		}
+	}
+
+	for i := first; i < len(ex.Trace); i++ {
+		ex.Trace[i].Depth -= minDepth
+	}
+	for _, loc := range order {
+		ex.Bodies = append(ex.Bodies, *byLocation[loc])
+	}
+}
+
+// addFailure records a failed expression of the body.
+func (b *BodyOutcome) addFailure(evt *topdown.Event, expr *ast.Expr, names map[ast.Var]ast.Var, step TraceStep) {
+	for i := range b.Failures {
+		if b.Failures[i].Location == step.Location && b.Failures[i].Expr == step.Node {
+			b.Failures[i].Count++
+			return
+		}
+	}
+	b.Failures = append(b.Failures, ExprFailure{
+		Location: step.Location,
+		Expr:     step.Node,
+		Count:    1,
+		Bindings: bindings(evt, names, expr),
+	})
+}
+
+// addLookups records the references of expr that read the input, directly or
+// through a variable bound to a value, with the values they resolve to.
+func (ex *Explanation) addLookups(evt *topdown.Event, expr *ast.Expr, names map[ast.Var]ast.Var, input ast.Value, seen map[string]bool) {
+	ast.WalkRefs(expr, func(ref ast.Ref) bool {
+		if len(ex.Lookups) >= maxExplainLookups || len(ref) < 2 {
+			return false
+		}
+		head, ok := ref[0].Value.(ast.Var)
+		if !ok || head.Equal(ast.DefaultRootDocument.Value) {
+			return false
+		}
+
+		var base ast.Value
+		if head.Equal(ast.InputRootDocument.Value) {
+			base = input
+		} else if evt.Locals != nil {
+			base = evt.Locals.Get(head)
+		}
+		if base == nil {
+			return false
+		}
+
+		path := make(ast.Ref, 0, len(ref)-1)
+		for _, term := range ref[1:] {
+			if v, ok := term.Value.(ast.Var); ok {
+				if evt.Locals == nil {
+					return false
+				}
+				bound := evt.Locals.Get(v)
+				if bound == nil {
+					return false
+				}
+				term = ast.NewTerm(bound)
+			}
+			path = append(path, term)
+		}
+
+		value := "undefined"
+		if found, err := base.Find(path); err == nil {
+			value = truncate(found.String())
+		}
+		lookup := Lookup{Ref: nodeString(ref, names), Value: value}
+		if key := lookup.Ref + "\x00" + lookup.Value; !seen[key] {
+			seen[key] = true
+			ex.Lookups = append(ex.Lookups, lookup)
+		}
+		return false
+	})
+}
+
+// isDenyRule reports whether rule is a deny rule of a package.
+func isDenyRule(rule *ast.Rule) bool {
+	ref := rule.Head.Ref()
+	return len(ref) > 0 && ref[0].Value.Compare(ast.Var("deny")) == 0
+}
+
+// localNames maps the compiler-generated names of the event's local variables
+// back to the names used in the policy.
+func localNames(evt *topdown.Event) map[ast.Var]ast.Var {
+	names := make(map[ast.Var]ast.Var, len(evt.LocalMetadata))
+	for v, md := range evt.LocalMetadata {
+		names[v] = md.Name
+	}
+	return names
+}
+
+// nodeString renders a trace node with the policy's variable names. Rules are
+// rendered by their reference, e.g. data.infraguard.rules.aliyun.x.deny.
+func nodeString(node interface{}, names map[ast.Var]ast.Var) string {
+	switch n := node.(type) {
+	case nil:
+		return ""
+	case *ast.Rule:
+		if n.Module != nil {
+			return n.Ref().String()
+		}
+		return n.Head.Ref().String()
+	case *ast.Expr:
+		node = n.Copy()
+	case ast.Body:
+		node = n.Copy()
+	case ast.Ref:
+		node = n.Copy()
+	}
+	renamed, err := ast.TransformVars(node, func(v ast.Var) (ast.Value, error) {
+		if name, ok := names[v]; ok {
+			return name, nil
+		}
+		return v, nil
+	})
+	if err != nil {
+		renamed = node
+	}
+	return truncate(fmt.Sprint(renamed))
+}
+
+// bindings returns the values of the event's local variables that have a name in
+// the policy. If expr is not nil, only the variables used by expr are included,
+// along with the compiler temporaries it refers to.
+func bindings(evt *topdown.Event, names map[ast.Var]ast.Var, expr *ast.Expr) map[string]string {
+	if evt.Locals == nil {
+		return nil
+	}
+	var used map[ast.Var]bool
+	if expr != nil {
+		used = make(map[ast.Var]bool)
+		ast.WalkVars(expr, func(v ast.Var) bool {
+			used[v] = true
+			return false
+		})
+	}
+
+	out := make(map[string]string)
+	evt.Locals.Iter(func(k, v ast.Value) bool {
+		local, ok := k.(ast.Var)
+		if !ok || (used != nil && !used[local]) {
+			return false
+		}
+		name, ok := names[local]
+		if !ok && expr != nil {
+			name, ok = local, true // A temporary of the expression, shown as rendered
+		}
+		if !ok || (expr == nil && (name.IsGenerated() || strings.HasPrefix(string(name), "_"))) {
+			return false
+		}
+		out[string(name)] = truncate(v.String())
+		return false
+	})
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// truncate shortens s to maxExplainValueLen runes.
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxExplainValueLen {
+		return s
+	}
+	return string(runes[:maxExplainValueLen]) + "..."
+}
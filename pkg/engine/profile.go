@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/profiler"
+)
+
+// MaxProfileExprs is the number of slowest expressions reported per rule.
+const MaxProfileExprs = 5
+
+// RuleProfile holds the evaluation statistics of one rule, accumulated over all
+// the inputs it was evaluated against.
+type RuleProfile struct {
+	RuleID      string        `json:"rule_id"`
+	TotalTimeNs int64         `json:"total_time_ns"` // Wall time of all evaluations
+	Evaluations int           `json:"evaluations"`   // Number of inputs the rule was evaluated against
+	TimedOut    int           `json:"timed_out,omitempty"`
+	SlowestExpr []ExprProfile `json:"slowest_exprs"` // At most MaxProfileExprs, slowest first
+}
+
+// ExprProfile holds the statistics of the expressions on one policy line, as
+// reported by the OPA profiler. Expressions of helper functions are attributed
+// to the rules that call them.
+type ExprProfile struct {
+	Location    string `json:"location"`
+	Expr        string `json:"expr"`
+	TotalTimeNs int64  `json:"total_time_ns"`
+	NumEval     int    `json:"num_eval"`
+	NumRedo     int    `json:"num_redo"`
+}
+
+// Profile accumulates per-rule evaluation statistics. Pass it in
+// EvalOptions.Profile to profile every evaluation of an Evaluator. It is safe for
+// concurrent use.
+type Profile struct {
+	mu    sync.Mutex
+	rules map[string]*ruleStats
+}
+
+// ruleStats is the running total of one rule; exprs is keyed by location.
+type ruleStats struct {
+	profile RuleProfile
+	exprs   map[string]*ExprProfile
+}
+
+// NewProfile returns an empty profile.
+func NewProfile() *Profile {
+	return &Profile{rules: make(map[string]*ruleStats)}
+}
+
+// add records one evaluation of a rule.
+func (p *Profile) add(ruleID string, elapsed time.Duration, timedOut bool, prof *profiler.Profiler) {
+	stats := prof.ReportTopNResults(0, nil)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rs, ok := p.rules[ruleID]
+	if !ok {
+		rs = &ruleStats{
+			profile: RuleProfile{RuleID: ruleID},
+			exprs:   make(map[string]*ExprProfile),
+		}
+		p.rules[ruleID] = rs
+	}
+	rs.profile.TotalTimeNs += elapsed.Nanoseconds()
+	rs.profile.Evaluations++
+	if timedOut {
+		rs.profile.TimedOut++
+	}
+
+	for _, stat := range stats {
+		if stat.Location == nil || stat.Location.File == "" {
+			continue // The generated query that wraps the deny rule
+		}
+		loc := stat.Location.String()
+		expr, ok := rs.exprs[loc]
+		if !ok {
+			text := strings.Join(strings.Fields(string(stat.Location.Text)), " ")
+			expr = &ExprProfile{Location: loc, Expr: truncate(text)}
+			rs.exprs[loc] = expr
+		}
+		expr.TotalTimeNs += stat.ExprTimeNs
+		expr.NumEval += stat.NumEval
+		expr.NumRedo += stat.NumRedo
+	}
+}
+
+// Rules returns the statistics of every profiled rule, slowest first.
+func (p *Profile) Rules() []RuleProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rules := make([]RuleProfile, 0, len(p.rules))
+	for _, rs := range p.rules {
+		rule := rs.profile
+		rule.SlowestExpr = make([]ExprProfile, 0, len(rs.exprs))
+		for _, expr := range rs.exprs {
+			rule.SlowestExpr = append(rule.SlowestExpr, *expr)
+		}
+		sort.Slice(rule.SlowestExpr, func(i, j int) bool {
+			a, b := rule.SlowestExpr[i], rule.SlowestExpr[j]
+			if a.TotalTimeNs != b.TotalTimeNs {
+				return a.TotalTimeNs > b.TotalTimeNs
+			}
+			return a.Location < b.Location
+		})
+		if len(rule.SlowestExpr) > MaxProfileExprs {
+			rule.SlowestExpr = rule.SlowestExpr[:MaxProfileExprs]
+		}
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].TotalTimeNs != rules[j].TotalTimeNs {
+			return rules[i].TotalTimeNs > rules[j].TotalTimeNs
+		}
+		return rules[i].RuleID < rules[j].RuleID
+	})
+	return rules
+}
@@ -0,0 +1,182 @@
+// Package gitdiff lists the files and lines changed in a local git repository
+// since a given ref. It runs the git command line and never contacts a remote.
+package gitdiff
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of line numbers in the new version of a file.
+type LineRange struct {
+	Start int
+	End   int
+}
+
+// Changes holds the files changed since a ref, keyed by canonical absolute
+// path. A file whose line ranges are nil changed as a whole, e.g. because it
+// is untracked.
+type Changes struct {
+	Ref   string
+	files map[string][]LineRange
+}
+
+// hunkRe matches the new-file range of a unified diff hunk header, e.g.
+// "@@ -10,2 +12,3 @@".
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Since lists the changes between ref and the working tree of the repository
+// containing dir, including uncommitted and untracked files. Deleted files are
+// not included.
+func Since(dir, ref string) (*Changes, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root = canonical(strings.TrimSpace(root))
+
+	// git diff would take an unknown ref for a path; check it explicitly.
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown revision %s", ref)
+	}
+
+	names, err := git(root, "-c", "core.quotePath=false", "diff", "--name-only", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := git(root, "-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "-U0", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	// Files without hunks, such as binary files, changed as a whole.
+	hunks := parseDiff(diff)
+	c := &Changes{Ref: ref, files: make(map[string][]LineRange)}
+	for _, path := range strings.Split(names, "\n") {
+		if path != "" {
+			c.files[filepath.Join(root, filepath.FromSlash(path))] = hunks[path]
+		}
+	}
+
+	untracked, err := git(root, "-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(untracked, "\n") {
+		if path != "" {
+			c.files[filepath.Join(root, filepath.FromSlash(path))] = nil
+		}
+	}
+	return c, nil
+}
+
+// parseDiff returns the changed line ranges of each file in a unified diff
+// with zero context lines, keyed by the file path relative to the repository
+// root. A hunk that only removes lines yields the lines on both sides of the
+// removal.
+func parseDiff(diff string) map[string][]LineRange {
+	files := make(map[string][]LineRange)
+	var current string
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			current = ""
+			if name := strings.TrimPrefix(line, "+++ "); strings.HasPrefix(name, "b/") {
+				current = strings.TrimPrefix(name, "b/")
+			}
+		case strings.HasPrefix(line, "@@ ") && current != "":
+			m := hunkRe.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			r := LineRange{Start: start, End: start + count - 1}
+			if count == 0 {
+				r = LineRange{Start: start, End: start + 1}
+			}
+			files[current] = append(files[current], r)
+		}
+	}
+	return files
+}
+
+// HasFile reports whether the file at path changed.
+func (c *Changes) HasFile(path string) bool {
+	_, ok := c.files[canonical(path)]
+	return ok
+}
+
+// HasDirFile reports whether a file directly in dir for which match returns
+// true changed. match is given the file's path.
+func (c *Changes) HasDirFile(dir string, match func(path string) bool) bool {
+	dir = canonical(dir)
+	for path := range c.files {
+		if filepath.Dir(path) == dir && match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether any changed line of the file at path falls within
+// [start, end]. An end of 0 means the end of the file.
+func (c *Changes) Overlaps(path string, start, end int) bool {
+	ranges, ok := c.files[canonical(path)]
+	if !ok {
+		return false
+	}
+	if ranges == nil {
+		return true
+	}
+	for _, r := range ranges {
+		if r.End >= start && (end == 0 || r.Start <= end) {
+			return true
+		}
+	}
+	return false
+}
+
+// git runs a git command in dir and returns its standard output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git: %s", msg)
+		}
+		return "", fmt.Errorf("git: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// canonical returns the absolute path with symbolic links resolved, so paths
+// reported by git compare equal to the paths of scanned templates.
+func canonical(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	// The file may not exist (e.g. deleted); resolve its directory instead.
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return filepath.Join(dir, filepath.Base(abs))
+	}
+	return abs
+}
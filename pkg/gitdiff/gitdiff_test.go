@@ -0,0 +1,108 @@
+package gitdiff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseDiff(t *testing.T) {
+	Convey("Given a unified diff with zero context lines", t, func() {
+		diff := `diff --git a/stacks/a.yaml b/stacks/a.yaml
+index 1111111..2222222 100644
+--- a/stacks/a.yaml
++++ b/stacks/a.yaml
+@@ -3 +3 @@ Resources:
+-      CidrBlock: 10.0.0.0/24
++      CidrBlock: 10.0.0.0/29
+@@ -10,0 +11,3 @@ Resources:
++  Extra:
++    Type: ALIYUN::ECS::VPC
++    Properties: {}
+@@ -20,2 +22,0 @@ Resources:
+-  Removed:
+-    Type: ALIYUN::ECS::VPC
+diff --git a/old.tf b/old.tf
+deleted file mode 100644
+--- a/old.tf
++++ /dev/null
+@@ -1,2 +0,0 @@
+-resource "x" "y" {
+-}
+`
+		files := parseDiff(diff)
+
+		Convey("It should return the new-file line ranges of each hunk", func() {
+			So(files["stacks/a.yaml"], ShouldResemble, []LineRange{
+				{Start: 3, End: 3},
+				{Start: 11, End: 13},
+				{Start: 22, End: 23},
+			})
+		})
+
+		Convey("It should skip deleted files", func() {
+			So(len(files), ShouldEqual, 1)
+		})
+	})
+}
+
+func TestSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	Convey("Given a git repository with committed and uncommitted changes", t, func() {
+		root := t.TempDir()
+		run := func(args ...string) {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = root
+			cmd.Env = append(os.Environ(),
+				"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+				"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+			So(cmd.Run(), ShouldBeNil)
+		}
+		write := func(name, content string) {
+			path := filepath.Join(root, name)
+			So(os.MkdirAll(filepath.Dir(path), 0755), ShouldBeNil)
+			So(os.WriteFile(path, []byte(content), 0644), ShouldBeNil)
+		}
+
+		run("init", "-q")
+		write("a.yaml", "one\ntwo\nthree\n")
+		write("tf/main.tf", "resource \"a\" \"b\" {}\n")
+		write("same.yaml", "unchanged\n")
+		run("add", "-A")
+		run("commit", "-q", "-m", "init")
+
+		write("a.yaml", "one\nTWO\nthree\n")
+		write("tf/vars.tf", "variable \"x\" {}\n")
+
+		c, err := Since(root, "HEAD")
+		So(err, ShouldBeNil)
+
+		Convey("It should list modified and untracked files", func() {
+			So(c.HasFile(filepath.Join(root, "a.yaml")), ShouldBeTrue)
+			So(c.HasFile(filepath.Join(root, "tf", "vars.tf")), ShouldBeTrue)
+			So(c.HasFile(filepath.Join(root, "same.yaml")), ShouldBeFalse)
+			isTF := func(path string) bool { return filepath.Ext(path) == ".tf" }
+			isYAML := func(path string) bool { return filepath.Ext(path) == ".yaml" }
+			So(c.HasDirFile(filepath.Join(root, "tf"), isTF), ShouldBeTrue)
+			So(c.HasDirFile(filepath.Join(root, "tf"), isYAML), ShouldBeFalse)
+		})
+
+		Convey("It should report the changed lines", func() {
+			a := filepath.Join(root, "a.yaml")
+			So(c.Overlaps(a, 2, 2), ShouldBeTrue)
+			So(c.Overlaps(a, 3, 0), ShouldBeFalse)
+			So(c.Overlaps(filepath.Join(root, "tf", "vars.tf"), 100, 0), ShouldBeTrue)
+		})
+
+		Convey("It should reject an unknown ref", func() {
+			_, err := Since(root, "no-such-ref")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -81,6 +81,8 @@ type Messages struct {
 		Description         string `yaml:"description"`
 		ResourceTypes       string `yaml:"resource_types"`
 		IaCTypes            string `yaml:"iac_types"`
+		Params              string `yaml:"params"`
+		ParamLine           string `yaml:"param_line"`
 		PackID              string `yaml:"pack_id"`
 	} `yaml:"policy_get"`
 
@@ -200,6 +202,7 @@ type Messages struct {
 		DetailLoad     string `yaml:"detail_load"`
 		DetailLoadTF   string `yaml:"detail_load_tf"`
 		DetailEval     string `yaml:"detail_eval"`
+		DetailTimeout  string `yaml:"detail_timeout"`
 	} `yaml:"policy_test"`
 
 	// Waiver command
@@ -210,30 +213,92 @@ type Messages struct {
 		RulesDirFlag string `yaml:"rules_dir_flag"`
 		NotFound     string `yaml:"not_found"`
 		List         struct {
-			Short          string `yaml:"short"`
-			Title          string `yaml:"title"`
-			None           string `yaml:"none"`
-			HeaderRule     string `yaml:"header_rule"`
-			HeaderResource string `yaml:"header_resource"`
-			HeaderStatus   string `yaml:"header_status"`
-			HeaderExpires  string `yaml:"header_expires"`
-			HeaderReason   string `yaml:"header_reason"`
-			StateActive    string `yaml:"state_active"`
-			StateExpired   string `yaml:"state_expired"`
-			StatePermanent string `yaml:"state_permanent"`
+			Short            string `yaml:"short"`
+			Title            string `yaml:"title"`
+			None             string `yaml:"none"`
+			HeaderRule       string `yaml:"header_rule"`
+			HeaderResource   string `yaml:"header_resource"`
+			HeaderStatus     string `yaml:"header_status"`
+			HeaderExpires    string `yaml:"header_expires"`
+			HeaderCreated    string `yaml:"header_created"`
+			HeaderApprovedBy string `yaml:"header_approved_by"`
+			HeaderTicket     string `yaml:"header_ticket"`
+			HeaderReason     string `yaml:"header_reason"`
+			StateActive      string `yaml:"state_active"`
+			StateExpired     string `yaml:"state_expired"`
+			StatePermanent   string `yaml:"state_permanent"`
+			Ceiling          string `yaml:"ceiling"`
+			Pack             string `yaml:"pack"`
+			MaxSeverity      string `yaml:"max_severity"`
 		} `yaml:"list"`
 		Lint struct {
-			Short          string `yaml:"short"`
-			Valid          string `yaml:"valid"`
-			Summary        string `yaml:"summary"`
-			WarnSummary    string `yaml:"warn_summary"`
-			MissingRule    string `yaml:"missing_rule"`
-			MissingReason  string `yaml:"missing_reason"`
-			UnknownRule    string `yaml:"unknown_rule"`
-			InvalidExpires string `yaml:"invalid_expires"`
-			Expired        string `yaml:"expired"`
-			Permanent      string `yaml:"permanent"`
+			Short              string `yaml:"short"`
+			Valid              string `yaml:"valid"`
+			Summary            string `yaml:"summary"`
+			WarnSummary        string `yaml:"warn_summary"`
+			MissingRule        string `yaml:"missing_rule"`
+			MissingReason      string `yaml:"missing_reason"`
+			UnknownRule        string `yaml:"unknown_rule"`
+			InvalidExpires     string `yaml:"invalid_expires"`
+			Expired            string `yaml:"expired"`
+			Permanent          string `yaml:"permanent"`
+			InvalidCreated     string `yaml:"invalid_created"`
+			InvalidCeiling     string `yaml:"invalid_severity_ceiling"`
+			InvalidMaxSeverity string `yaml:"invalid_max_severity"`
+			UnknownPack        string `yaml:"unknown_pack"`
+			PackCoversHigh     string `yaml:"pack_covers_high"`
+			MissingApprover    string `yaml:"missing_approver"`
+			MissingTicket      string `yaml:"missing_ticket"`
+			Lifetime           string `yaml:"lifetime_exceeded"`
 		} `yaml:"lint"`
+		Add struct {
+			Short               string `yaml:"short"`
+			Long                string `yaml:"long"`
+			RuleFlag            string `yaml:"rule_flag"`
+			ResourceFlag        string `yaml:"resource_flag"`
+			FileFlag            string `yaml:"file_flag"`
+			FingerprintFlag     string `yaml:"fingerprint_flag"`
+			ReasonFlag          string `yaml:"reason_flag"`
+			ExpiresFlag         string `yaml:"expires_flag"`
+			OwnerFlag           string `yaml:"owner_flag"`
+			ApprovedByFlag      string `yaml:"approved_by_flag"`
+			TicketFlag          string `yaml:"ticket_flag"`
+			SeverityCeilingFlag string `yaml:"severity_ceiling_flag"`
+			PackFlag            string `yaml:"pack_flag"`
+			MaxSeverityFlag     string `yaml:"max_severity_flag"`
+			ReportFlag          string `yaml:"report_flag"`
+			ReasonRequired      string `yaml:"reason_required"`
+			TargetRequired      string `yaml:"target_required"`
+			UnknownRule         string `yaml:"unknown_rule"`
+			UnknownPack         string `yaml:"unknown_pack"`
+			InvalidExpires      string `yaml:"invalid_expires"`
+			ExpiresPast         string `yaml:"expires_past"`
+			ExpiresRequired     string `yaml:"expires_required"`
+			ExpiresTooLate      string `yaml:"expires_too_late"`
+			NoSelection         string `yaml:"no_selection"`
+			ReportFlagConflict  string `yaml:"report_flag_conflict"`
+			NoMatch             string `yaml:"no_match"`
+			ReadReport          string `yaml:"read_report"`
+			Added               string `yaml:"added"`
+			Skipped             string `yaml:"skipped"`
+			PolicyViolation     string `yaml:"policy_violation"`
+		} `yaml:"add"`
+		Prune struct {
+			Short             string `yaml:"short"`
+			Long              string `yaml:"long"`
+			ReportFlag        string `yaml:"report_flag"`
+			RemoveFlag        string `yaml:"remove_flag"`
+			ReportRequired    string `yaml:"report_required"`
+			FilteredReport    string `yaml:"filtered_report"`
+			AllUsed           string `yaml:"all_used"`
+			UnusedTitle       string `yaml:"unused_title"`
+			UncheckedTitle    string `yaml:"unchecked_title"`
+			UnusedEntry       string `yaml:"unused_entry"`
+			UnusedInlineTitle string `yaml:"unused_inline_title"`
+			RemoveHint        string `yaml:"remove_hint"`
+			Removed           string `yaml:"removed"`
+			InlineHint        string `yaml:"inline_hint"`
+		} `yaml:"prune"`
 	} `yaml:"waiver"`
 
 	// Update command
@@ -300,32 +365,73 @@ type Messages struct {
 
 	// Scan command
 	Scan struct {
-		Short                string `yaml:"short"`
-		Long                 string `yaml:"long"`
-		TemplateFlag         string `yaml:"template_flag"`
-		PolicyFlag           string `yaml:"policy_flag"`
-		InputFlag            string `yaml:"input_flag"`
-		FormatFlag           string `yaml:"format_flag"`
-		OutputFlag           string `yaml:"output_flag"`
-		ModeFlag             string `yaml:"mode_flag"`
-		ReportWritten        string `yaml:"report_written"`
-		NoViolations         string `yaml:"no_violations"`
-		TotalViolations      string `yaml:"total_violations"`
-		FilePrefix           string `yaml:"file_prefix"`
-		NoTemplatesFound     string `yaml:"no_templates_found"`
-		NoTemplatesProcessed string `yaml:"no_templates_processed"`
-		SkippedFile          string `yaml:"skipped_file"`
-		FileError            string `yaml:"file_error"`
-		StatusCode           string `yaml:"status_code"`
-		Code                 string `yaml:"code"`
-		Message              string `yaml:"message"`
-		RequestID            string `yaml:"request_id"`
-		CallingPreviewStack  string `yaml:"calling_preview_stack"`
-		WaiversFlag          string `yaml:"waivers_flag"`
-		NoWaiversFlag        string `yaml:"no_waivers_flag"`
-		ShowWaivedFlag       string `yaml:"show_waived_flag"`
-		FailOnExpiredFlag    string `yaml:"fail_on_expired_flag"`
-		WaiverLoadError      string `yaml:"waiver_load_error"`
+		Short                 string `yaml:"short"`
+		Long                  string `yaml:"long"`
+		TemplateFlag          string `yaml:"template_flag"`
+		PolicyFlag            string `yaml:"policy_flag"`
+		InputFlag             string `yaml:"input_flag"`
+		FormatFlag            string `yaml:"format_flag"`
+		OutputFlag            string `yaml:"output_flag"`
+		ModeFlag              string `yaml:"mode_flag"`
+		ReportWritten         string `yaml:"report_written"`
+		NoViolations          string `yaml:"no_violations"`
+		TotalViolations       string `yaml:"total_violations"`
+		FilePrefix            string `yaml:"file_prefix"`
+		NoTemplatesFound      string `yaml:"no_templates_found"`
+		NoTemplatesProcessed  string `yaml:"no_templates_processed"`
+		SkippedFile           string `yaml:"skipped_file"`
+		FileError             string `yaml:"file_error"`
+		StatusCode            string `yaml:"status_code"`
+		Code                  string `yaml:"code"`
+		Message               string `yaml:"message"`
+		RequestID             string `yaml:"request_id"`
+		CallingPreviewStack   string `yaml:"calling_preview_stack"`
+		WaiversFlag           string `yaml:"waivers_flag"`
+		NoWaiversFlag         string `yaml:"no_waivers_flag"`
+		ShowWaivedFlag        string `yaml:"show_waived_flag"`
+		FailOnExpiredFlag     string `yaml:"fail_on_expired_flag"`
+		JobsFlag              string `yaml:"jobs_flag"`
+		EvalTimeoutFlag       string `yaml:"eval_timeout_flag"`
+		RuleTimedOut          string `yaml:"rule_timed_out"`
+		ExplainFlag           string `yaml:"explain_flag"`
+		ExplainResourceFlag   string `yaml:"explain_resource_flag"`
+		ExplainHeader         string `yaml:"explain_header"`
+		ExplainResource       string `yaml:"explain_resource"`
+		ExplainFired          string `yaml:"explain_fired"`
+		ExplainPassed         string `yaml:"explain_passed"`
+		ExplainBody           string `yaml:"explain_body"`
+		ExplainFailedExpr     string `yaml:"explain_failed_expr"`
+		ExplainNoBody         string `yaml:"explain_no_body"`
+		ExplainLookups        string `yaml:"explain_lookups"`
+		ExplainTrace          string `yaml:"explain_trace"`
+		ProfileFlag           string `yaml:"profile_flag"`
+		ProfileHeader         string `yaml:"profile_header"`
+		ProfileTotalTime      string `yaml:"profile_total_time"`
+		ProfileEvaluations    string `yaml:"profile_evaluations"`
+		ProfileAvgTime        string `yaml:"profile_avg_time"`
+		ProfileSlowestExprs   string `yaml:"profile_slowest_exprs"`
+		ProfileTimedOut       string `yaml:"profile_timed_out"`
+		ParamsFlag            string `yaml:"params_flag"`
+		ParamsLoadError       string `yaml:"params_load_error"`
+		ParamsUnknownRule     string `yaml:"params_unknown_rule"`
+		ParamsUnknownParam    string `yaml:"params_unknown_param"`
+		ConfigFlag            string `yaml:"config_flag"`
+		ProjectLoadError      string `yaml:"project_load_error"`
+		FailOnFlag            string `yaml:"fail_on_flag"`
+		MinSeverityFlag       string `yaml:"min_severity_flag"`
+		BaselineFlag          string `yaml:"baseline_flag"`
+		BaselineWriteFlag     string `yaml:"baseline_write_flag"`
+		BaselineLoadError     string `yaml:"baseline_load_error"`
+		BaselineWriteError    string `yaml:"baseline_write_error"`
+		BaselineWritten       string `yaml:"baseline_written"`
+		BaselineResolved      string `yaml:"baseline_resolved"`
+		ChangedSinceFlag      string `yaml:"changed_since_flag"`
+		ChangedLinesFlag      string `yaml:"changed_lines_flag"`
+		MarkdownMaxSizeFlag   string `yaml:"markdown_max_size_flag"`
+		ChangedSinceError     string `yaml:"changed_since_error"`
+		NoChangedTemplates    string `yaml:"no_changed_templates"`
+		WaiverLoadError       string `yaml:"waiver_load_error"`
+		WaiverPacksUnresolved string `yaml:"waiver_packs_unresolved"`
 	} `yaml:"scan"`
 
 	// Report
@@ -339,6 +445,7 @@ type Messages struct {
 		Resource              string `yaml:"resource"`
 		Location              string `yaml:"location"`
 		Line                  string `yaml:"line"`
+		Fingerprint           string `yaml:"fingerprint"`
 		Reason                string `yaml:"reason"`
 		Recommendation        string `yaml:"recommendation"`
 		Results               string `yaml:"results"`
@@ -362,6 +469,11 @@ type Messages struct {
 		WaiverExpired         string `yaml:"waiver_expired"`
 		WaiverSummaryActive   string `yaml:"waiver_summary_active"`
 		WaiverSummaryExpired  string `yaml:"waiver_summary_expired"`
+		InBaseline            string `yaml:"in_baseline"`
+		BaselineSummary       string `yaml:"baseline_summary"`
+		EvalTimedOut          string `yaml:"eval_timed_out"`
+		MarkdownWaived        string `yaml:"markdown_waived"`
+		MarkdownTruncated     string `yaml:"markdown_truncated"`
 	} `yaml:"report"`
 
 	// Severity levels
@@ -426,9 +538,28 @@ type Messages struct {
 
 	// Error messages
 	Errors struct {
-		InvalidFormat string `yaml:"invalid_format"`
-		InvalidLang   string `yaml:"invalid_lang"`
-		InvalidMode   string `yaml:"invalid_mode"`
+		InvalidFormat      string `yaml:"invalid_format"`
+		InvalidLang        string `yaml:"invalid_lang"`
+		InvalidMode        string `yaml:"invalid_mode"`
+		InvalidJobs        string `yaml:"invalid_jobs"`
+		InvalidEvalTimeout string `yaml:"invalid_eval_timeout"`
+		InvalidFailOn      string `yaml:"invalid_fail_on"`
+		InvalidMinSeverity string `yaml:"invalid_min_severity"`
+		BaselineWithWrite  string `yaml:"baseline_with_write"`
+
+		ChangedLinesRequiresSince string `yaml:"changed_lines_requires_since"`
+
+		// Scan --explain errors
+		ExplainRuleNotFound         string `yaml:"explain_rule_not_found"`
+		ExplainResourceNotFound     string `yaml:"explain_resource_not_found"`
+		ExplainFormat               string `yaml:"explain_format"`
+		ExplainResourceRequiresRule string `yaml:"explain_resource_requires_rule"`
+
+		// Scan --profile errors
+		ProfileFormat      string `yaml:"profile_format"`
+		ProfileWithExplain string `yaml:"profile_with_explain"`
+
+		PolicyRequired string `yaml:"policy_required"`
 
 		// Language tag validation errors
 		InvalidLangTagSeparator string `yaml:"invalid_lang_tag_separator"`
@@ -514,6 +645,22 @@ type Messages struct {
 		PolicyPathDoesNotExist          string `yaml:"policy_path_does_not_exist"`
 		NoRegoFilesInPolicyDirectory    string `yaml:"no_rego_files_in_policy_directory"`
 
+		// Rule parameters
+		ParamUnknown             string `yaml:"param_unknown"`
+		ParamTypeMismatch        string `yaml:"param_type_mismatch"`
+		RuleParamsNotObject      string `yaml:"rule_params_not_object"`
+		RuleParamNotObject       string `yaml:"rule_param_not_object"`
+		RuleParamInvalidType     string `yaml:"rule_param_invalid_type"`
+		RuleParamMissingDefault  string `yaml:"rule_param_missing_default"`
+		RuleParamDefaultMismatch string `yaml:"rule_param_default_mismatch"`
+
+		// Project configuration
+		ProjectInvalidSeverity       string `yaml:"project_invalid_severity"`
+		ProjectInvalidFailOn         string `yaml:"project_invalid_fail_on"`
+		ProjectInvalidMaxExpiry      string `yaml:"project_invalid_max_expiry"`
+		ProjectInvalidWaiverSeverity string `yaml:"project_invalid_waiver_severity"`
+		ProjectInvalidMaxDays        string `yaml:"project_invalid_max_days"`
+
 		// Preview mode errors
 		PreviewOnlyROSSupported string `yaml:"preview_only_ros_supported"`
 		PreviewUnsupportedMode  string `yaml:"preview_unsupported_mode"`
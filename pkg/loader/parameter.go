@@ -11,6 +11,7 @@ import (
 
 	"github.com/aliyun/infraguard/pkg/i18n"
 	"github.com/aliyun/infraguard/pkg/models"
+	"github.com/aliyun/infraguard/pkg/providers/terraform"
 	"gopkg.in/yaml.v3"
 )
 
@@ -85,6 +86,15 @@ func parseFile(path string) (models.TemplateParams, error) {
 
 	ext := strings.ToLower(filepath.Ext(path))
 
+	// Terraform variable files are HCL (or HCL-flavored JSON)
+	if terraform.IsVarsFile(path) {
+		params, err := terraform.ParseVarsFile(path)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.Msg().Errors.ParseInputFile, path, err.Error())
+		}
+		return params, nil
+	}
+
 	// Try JSON
 	if ext == ".json" || (len(content) > 0 && content[0] == '{') {
 		var params models.TemplateParams
@@ -51,7 +51,7 @@ func TestParseInputValues(t *testing.T) {
 		{
 			name:   "tfvars file format",
 			inputs: []string{tfvarsFile},
-			want:   models.TemplateParams{"instance_type": "ecs.g6.large", "bandwidth": "10"},
+			want:   models.TemplateParams{"instance_type": "ecs.g6.large", "bandwidth": float64(10)},
 		},
 		{
 			name:   "Mixed formats and overrides",
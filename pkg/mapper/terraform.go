@@ -2,6 +2,7 @@ package mapper
 
 import (
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/aliyun/infraguard/pkg/i18n"
@@ -35,7 +36,13 @@ func MapTerraformViolations(violations []models.OPAViolation, opaInput map[strin
 			RecommendationRaw: v.Meta.Recommendation,
 		}
 
-		line, filename := findTerraformResourceLocation(v.ResourceID, resources)
+		meta := findTerraformResourceMeta(v.ResourceID, resources)
+		// Resources from child modules are reported by their Terraform address
+		// (e.g., "module.network.alicloud_vpc.main").
+		if addr, ok := meta["address"].(string); ok && addr != "" {
+			rv.ResourceID = addr
+		}
+		line, filename := metaLocation(meta)
 		if line > 0 {
 			rv.Line = line
 			if filename != "" {
@@ -50,42 +57,78 @@ func MapTerraformViolations(violations []models.OPAViolation, opaInput map[strin
 }
 
 // findTerraformResourceLocation looks up a resource's source location from the __meta__ field.
-// The resourceID is expected in "resource_type.resource_name" format (e.g., "alicloud_instance.web").
+// The resourceID is expected in "resource_type.resource_name" format (e.g., "alicloud_instance.web"),
+// optionally with a count or for_each instance key (e.g., "alicloud_instance.web[0]").
 // Returns the line number and filename from the __meta__ map, or (0, "") if not found.
 func findTerraformResourceLocation(resourceID string, resources map[string]interface{}) (int, string) {
+	return metaLocation(findTerraformResourceMeta(resourceID, resources))
+}
+
+// metaLocation extracts the line number and filename from a __meta__ map.
+func metaLocation(meta map[string]interface{}) (int, string) {
+	line := 0
+	filename := ""
+	if l, ok := meta["line"].(float64); ok {
+		line = int(l)
+	} else if l, ok := meta["line"].(int); ok {
+		line = l
+	}
+	if f, ok := meta["filename"].(string); ok {
+		filename = f
+	}
+	return line, filename
+}
+
+// findTerraformResourceMeta returns the __meta__ map of the resource a violation
+// refers to, or nil if it cannot be found.
+func findTerraformResourceMeta(resourceID string, resources map[string]interface{}) map[string]interface{} {
 	if resources == nil {
-		return 0, ""
+		return nil
 	}
 
 	parts := strings.SplitN(resourceID, ".", 2)
 	if len(parts) != 2 {
-		return 0, ""
+		return nil
 	}
 	resType := parts[0]
 	resName := parts[1]
 
 	typeMap, ok := resources[resType].(map[string]interface{})
 	if !ok {
-		return 0, ""
+		return nil
 	}
-	instance, ok := typeMap[resName].(map[string]interface{})
-	if !ok {
-		return 0, ""
-	}
-	meta, ok := instance["__meta__"].(map[string]interface{})
-	if !ok {
-		return 0, ""
+	instance := lookupTerraformInstance(typeMap, resName)
+	if instance == nil {
+		return nil
 	}
+	meta, _ := instance["__meta__"].(map[string]interface{})
+	return meta
+}
 
-	line := 0
-	filename := ""
-	if l, ok := meta["line"].(float64); ok {
-		line = int(l)
-	} else if l, ok := meta["line"].(int); ok {
-		line = l
+// lookupTerraformInstance finds the resource entry for name. Instances expanded
+// from count or for_each share their block's __meta__, so a bare block name
+// resolves to any of its instances and an instance key not present in the input
+// falls back to the block.
+func lookupTerraformInstance(typeMap map[string]interface{}, name string) map[string]interface{} {
+	if instance, ok := typeMap[name].(map[string]interface{}); ok {
+		return instance
 	}
-	if f, ok := meta["filename"].(string); ok {
-		filename = f
+	if i := strings.Index(name, "["); i > 0 {
+		if instance, ok := typeMap[name[:i]].(map[string]interface{}); ok {
+			return instance
+		}
+		name = name[:i]
 	}
-	return line, filename
+	keys := make([]string, 0, len(typeMap))
+	for key := range typeMap {
+		if strings.HasPrefix(key, name+"[") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	instance, _ := typeMap[keys[0]].(map[string]interface{})
+	return instance
 }
@@ -1,6 +1,7 @@
 package mapper
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/aliyun/infraguard/pkg/models"
@@ -67,6 +68,32 @@ func TestMapTerraformViolations(t *testing.T) {
 			So(rich[0].Recommendation, ShouldEqual, "移除公网IP")
 		})
 
+		Convey("It should report child module resources by their Terraform address", func() {
+			moduleInput := map[string]interface{}{
+				"resources": map[string]interface{}{
+					"alicloud_vpc": map[string]interface{}{
+						"module.network.main": map[string]interface{}{
+							"__meta__": map[string]interface{}{
+								"filename": "modules/network/main.tf",
+								"line":     4,
+								"address":  "module.network.alicloud_vpc.main",
+							},
+						},
+					},
+				},
+			}
+			moduleViolations := []models.OPAViolation{{
+				ID:         "vpc-rule",
+				ResourceID: "alicloud_vpc.module.network.main",
+				Meta:       models.ViolationMeta{Severity: "low", Reason: "r"},
+			}}
+
+			rich := MapTerraformViolations(moduleViolations, moduleInput, "/path/to/project", "en")
+			So(rich[0].ResourceID, ShouldEqual, "module.network.alicloud_vpc.main")
+			So(rich[0].Line, ShouldEqual, 4)
+			So(rich[0].File, ShouldEqual, filepath.Join("/path/to/project", "modules", "network", "main.tf"))
+		})
+
 		Convey("It should default to line 1 when resource not found in meta", func() {
 			unknownViolations := []models.OPAViolation{
 				{
@@ -147,6 +174,32 @@ func TestFindTerraformResourceLocation(t *testing.T) {
 			So(filename, ShouldEqual, "")
 		})
 
+		Convey("It should map count and for_each instances back to their block", func() {
+			instanceResources := map[string]interface{}{
+				"alicloud_instance": map[string]interface{}{
+					"web[0]": map[string]interface{}{
+						"__meta__": map[string]interface{}{"filename": "main.tf", "line": 7},
+					},
+					"web[1]": map[string]interface{}{
+						"__meta__": map[string]interface{}{"filename": "main.tf", "line": 7},
+					},
+					`app["a.b"]`: map[string]interface{}{
+						"__meta__": map[string]interface{}{"filename": "app.tf", "line": 3},
+					},
+				},
+			}
+			line, filename := findTerraformResourceLocation("alicloud_instance.web[1]", instanceResources)
+			So(line, ShouldEqual, 7)
+			So(filename, ShouldEqual, "main.tf")
+
+			line, filename = findTerraformResourceLocation(`alicloud_instance.app["a.b"]`, instanceResources)
+			So(line, ShouldEqual, 3)
+			So(filename, ShouldEqual, "app.tf")
+
+			line, _ = findTerraformResourceLocation("alicloud_instance.web", instanceResources)
+			So(line, ShouldEqual, 7)
+		})
+
 		Convey("It should handle nil resources", func() {
 			line, filename := findTerraformResourceLocation("alicloud_instance.web", nil)
 			So(line, ShouldEqual, 0)
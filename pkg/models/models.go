@@ -1,13 +1,23 @@
 // Package models defines core data structures for InfraGuard.
 package models
 
-import "strings"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
 
 // Severity level constants
 const (
 	SeverityHigh   = "high"
 	SeverityMedium = "medium"
 	SeverityLow    = "low"
+
+	// SeverityNone is the fail threshold under which no violation fails a scan.
+	SeverityNone = "none"
 )
 
 // I18nString represents an internationalized string with language-specific values.
@@ -36,6 +46,87 @@ type RuleImpl struct {
 	Content     string `json:"content"`
 }
 
+// Rule parameter types
+const (
+	ParamTypeString  = "string"
+	ParamTypeNumber  = "number"
+	ParamTypeBoolean = "boolean"
+	ParamTypeArray   = "array"
+	ParamTypeObject  = "object"
+)
+
+// RuleParam is a configurable parameter declared in a rule's rule_meta.params.
+// Rules read its value from data.infraguard.params[<rule-id>][<name>].
+type RuleParam struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`    // One of the ParamType* constants
+	Default     interface{} `json:"default"` // Used unless the project overrides it
+	Description I18nString  `json:"description,omitempty"`
+}
+
+// ParamTypes returns the valid rule parameter types.
+func ParamTypes() []string {
+	return []string{ParamTypeString, ParamTypeNumber, ParamTypeBoolean, ParamTypeArray, ParamTypeObject}
+}
+
+// ParamValueHasType reports whether v, as decoded from JSON, YAML or Rego, is a
+// value of the given parameter type.
+func ParamValueHasType(paramType string, v interface{}) bool {
+	switch paramType {
+	case ParamTypeString:
+		_, ok := v.(string)
+		return ok
+	case ParamTypeNumber:
+		switch v.(type) {
+		case int, int64, uint64, float64, json.Number:
+			return true
+		}
+	case ParamTypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	case ParamTypeArray:
+		_, ok := v.([]interface{})
+		return ok
+	case ParamTypeObject:
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// ParseRuleParams converts the rule_meta.params object, which maps parameter
+// names to {"type", "default", "description"} declarations, to parameters sorted
+// by name. Malformed declarations are skipped; policy validation reports them.
+func ParseRuleParams(v interface{}) []RuleParam {
+	decls, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var params []RuleParam
+	for name, d := range decls {
+		decl, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paramType, _ := decl["type"].(string)
+		param := RuleParam{Name: name, Type: paramType, Default: decl["default"]}
+		switch desc := decl["description"].(type) {
+		case string:
+			param.Description = I18nString{"en": desc}
+		case map[string]interface{}:
+			param.Description = make(I18nString, len(desc))
+			for lang, text := range desc {
+				if s, ok := text.(string); ok {
+					param.Description[lang] = s
+				}
+			}
+		}
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
 // Rule represents a compliance rule with its metadata.
 type Rule struct {
 	ID              string               `json:"id"`                        // e.g., "rule:aliyun:ecs-public-ip"
@@ -47,6 +138,7 @@ type Rule struct {
 	ResourceTypes   []string             `json:"resource_types"`            // Resource types this rule applies to
 	IaCTypes        []string             `json:"iac_types"`                 // Supported IaC types (e.g., "ros", "terraform")
 	Implementations map[string]*RuleImpl `json:"implementations,omitempty"` // Per-IaC-type implementations
+	Params          []RuleParam          `json:"params,omitempty"`          // Configurable parameters, sorted by name
 	FilePath        string               `json:"file_path"`                 // Source .rego file path (backward compat)
 	PackageName     string               `json:"package_name"`              // Rego package name
 	Content         string               `json:"content"`                   // Rego content (for embedded)
@@ -106,6 +198,14 @@ func (pi *PolicyIndex) AddRule(rule *Rule) {
 		}
 	}
 
+	// Merge Params; implementations normally declare the same ones
+	for _, param := range rule.Params {
+		if !containsParam(existing.Params, param.Name) {
+			existing.Params = append(existing.Params, param)
+		}
+	}
+	sort.Slice(existing.Params, func(i, j int) bool { return existing.Params[i].Name < existing.Params[j].Name })
+
 	// Merge Implementations
 	if existing.Implementations == nil {
 		existing.Implementations = make(map[string]*RuleImpl)
@@ -119,6 +219,15 @@ func (pi *PolicyIndex) AddRule(rule *Rule) {
 	}
 }
 
+func containsParam(params []RuleParam, name string) bool {
+	for _, p := range params {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func containsString(slice []string, s string) bool {
 	for _, v := range slice {
 		if v == s {
@@ -193,9 +302,42 @@ type RichViolation struct {
 	SnippetLines      []SnippetLine `json:"snippet_lines"` // Multi-line snippet with context
 	Reason            string        `json:"reason"`
 	Recommendation    string        `json:"recommendation"`
-	ReasonRaw         interface{}   `json:"-"`                // Original i18n data (string or map)
-	RecommendationRaw interface{}   `json:"-"`                // Original i18n data (string or map)
-	Waiver            *WaiverInfo   `json:"waiver,omitempty"` // Set when this violation matched a waiver
+	ReasonRaw         interface{}   `json:"-"`                  // Original i18n data (string or map)
+	RecommendationRaw interface{}   `json:"-"`                  // Original i18n data (string or map)
+	Waiver            *WaiverInfo   `json:"waiver,omitempty"`   // Set when this violation matched a waiver
+	Baseline          bool          `json:"baseline,omitempty"` // Set when this violation is in the scan baseline
+	Fingerprint       string        `json:"fingerprint"`        // Stable identifier, see Fingerprint
+}
+
+// Fingerprint returns the stable identifier of a violation. It is computed from
+// the short rule ID in lowercase, the IaC type, the file path relative to the
+// workspace root, the resource ID and the violation path. Line numbers are left
+// out, so edits that move a resource do not change the fingerprint.
+func Fingerprint(ruleID, iacType, relFile, resourceID string, violationPath []string) string {
+	h := sha256.New()
+	for _, part := range []string{
+		strings.ToLower(ShortRuleID(ruleID)),
+		strings.ToLower(iacType),
+		filepath.ToSlash(relFile),
+		resourceID,
+		strings.Join(violationPath, "."),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// ShortRuleID reduces a full rule ID ("rule:aliyun:foo") to its short form ("foo").
+func ShortRuleID(id string) string {
+	if !strings.HasPrefix(id, "rule:") && !strings.HasPrefix(id, "pack:") {
+		return id
+	}
+	parts := strings.Split(id, ":")
+	if len(parts) >= 3 {
+		return parts[len(parts)-1]
+	}
+	return id
 }
 
 // Waiver status constants.
@@ -206,11 +348,15 @@ const (
 
 // WaiverInfo describes the waiver that matched a violation.
 type WaiverInfo struct {
-	Status  string `json:"status"`            // active | expired
-	Source  string `json:"source"`            // inline | file
-	Reason  string `json:"reason"`            // Why the violation is waived
-	Owner   string `json:"owner,omitempty"`   // Responsible person
-	Expires string `json:"expires,omitempty"` // YYYY-MM-DD, empty means permanent
+	Status          string `json:"status"`                     // active | expired
+	Source          string `json:"source"`                     // inline | file
+	Reason          string `json:"reason"`                     // Why the violation is waived
+	Owner           string `json:"owner,omitempty"`            // Responsible person
+	Expires         string `json:"expires,omitempty"`          // YYYY-MM-DD, empty means permanent
+	ApprovedBy      string `json:"approved_by,omitempty"`      // Person who approved the exception
+	Ticket          string `json:"ticket,omitempty"`           // Tracking ticket, ID or URL
+	Created         string `json:"created,omitempty"`          // YYYY-MM-DD the waiver was granted
+	SeverityCeiling string `json:"severity_ceiling,omitempty"` // Highest severity the waiver may cover
 }
 
 // IsActive reports whether the waiver is currently suppressing the violation.
@@ -219,9 +365,13 @@ func (w *WaiverInfo) IsActive() bool {
 }
 
 // IsSuppressed reports whether a violation should be hidden from output and
-// excluded from totals because of its waiver. Active waivers always suppress;
-// expired waivers suppress only when expired waivers are not configured to fail.
+// excluded from totals because of its waiver or the baseline. Active waivers
+// and the baseline always suppress; expired waivers suppress only when expired
+// waivers are not configured to fail.
 func (v RichViolation) IsSuppressed(failOnExpired bool) bool {
+	if v.Baseline {
+		return true
+	}
 	if v.Waiver == nil {
 		return false
 	}
@@ -248,6 +398,11 @@ func SeverityOrder(severity string) int {
 	}
 }
 
+// SeverityAtLeast reports whether severity is at least as severe as threshold.
+func SeverityAtLeast(severity, threshold string) bool {
+	return SeverityOrder(severity) <= SeverityOrder(threshold)
+}
+
 // SeverityLevels returns all supported severity levels in order.
 func SeverityLevels() []string {
 	return []string{SeverityHigh, SeverityMedium, SeverityLow}
@@ -270,14 +425,28 @@ type ReportSummary struct {
 	SeverityCounts      map[string]int `json:"severity_counts"`
 	FilesScanned        int            `json:"files_scanned"`
 	FilesWithViolations int            `json:"files_with_violations"`
-	WaivedCount         int            `json:"waived_count"`         // Violations suppressed by an active waiver
-	ExpiredWaiverCount  int            `json:"expired_waiver_count"` // Violations whose waiver has expired
+	WaivedCount         int            `json:"waived_count"`            // Violations suppressed by an active waiver
+	ExpiredWaiverCount  int            `json:"expired_waiver_count"`    // Violations whose waiver has expired
+	BaselineCount       int            `json:"baseline_count"`          // Violations suppressed by the baseline
+	TimedOutRuleCount   int            `json:"timed_out_rule_count"`    // Rule evaluations that exceeded the rule timeout
+	FailOn              string         `json:"fail_on"`                 // Lowest severity that fails the scan, or "none"
+	MinSeverity         string         `json:"min_severity,omitempty"`  // Lowest severity reported, if filtered
+	ChangedSince        string         `json:"changed_since,omitempty"` // Git ref the scan was limited to changes since, if any
+	ChangedLines        bool           `json:"changed_lines,omitempty"` // Only violations on changed lines were reported
+	Policies            []string       `json:"policies,omitempty"`      // Policy specifications the scan evaluated
+	Paths               []string       `json:"paths,omitempty"`         // Absolute paths of the scanned files and directories
 }
 
 // FileResult holds violations for a specific file.
 type FileResult struct {
 	File       string          `json:"file"`
 	Violations []RichViolation `json:"violations"`
+	// EvaluatedRules holds the IDs of the rules evaluated against this file,
+	// so reporters can list passing rules too. Not part of the JSON report.
+	EvaluatedRules []string `json:"-"`
+	// TimedOutRules holds the IDs of the rules whose evaluation against this file
+	// timed out; they were not checked.
+	TimedOutRules []string `json:"timed_out_rules,omitempty"`
 }
 
 // Report represents the full scan report for JSON output.
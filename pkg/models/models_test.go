@@ -69,3 +69,75 @@ func TestSeverityLevels(t *testing.T) {
 		})
 	})
 }
+
+func TestParseRuleParams(t *testing.T) {
+	Convey("Given a rule_meta.params object", t, func() {
+		params := ParseRuleParams(map[string]interface{}{
+			"threshold": map[string]interface{}{
+				"type":        "number",
+				"default":     28,
+				"description": map[string]interface{}{"en": "Threshold", "zh": "阈值"},
+			},
+			"allowed": map[string]interface{}{"type": "array", "default": []interface{}{"a"}},
+			"broken":  "not an object",
+		})
+
+		Convey("It should return the well-formed parameters sorted by name", func() {
+			So(len(params), ShouldEqual, 2)
+			So(params[0].Name, ShouldEqual, "allowed")
+			So(params[1].Name, ShouldEqual, "threshold")
+			So(params[1].Type, ShouldEqual, ParamTypeNumber)
+			So(params[1].Default, ShouldEqual, 28)
+			So(params[1].Description.Get("zh"), ShouldEqual, "阈值")
+		})
+
+		Convey("It should return nil for a non-object", func() {
+			So(ParseRuleParams([]interface{}{"x"}), ShouldBeNil)
+		})
+	})
+}
+
+func TestParamValueHasType(t *testing.T) {
+	Convey("Given parameter values", t, func() {
+		So(ParamValueHasType(ParamTypeNumber, 1), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeNumber, 1.5), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeNumber, "1"), ShouldBeFalse)
+		So(ParamValueHasType(ParamTypeString, "a"), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeBoolean, true), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeArray, []interface{}{}), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeObject, map[string]interface{}{}), ShouldBeTrue)
+		So(ParamValueHasType(ParamTypeObject, []interface{}{}), ShouldBeFalse)
+		So(ParamValueHasType("integer", 1), ShouldBeFalse)
+	})
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	Convey("Given severities and thresholds", t, func() {
+		So(SeverityAtLeast(SeverityHigh, SeverityMedium), ShouldBeTrue)
+		So(SeverityAtLeast(SeverityMedium, SeverityMedium), ShouldBeTrue)
+		So(SeverityAtLeast(SeverityLow, SeverityMedium), ShouldBeFalse)
+		So(SeverityAtLeast("HIGH", SeverityLow), ShouldBeTrue)
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	Convey("Given a violation fingerprint", t, func() {
+		fp := Fingerprint("rule:aliyun:vswitch-available-ip-count", "ros", "stacks/t.yaml", "VSw", []string{"Properties", "CidrBlock"})
+
+		Convey("It should be 32 hex characters", func() {
+			So(len(fp), ShouldEqual, 32)
+		})
+
+		Convey("It should normalize the rule ID and the IaC type", func() {
+			So(Fingerprint("VSWITCH-available-ip-count", "ROS", "stacks/t.yaml", "VSw", []string{"Properties", "CidrBlock"}), ShouldEqual, fp)
+		})
+
+		Convey("It should depend on rule, IaC type, file, resource and path", func() {
+			So(Fingerprint("rule:aliyun:other", "ros", "stacks/t.yaml", "VSw", []string{"Properties", "CidrBlock"}), ShouldNotEqual, fp)
+			So(Fingerprint("rule:aliyun:vswitch-available-ip-count", "terraform", "stacks/t.yaml", "VSw", []string{"Properties", "CidrBlock"}), ShouldNotEqual, fp)
+			So(Fingerprint("rule:aliyun:vswitch-available-ip-count", "ros", "stacks/u.yaml", "VSw", []string{"Properties", "CidrBlock"}), ShouldNotEqual, fp)
+			So(Fingerprint("rule:aliyun:vswitch-available-ip-count", "ros", "stacks/t.yaml", "Other", []string{"Properties", "CidrBlock"}), ShouldNotEqual, fp)
+			So(Fingerprint("rule:aliyun:vswitch-available-ip-count", "ros", "stacks/t.yaml", "VSw", []string{"Properties", "VpcId"}), ShouldNotEqual, fp)
+		})
+	})
+}
@@ -90,6 +90,7 @@ func parseRuleMeta(value interface{}, filePath, packageName, baseDir string) (*m
 		Recommendation interface{} `json:"recommendation"`
 		ResourceTypes  []string    `json:"resource_types"`
 		IaCType        string      `json:"iac_type"`
+		Params         interface{} `json:"params"`
 	}
 
 	if err := json.Unmarshal(data, &rawMeta); err != nil {
@@ -129,6 +130,7 @@ func parseRuleMeta(value interface{}, filePath, packageName, baseDir string) (*m
 		Recommendation: parseI18nString(rawMeta.Recommendation),
 		ResourceTypes:  rawMeta.ResourceTypes,
 		IaCTypes:       iacTypes,
+		Params:         models.ParseRuleParams(rawMeta.Params),
 		FilePath:       filePath,
 		PackageName:    packageName,
 	}
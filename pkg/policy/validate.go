@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/aliyun/infraguard/pkg/i18n"
@@ -26,6 +27,7 @@ const (
 	ErrCodeRuleInvalidFieldType  = "RULE_INVALID_FIELD_TYPE"
 	ErrCodeRuleMissingDeny       = "RULE_MISSING_DENY"
 	ErrCodeRuleInvalidDenyFormat = "RULE_INVALID_DENY_FORMAT"
+	ErrCodeRuleInvalidParam      = "RULE_INVALID_PARAM"
 
 	// Pack validation error codes
 	ErrCodePackMissingMeta      = "PACK_MISSING_META"
@@ -390,6 +392,67 @@ func validateRuleMeta(content, filePath, packageName string, extraModules []Rego
 		}
 	}
 
+	if params, exists := meta["params"]; exists {
+		errors = append(errors, validateRuleParams(params, filePath)...)
+	}
+
+	return errors
+}
+
+// validateRuleParams validates the parameter declarations in rule_meta.params.
+func validateRuleParams(params interface{}, filePath string) []*ValidationError {
+	msg := i18n.Msg()
+	newError := func(message string) *ValidationError {
+		return &ValidationError{
+			FilePath:   filePath,
+			ErrorCode:  ErrCodeRuleInvalidParam,
+			Message:    message,
+			Suggestion: msg.PolicyValidate.Errors["RULE_INVALID_PARAM_suggestion"],
+		}
+	}
+
+	decls, ok := params.(map[string]interface{})
+	if !ok {
+		return []*ValidationError{newError(msg.Errors.RuleParamsNotObject)}
+	}
+
+	names := make([]string, 0, len(decls))
+	for name := range decls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errors []*ValidationError
+	for _, name := range names {
+		decl, ok := decls[name].(map[string]interface{})
+		if !ok {
+			errors = append(errors, newError(fmt.Sprintf(msg.Errors.RuleParamNotObject, name)))
+			continue
+		}
+
+		paramType, _ := decl["type"].(string)
+		validType := false
+		for _, t := range models.ParamTypes() {
+			if paramType == t {
+				validType = true
+			}
+		}
+		if !validType {
+			errors = append(errors, newError(fmt.Sprintf(msg.Errors.RuleParamInvalidType, name, strings.Join(models.ParamTypes(), ", "))))
+		}
+
+		if def, exists := decl["default"]; !exists || def == nil {
+			errors = append(errors, newError(fmt.Sprintf(msg.Errors.RuleParamMissingDefault, name)))
+		} else if validType && !models.ParamValueHasType(paramType, def) {
+			errors = append(errors, newError(fmt.Sprintf(msg.Errors.RuleParamDefaultMismatch, name, paramType)))
+		}
+
+		if desc, exists := decl["description"]; exists && desc != nil {
+			if err := validateI18nFieldType(desc, "params."+name+".description", filePath); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
 	return errors
 }
 
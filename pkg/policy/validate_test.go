@@ -237,6 +237,69 @@ deny contains result if {
 			})
 		})
 
+		Convey("When validating rule parameter declarations", func() {
+			ruleWithParams := func(params string) string {
+				return `package infraguard.rules.aliyun.params_rule
+
+import rego.v1
+
+rule_meta := {
+	"id": "params-rule",
+	"name": "Params Rule",
+	"severity": "medium",
+	"reason": "Some reason",
+	"resource_types": ["ALIYUN::VPC::VSwitch"],
+	"params": ` + params + `,
+}
+
+deny contains result if {
+	false
+	result := {
+		"id": rule_meta.id,
+		"resource_id": "test",
+		"violation_path": ["test"],
+		"meta": {
+			"severity": rule_meta.severity,
+			"reason": rule_meta.reason,
+		},
+	}
+}`
+			}
+			paramErrors := func(params string) []*ValidationError {
+				result, err := ValidateContent(ruleWithParams(params), "test.rego")
+				So(err, ShouldBeNil)
+				var errs []*ValidationError
+				for _, e := range result.Errors {
+					if e.ErrorCode == ErrCodeRuleInvalidParam {
+						errs = append(errs, e)
+					}
+				}
+				return errs
+			}
+
+			Convey("It should accept well-formed declarations", func() {
+				So(paramErrors(`{"max_prefix_length": {"type": "number", "default": 28, "description": {"en": "Max", "zh": "最大"}}}`), ShouldBeEmpty)
+			})
+
+			Convey("It should reject params that are not an object", func() {
+				So(len(paramErrors(`["max_prefix_length"]`)), ShouldEqual, 1)
+			})
+
+			Convey("It should reject an unknown type", func() {
+				errs := paramErrors(`{"n": {"type": "integer", "default": 1}}`)
+				So(len(errs), ShouldEqual, 1)
+				So(errs[0].Message, ShouldContainSubstring, "n")
+			})
+
+			Convey("It should reject a missing default", func() {
+				So(len(paramErrors(`{"n": {"type": "number"}}`)), ShouldEqual, 1)
+			})
+
+			Convey("It should reject a default of the wrong type", func() {
+				So(len(paramErrors(`{"n": {"type": "boolean", "default": "yes"}}`)), ShouldEqual, 1)
+			})
+		})
+
 		Convey("When validating content without rule_meta or pack_meta", func() {
 			content := `package test.helper
 
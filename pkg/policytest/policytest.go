@@ -7,6 +7,7 @@
 package policytest
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -42,6 +43,7 @@ const (
 	CodeLoad       = "load"        // failed to load template
 	CodeLoadTF     = "load_tf"     // failed to load terraform
 	CodeEval       = "eval"        // evaluation error
+	CodeTimeout    = "timeout"     // evaluation exceeded the rule timeout (Detail = timeout)
 )
 
 // CaseResult is the outcome of a single fixture case.
@@ -176,55 +178,81 @@ func runROS(testDir, ruleName string, rule *models.Rule, libModules map[string]s
 	if !fileExists(compliant) && !fileExists(violation) {
 		return nil
 	}
+	ev := compileRule(rule, "ros", libModules)
 	var out []CaseResult
 	if fileExists(compliant) {
-		out = append(out, evalROSCase(ruleName, rule, "ros/compliant", compliant, libModules, false))
+		out = append(out, evalROSCase(ruleName, "ros/compliant", compliant, ev, false))
 	}
 	if fileExists(violation) {
-		out = append(out, evalROSCase(ruleName, rule, "ros/violation", violation, libModules, true))
+		out = append(out, evalROSCase(ruleName, "ros/violation", violation, ev, true))
 	}
 	return out
 }
 
 func runTF(testDir, ruleName string, rule *models.Rule, libModules map[string]string) []CaseResult {
 	tfDir := filepath.Join(testDir, "terraform")
-	compliant := filepath.Join(tfDir, "compliant")
+	compliant := TerraformCompliantDirs(tfDir)
 	violation := filepath.Join(tfDir, "violation")
-	cMain := filepath.Join(compliant, "main.tf")
 	vMain := filepath.Join(violation, "main.tf")
-	if !fileExists(cMain) && !fileExists(vMain) {
+	if len(compliant) == 0 && !fileExists(vMain) {
 		return nil
 	}
+	ev := compileRule(rule, "terraform", libModules)
 	var out []CaseResult
-	if fileExists(cMain) {
-		out = append(out, evalTFCase(ruleName, rule, "terraform/compliant", compliant, libModules, false))
+	for _, dir := range compliant {
+		out = append(out, evalTFCase(ruleName, "terraform/"+filepath.Base(dir), dir, ev, false))
 	}
 	if fileExists(vMain) {
-		out = append(out, evalTFCase(ruleName, rule, "terraform/violation", violation, libModules, true))
+		out = append(out, evalTFCase(ruleName, "terraform/violation", violation, ev, true))
 	}
 	return out
 }
 
-func evalROSCase(ruleName string, rule *models.Rule, caseName, path string, libModules map[string]string, expectViolation bool) CaseResult {
+// TerraformCompliantDirs returns the compliant Terraform fixture directories
+// under tfDir that hold a main.tf: "compliant", then any "compliant-<case>"
+// directories for additional compliant cases, in name order.
+func TerraformCompliantDirs(tfDir string) []string {
+	var dirs []string
+	if fileExists(filepath.Join(tfDir, "compliant", "main.tf")) {
+		dirs = append(dirs, filepath.Join(tfDir, "compliant"))
+	}
+	extra, _ := filepath.Glob(filepath.Join(tfDir, "compliant-*", "main.tf"))
+	for _, main := range extra {
+		dirs = append(dirs, filepath.Dir(main))
+	}
+	return dirs
+}
+
+func evalROSCase(ruleName, caseName, path string, ev ruleEvaluator, expectViolation bool) CaseResult {
 	data, err := loadYAML(path)
 	if err != nil {
 		return errorCase(ruleName, "ros", caseName, CodeLoad, err.Error())
 	}
-	opts := buildOpts(rule, "ros", libModules)
-	return evalAndCheck(ruleName, "ros", caseName, opts, data, expectViolation)
+	return evalAndCheck(ruleName, "ros", caseName, ev, data, expectViolation)
 }
 
-func evalTFCase(ruleName string, rule *models.Rule, caseName, dir string, libModules map[string]string, expectViolation bool) CaseResult {
+func evalTFCase(ruleName, caseName, dir string, ev ruleEvaluator, expectViolation bool) CaseResult {
 	data, err := terraform.Load(dir, nil)
 	if err != nil {
 		return errorCase(ruleName, "terraform", caseName, CodeLoadTF, err.Error())
 	}
-	opts := buildOpts(rule, "terraform", libModules)
-	return evalAndCheck(ruleName, "terraform", caseName, opts, data, expectViolation)
+	return evalAndCheck(ruleName, "terraform", caseName, ev, data, expectViolation)
+}
+
+// ruleEvaluator is a rule compiled once for all of its cases of one IaC type.
+// A compilation error is reported by every case that evaluates it.
+type ruleEvaluator struct {
+	evaluator *engine.Evaluator
+	err       error
+}
+
+func compileRule(rule *models.Rule, iac string, libModules map[string]string) ruleEvaluator {
+	evaluator, err := engine.NewEvaluator(buildOpts(rule, iac, libModules))
+	return ruleEvaluator{evaluator: evaluator, err: err}
 }
 
 func buildOpts(rule *models.Rule, iac string, libModules map[string]string) *engine.EvalOptions {
-	opts := &engine.EvalOptions{LibModules: libModules}
+	opts := &engine.EvalOptions{LibModules: libModules, RuleTimeout: engine.DefaultRuleTimeout}
 	if rule.Implementations != nil {
 		if impl, ok := rule.Implementations[iac]; ok && impl.Content != "" {
 			opts.Modules = map[string]string{impl.FilePath: impl.Content}
@@ -237,11 +265,17 @@ func buildOpts(rule *models.Rule, iac string, libModules map[string]string) *eng
 	return opts
 }
 
-func evalAndCheck(ruleName, iac, caseName string, opts *engine.EvalOptions, input map[string]interface{}, expectViolation bool) CaseResult {
-	res, err := engine.EvaluateWithOpts(opts, input)
+func evalAndCheck(ruleName, iac, caseName string, ev ruleEvaluator, input map[string]interface{}, expectViolation bool) CaseResult {
+	if ev.err != nil {
+		return errorCase(ruleName, iac, caseName, CodeEval, ev.err.Error())
+	}
+	res, err := ev.evaluator.Evaluate(context.Background(), input)
 	if err != nil {
 		return errorCase(ruleName, iac, caseName, CodeEval, err.Error())
 	}
+	if len(res.TimedOutRules) > 0 {
+		return errorCase(ruleName, iac, caseName, CodeTimeout, engine.DefaultRuleTimeout.String())
+	}
 	count := 0
 	for _, v := range res.Violations {
 		if shortID(v.ID) == ruleName {
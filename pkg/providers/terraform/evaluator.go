@@ -2,11 +2,16 @@ package terraform
 
 import (
 	"fmt"
+	"math/big"
+	"os"
 	"path/filepath"
+	"strings"
+	"unicode"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
 // VariableDef represents a Terraform variable definition.
@@ -102,6 +107,14 @@ func buildEvalContext(vars map[string]*VariableDef, locals map[string]cty.Value,
 			"var":   varObj,
 			"local": localObj,
 		},
+		Functions: terraformFunctions,
+	}
+}
+
+// addVariables binds additional top-level names in ctx.
+func addVariables(ctx *hcl.EvalContext, vars map[string]cty.Value) {
+	for name, val := range vars {
+		ctx.Variables[name] = val
 	}
 }
 
@@ -134,19 +147,47 @@ func extractLocals(parsed *ParsedConfig, ctx *hcl.EvalContext) (map[string]cty.V
 	return locals, allDiags
 }
 
+// maxModuleDepth bounds how deeply nested local module calls are followed.
+const maxModuleDepth = 16
+
+// moduleScope describes where a module sits in the configuration being evaluated.
+type moduleScope struct {
+	rootDir string   // Root module directory; __meta__ filenames are relative to it
+	prefix  string   // Address prefix of the module, e.g. "module.network."; empty for the root
+	stack   []string // Absolute directories of the modules being evaluated, to stop cycles
+}
+
 // evaluate resolves the full Terraform configuration into concrete Go values.
 func evaluate(parsed *ParsedConfig, inputVars map[string]interface{}) (*EvalResult, error) {
+	rootDir, err := filepath.Abs(parsed.Dir)
+	if err != nil {
+		rootDir = parsed.Dir
+	}
+	return evaluateModule(parsed, inputVars, moduleScope{rootDir: rootDir})
+}
+
+// evaluateModule resolves one module, following calls to local child modules.
+// Resources and data sources of child modules are merged into the result under
+// names carrying the module address (e.g. "module.network.main"), and their
+// __meta__ records the full Terraform address.
+func evaluateModule(parsed *ParsedConfig, inputVars map[string]interface{}, scope moduleScope) (*EvalResult, error) {
 	vars, diags := extractVariables(parsed)
 	if diags.HasErrors() {
 		return nil, fmt.Errorf("variable extraction: %s", diags.Error())
 	}
 
+	// References to resources and data sources resolve to placeholders
+	targets := refTargets(parsed, scope.prefix)
+
 	// First pass: build context with variables only to resolve locals
 	evalCtx := buildEvalContext(vars, nil, inputVars)
+	addVariables(evalCtx, resourceRefs(targets, evalCtx))
 	locals, _ := extractLocals(parsed, evalCtx)
 
-	// Second pass: rebuild context with both variables and locals
+	// Second pass: rebuild context with both variables and locals, which
+	// count and for_each of referenced blocks may use
 	evalCtx = buildEvalContext(vars, locals, inputVars)
+	addVariables(evalCtx, resourceRefs(targets, evalCtx))
 
 	result := &EvalResult{
 		Resources:   make(map[string]map[string]map[string]interface{}),
@@ -172,6 +213,15 @@ func evaluate(parsed *ParsedConfig, inputVars map[string]interface{}) (*EvalResu
 		result.Locals[name] = ctyToGo(val)
 	}
 
+	// Evaluate local child modules first so their outputs can be referenced
+	moduleOutputs, err := evaluateModuleCalls(parsed, evalCtx, scope, result)
+	if err != nil {
+		return nil, err
+	}
+	if len(moduleOutputs) > 0 {
+		evalCtx.Variables["module"] = cty.ObjectVal(moduleOutputs)
+	}
+
 	// Evaluate resource, data, and output blocks
 	for filePath, file := range parsed.Files {
 		body, ok := file.Body.(*hclsyntax.Body)
@@ -186,15 +236,14 @@ func evaluate(parsed *ParsedConfig, inputVars map[string]interface{}) (*EvalResu
 				}
 				resType := block.Labels[0]
 				resName := block.Labels[1]
-				attrs := evaluateBlockAttrs(block.Body, evalCtx)
-				attrs["__meta__"] = map[string]interface{}{
-					"filename": filepath.Base(filePath),
-					"line":     block.DefRange().Start.Line,
-				}
-				if result.Resources[resType] == nil {
-					result.Resources[resType] = make(map[string]map[string]interface{})
+				for _, inst := range expandInstances(block.Body, evalCtx) {
+					attrs := evaluateBlockAttrs(block.Body, inst.ctx)
+					attrs["__meta__"] = blockMeta(filePath, block, scope, resType+"."+resName+inst.key)
+					if result.Resources[resType] == nil {
+						result.Resources[resType] = make(map[string]map[string]interface{})
+					}
+					result.Resources[resType][scope.prefix+resName+inst.key] = attrs
 				}
-				result.Resources[resType][resName] = attrs
 
 			case "data":
 				if len(block.Labels) < 2 {
@@ -202,15 +251,14 @@ func evaluate(parsed *ParsedConfig, inputVars map[string]interface{}) (*EvalResu
 				}
 				dsType := block.Labels[0]
 				dsName := block.Labels[1]
-				attrs := evaluateBlockAttrs(block.Body, evalCtx)
-				attrs["__meta__"] = map[string]interface{}{
-					"filename": filepath.Base(filePath),
-					"line":     block.DefRange().Start.Line,
-				}
-				if result.DataSources[dsType] == nil {
-					result.DataSources[dsType] = make(map[string]map[string]interface{})
+				for _, inst := range expandInstances(block.Body, evalCtx) {
+					attrs := evaluateBlockAttrs(block.Body, inst.ctx)
+					attrs["__meta__"] = blockMeta(filePath, block, scope, "data."+dsType+"."+dsName+inst.key)
+					if result.DataSources[dsType] == nil {
+						result.DataSources[dsType] = make(map[string]map[string]interface{})
+					}
+					result.DataSources[dsType][scope.prefix+dsName+inst.key] = attrs
 				}
-				result.DataSources[dsType][dsName] = attrs
 
 			case "output":
 				if len(block.Labels) < 1 {
@@ -226,6 +274,262 @@ func evaluate(parsed *ParsedConfig, inputVars map[string]interface{}) (*EvalResu
 	return result, nil
 }
 
+// blockMeta builds the __meta__ entry locating a resource or data block. The
+// filename is relative to the root module so blocks in child modules point to
+// their own file; child module blocks also record their full address.
+func blockMeta(filePath string, block *hclsyntax.Block, scope moduleScope, localAddr string) map[string]interface{} {
+	filename := filepath.Base(filePath)
+	if abs, err := filepath.Abs(filePath); err == nil {
+		if rel, err := filepath.Rel(scope.rootDir, abs); err == nil {
+			filename = rel
+		}
+	}
+	meta := map[string]interface{}{
+		"filename": filename,
+		"line":     block.DefRange().Start.Line,
+	}
+	if scope.prefix != "" {
+		meta["address"] = scope.prefix + localAddr
+	}
+	return meta
+}
+
+// moduleMetaArgs are module block arguments that are not input variables.
+var moduleMetaArgs = []string{"source", "version", "count", "for_each", "providers", "depends_on"}
+
+// evaluateModuleCalls evaluates the module blocks of a module whose source is a
+// local path, merging their resources and data sources into result. It returns
+// the outputs of each single-instance module call for module.<name> references.
+// Remote sources, missing directories, and module cycles are skipped.
+func evaluateModuleCalls(parsed *ParsedConfig, ctx *hcl.EvalContext, scope moduleScope, result *EvalResult) (map[string]cty.Value, error) {
+	outputs := make(map[string]cty.Value)
+	if len(scope.stack) >= maxModuleDepth {
+		return outputs, nil
+	}
+
+	for _, file := range parsed.Files {
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if block.Type != "module" || len(block.Labels) == 0 {
+				continue
+			}
+			name := block.Labels[0]
+			childDir, ok := localModuleDir(parsed.Dir, block)
+			if !ok || containsString(scope.stack, childDir) {
+				continue
+			}
+			childParsed, diags := parseTFDir(childDir)
+			if diags.HasErrors() {
+				continue
+			}
+
+			for _, inst := range expandInstances(block.Body, ctx) {
+				args := evaluateBlockAttrs(block.Body, inst.ctx)
+				for _, meta := range moduleMetaArgs {
+					delete(args, meta)
+				}
+				childScope := moduleScope{
+					rootDir: scope.rootDir,
+					prefix:  scope.prefix + "module." + name + inst.key + ".",
+					stack:   append(append([]string{}, scope.stack...), childDir),
+				}
+				child, err := evaluateModule(childParsed, args, childScope)
+				if err != nil {
+					return nil, fmt.Errorf("module %s: %w", name, err)
+				}
+				mergeInstances(result.Resources, child.Resources)
+				mergeInstances(result.DataSources, child.DataSources)
+
+				// Module outputs are only bound for calls without count or for_each.
+				if inst.key == "" {
+					values := make(map[string]cty.Value)
+					for outName, out := range child.Outputs {
+						if attrs, ok := out.(map[string]interface{}); ok {
+							values[outName] = goToCty(attrs["value"])
+						}
+					}
+					if len(values) == 0 {
+						outputs[name] = cty.EmptyObjectVal
+					} else {
+						outputs[name] = cty.ObjectVal(values)
+					}
+				}
+			}
+		}
+	}
+	return outputs, nil
+}
+
+// localModuleDir returns the absolute directory of a module call whose source is
+// a local path ("./" or "../"), relative to the calling module's directory.
+func localModuleDir(callerDir string, block *hclsyntax.Block) (string, bool) {
+	attr, ok := block.Body.Attributes["source"]
+	if !ok {
+		return "", false
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.String {
+		return "", false
+	}
+	source := val.AsString()
+	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+		return "", false
+	}
+	dir, err := filepath.Abs(filepath.Join(callerDir, source))
+	if err != nil {
+		return "", false
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return dir, true
+}
+
+// mergeInstances copies the type -> name -> attributes entries of src into dst.
+func mergeInstances(dst, src map[string]map[string]map[string]interface{}) {
+	for typ, instances := range src {
+		if dst[typ] == nil {
+			dst[typ] = make(map[string]map[string]interface{})
+		}
+		for name, attrs := range instances {
+			dst[typ][name] = attrs
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// blockInstance is one instance of a resource or data block after expanding
+// its count or for_each meta-argument.
+type blockInstance struct {
+	key string           // Instance key suffix, e.g. [0] or ["a"]; empty for a single instance
+	ctx *hcl.EvalContext // Context with count.index or each.key/each.value bound
+}
+
+// maxCountInstances bounds the number of instances a count meta-argument is
+// expanded into.
+const maxCountInstances = 1000
+
+// expandInstances expands the count or for_each meta-argument of a block into one
+// instance per index or key, using Terraform's instance keys. A block without
+// either, or whose meta-argument cannot be resolved, yields a single instance
+// under the block's own name, as does a count above maxCountInstances. count = 0
+// or an empty for_each yields none.
+func expandInstances(body *hclsyntax.Body, ctx *hcl.EvalContext) []blockInstance {
+	single := []blockInstance{{ctx: ctx}}
+
+	if attr, ok := body.Attributes["count"]; ok {
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || val.IsNull() || !val.IsWhollyKnown() {
+			return single
+		}
+		num, err := convert.Convert(val, cty.Number)
+		if err != nil {
+			return single
+		}
+		n, acc := num.AsBigFloat().Int64()
+		if acc != big.Exact || n < 0 {
+			return single
+		}
+		if n > maxCountInstances {
+			// Too many to expand: keep one instance whose count.index is unknown.
+			child := ctx.NewChild()
+			child.Variables = map[string]cty.Value{
+				"count": cty.ObjectVal(map[string]cty.Value{"index": cty.UnknownVal(cty.Number)}),
+			}
+			return []blockInstance{{ctx: child}}
+		}
+		instances := make([]blockInstance, 0, n)
+		for i := int64(0); i < n; i++ {
+			child := ctx.NewChild()
+			child.Variables = map[string]cty.Value{
+				"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(i)}),
+			}
+			instances = append(instances, blockInstance{key: fmt.Sprintf("[%d]", i), ctx: child})
+		}
+		return instances
+	}
+
+	if attr, ok := body.Attributes["for_each"]; ok {
+		val, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || val.IsNull() || !val.IsWhollyKnown() {
+			return single
+		}
+		ty := val.Type()
+		if !ty.IsMapType() && !ty.IsObjectType() && !ty.IsSetType() {
+			return single
+		}
+		var instances []blockInstance
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			if ty.IsSetType() {
+				// A set must contain strings; each.key and each.value are the element.
+				if v.Type() != cty.String || v.IsNull() {
+					return single
+				}
+				k = v
+			}
+			child := ctx.NewChild()
+			child.Variables = map[string]cty.Value{
+				"each": cty.ObjectVal(map[string]cty.Value{"key": k, "value": v}),
+			}
+			instances = append(instances, blockInstance{key: "[" + quoteInstanceKey(k.AsString()) + "]", ctx: child})
+		}
+		return instances
+	}
+
+	return single
+}
+
+// quoteInstanceKey quotes a for_each key the way Terraform writes it in
+// instance addresses: HCL string syntax, with template introducers doubled and
+// non-printable characters as \u escapes. Unlike Go quoting, printable
+// non-ASCII characters are kept as they are.
+func quoteInstanceKey(key string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, r := range key {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '$', '%':
+			b.WriteRune(r)
+			if strings.HasPrefix(key[i+1:], "{") {
+				b.WriteRune(r)
+			}
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				b.WriteRune(r)
+			case r < 0x10000:
+				fmt.Fprintf(&b, "\\u%04x", r)
+			default:
+				fmt.Fprintf(&b, "\\U%08x", r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
 // evaluateBlockAttrs evaluates all attributes in a block body.
 func evaluateBlockAttrs(body *hclsyntax.Body, ctx *hcl.EvalContext) map[string]interface{} {
 	result := make(map[string]interface{})
@@ -240,20 +544,85 @@ func evaluateBlockAttrs(body *hclsyntax.Body, ctx *hcl.EvalContext) map[string]i
 	}
 	for _, block := range body.Blocks {
 		if block.Type == "dynamic" {
+			expandDynamicBlock(result, block, ctx)
 			continue
 		}
-		nested := evaluateBlockAttrs(block.Body, ctx)
-		if existing, ok := result[block.Type]; ok {
-			if existingList, ok := existing.([]interface{}); ok {
-				result[block.Type] = append(existingList, nested)
-			} else {
-				result[block.Type] = []interface{}{existing, nested}
-			}
+		appendNestedBlock(result, block.Type, evaluateBlockAttrs(block.Body, ctx))
+	}
+	return result
+}
+
+// appendNestedBlock stores a nested block under its type. A single block is kept as
+// an object; repeated blocks of the same type are merged into a list.
+func appendNestedBlock(result map[string]interface{}, blockType string, nested map[string]interface{}) {
+	if existing, ok := result[blockType]; ok {
+		if existingList, ok := existing.([]interface{}); ok {
+			result[blockType] = append(existingList, nested)
 		} else {
-			result[block.Type] = nested
+			result[blockType] = []interface{}{existing, nested}
 		}
+	} else {
+		result[blockType] = nested
+	}
+}
+
+// expandDynamicBlock expands a dynamic "x" block into one nested x block per element
+// of its for_each, evaluating the content body with the iterator bound. If for_each
+// cannot be resolved, the content is evaluated once with an unknown iterator so the
+// block's shape is still visible to rules.
+func expandDynamicBlock(result map[string]interface{}, block *hclsyntax.Block, ctx *hcl.EvalContext) {
+	if len(block.Labels) == 0 {
+		return
+	}
+	blockType := block.Labels[0]
+
+	iterator := blockType
+	if attr, ok := block.Body.Attributes["iterator"]; ok {
+		if name := hcl.ExprAsKeyword(attr.Expr); name != "" {
+			iterator = name
+		}
+	}
+
+	var content *hclsyntax.Block
+	for _, b := range block.Body.Blocks {
+		if b.Type == "content" {
+			content = b
+			break
+		}
+	}
+	if content == nil {
+		return
+	}
+
+	withIterator := func(key, value cty.Value) *hcl.EvalContext {
+		child := ctx.NewChild()
+		child.Variables = map[string]cty.Value{
+			iterator: cty.ObjectVal(map[string]cty.Value{"key": key, "value": value}),
+		}
+		return child
+	}
+
+	var forEach cty.Value
+	if attr, ok := block.Body.Attributes["for_each"]; ok {
+		val, diags := attr.Expr.Value(ctx)
+		if !diags.HasErrors() {
+			forEach = val
+		}
+	}
+	if forEach == cty.NilVal || forEach.IsNull() || !forEach.IsWhollyKnown() || !forEach.CanIterateElements() {
+		unknown := cty.StringVal(UnknownValue)
+		appendNestedBlock(result, blockType, evaluateBlockAttrs(content.Body, withIterator(unknown, unknown)))
+		return
+	}
+
+	isSet := forEach.Type().IsSetType()
+	for it := forEach.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if isSet {
+			k = v
+		}
+		appendNestedBlock(result, blockType, evaluateBlockAttrs(content.Body, withIterator(k, v)))
 	}
-	return result
 }
 
 // goToCty converts a Go value to a cty.Value.
@@ -269,6 +638,24 @@ func goToCty(v interface{}) cty.Value {
 		return cty.BoolVal(val)
 	case nil:
 		return cty.NullVal(cty.DynamicPseudoType)
+	case []interface{}:
+		if len(val) == 0 {
+			return cty.EmptyTupleVal
+		}
+		elems := make([]cty.Value, len(val))
+		for i, e := range val {
+			elems[i] = goToCty(e)
+		}
+		return cty.TupleVal(elems)
+	case map[string]interface{}:
+		if len(val) == 0 {
+			return cty.EmptyObjectVal
+		}
+		attrs := make(map[string]cty.Value, len(val))
+		for k, e := range val {
+			attrs[k] = goToCty(e)
+		}
+		return cty.ObjectVal(attrs)
 	default:
 		return cty.StringVal(fmt.Sprintf("%v", val))
 	}
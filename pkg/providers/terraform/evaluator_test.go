@@ -103,3 +103,241 @@ func TestEvaluateConfig(t *testing.T) {
 		})
 	})
 }
+
+func TestEvaluateCountAndForEach(t *testing.T) {
+	Convey("Given resources using count and for_each", t, func() {
+		dir := t.TempDir()
+		content := []byte(`variable "create" {
+  default = false
+}
+
+resource "alicloud_instance" "web" {
+  count         = 2
+  instance_name = "web-${count.index}"
+}
+
+resource "alicloud_oss_bucket" "buckets" {
+  for_each = {
+    logs   = "private"
+    public = "public-read"
+  }
+  bucket = "bucket-${each.key}"
+  acl    = each.value
+}
+
+resource "alicloud_security_group" "named" {
+  for_each = toset(["a", "b"])
+  name     = "sg-${each.value}"
+}
+
+resource "alicloud_eip" "optional" {
+  count = var.create ? 1 : 0
+}
+
+resource "alicloud_vswitch" "unresolved" {
+  count = var.missing
+}
+
+resource "alicloud_vpc" "huge" {
+  count    = 1e9
+  vpc_name = "vpc-${count.index}"
+}
+
+resource "alicloud_log_project" "projects" {
+  for_each = toset(["日志", "a\"b", "$${x}"])
+  name     = each.key
+}
+`)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), content, 0644), ShouldBeNil)
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+
+		Convey("count should expand into indexed instances", func() {
+			web := result.Resources["alicloud_instance"]
+			So(web, ShouldHaveLength, 2)
+			So(web["web[0]"]["instance_name"], ShouldEqual, "web-0")
+			So(web["web[1]"]["instance_name"], ShouldEqual, "web-1")
+			meta := web["web[1]"]["__meta__"].(map[string]interface{})
+			So(meta["line"], ShouldEqual, 5)
+		})
+
+		Convey("for_each over a map should expand into keyed instances", func() {
+			buckets := result.Resources["alicloud_oss_bucket"]
+			So(buckets, ShouldHaveLength, 2)
+			So(buckets[`buckets["logs"]`]["bucket"], ShouldEqual, "bucket-logs")
+			So(buckets[`buckets["public"]`]["acl"], ShouldEqual, "public-read")
+		})
+
+		Convey("for_each over a set should key instances by value", func() {
+			groups := result.Resources["alicloud_security_group"]
+			So(groups, ShouldHaveLength, 2)
+			So(groups[`named["a"]`]["name"], ShouldEqual, "sg-a")
+		})
+
+		Convey("count = 0 should produce no instances", func() {
+			So(result.Resources, ShouldNotContainKey, "alicloud_eip")
+		})
+
+		Convey("an unresolvable count should fall back to a single instance", func() {
+			So(result.Resources["alicloud_vswitch"], ShouldContainKey, "unresolved")
+		})
+
+		Convey("a count above the limit should keep a single instance with an unknown index", func() {
+			vpcs := result.Resources["alicloud_vpc"]
+			So(vpcs, ShouldHaveLength, 1)
+			So(vpcs["huge"]["vpc_name"], ShouldEqual, UnknownValue)
+		})
+
+		Convey("for_each keys should be quoted as Terraform does", func() {
+			projects := result.Resources["alicloud_log_project"]
+			So(projects, ShouldContainKey, `projects["日志"]`)
+			So(projects, ShouldContainKey, `projects["a\"b"]`)
+			So(projects, ShouldContainKey, `projects["$${x}"]`)
+		})
+	})
+}
+
+func TestEvaluateDynamicBlocks(t *testing.T) {
+	Convey("Given resources with dynamic blocks", t, func() {
+		dir := t.TempDir()
+		content := []byte(`variable "ports" {
+  default = [22, 443]
+}
+
+resource "alicloud_security_group" "sg" {
+  ingress {
+    port = 80
+  }
+
+  dynamic "ingress" {
+    for_each = var.ports
+    content {
+      port     = ingress.value
+      priority = ingress.key
+    }
+  }
+}
+
+resource "alicloud_instance" "web" {
+  dynamic "data_disks" {
+    for_each = {
+      logs = 40
+    }
+    iterator = disk
+    content {
+      name      = disk.key
+      size      = disk.value
+      encrypted = true
+    }
+  }
+}
+
+resource "alicloud_slb_listener" "l" {
+  dynamic "rule" {
+    for_each = var.missing
+    content {
+      port = rule.value
+    }
+  }
+}
+`)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), content, 0644), ShouldBeNil)
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+
+		Convey("dynamic blocks should merge with static blocks of the same type", func() {
+			ingress, ok := result.Resources["alicloud_security_group"]["sg"]["ingress"].([]interface{})
+			So(ok, ShouldBeTrue)
+			So(ingress, ShouldHaveLength, 3)
+			So(ingress[0].(map[string]interface{})["port"], ShouldEqual, float64(80))
+			So(ingress[1].(map[string]interface{})["port"], ShouldEqual, float64(22))
+			So(ingress[2].(map[string]interface{})["priority"], ShouldEqual, float64(1))
+		})
+
+		Convey("a custom iterator name should be bound", func() {
+			disk, ok := result.Resources["alicloud_instance"]["web"]["data_disks"].(map[string]interface{})
+			So(ok, ShouldBeTrue)
+			So(disk["name"], ShouldEqual, "logs")
+			So(disk["size"], ShouldEqual, float64(40))
+			So(disk["encrypted"], ShouldEqual, true)
+		})
+
+		Convey("an unresolvable for_each should keep one block with unknown values", func() {
+			rule, ok := result.Resources["alicloud_slb_listener"]["l"]["rule"].(map[string]interface{})
+			So(ok, ShouldBeTrue)
+			So(rule["port"], ShouldEqual, UnknownValue)
+		})
+	})
+}
+
+func TestEvaluateLocalModules(t *testing.T) {
+	Convey("Given a root module calling a local child module", t, func() {
+		dir := t.TempDir()
+		modDir := filepath.Join(dir, "modules", "network")
+		So(os.MkdirAll(modDir, 0755), ShouldBeNil)
+
+		root := []byte(`module "network" {
+  source   = "./modules/network"
+  vpc_name = "prod"
+  cidrs    = ["10.0.0.0/16"]
+}
+
+module "remote" {
+  source = "hashicorp/consul/aws"
+}
+
+resource "alicloud_vswitch" "sw" {
+  vpc_id = module.network.name
+}
+`)
+		child := []byte(`variable "vpc_name" {}
+variable "cidrs" {}
+
+resource "alicloud_vpc" "main" {
+  vpc_name   = var.vpc_name
+  cidr_block = var.cidrs[0]
+}
+
+output "name" {
+  value = var.vpc_name
+}
+`)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), root, 0644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(modDir, "main.tf"), child, 0644), ShouldBeNil)
+
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+
+		Convey("child resources should be bound from the call's arguments", func() {
+			vpc := result.Resources["alicloud_vpc"]["module.network.main"]
+			So(vpc, ShouldNotBeNil)
+			So(vpc["vpc_name"], ShouldEqual, "prod")
+			So(vpc["cidr_block"], ShouldEqual, "10.0.0.0/16")
+		})
+
+		Convey("__meta__ should point to the module's file and record the address", func() {
+			meta := result.Resources["alicloud_vpc"]["module.network.main"]["__meta__"].(map[string]interface{})
+			So(meta["filename"], ShouldEqual, filepath.Join("modules", "network", "main.tf"))
+			So(meta["line"], ShouldEqual, 4)
+			So(meta["address"], ShouldEqual, "module.network.alicloud_vpc.main")
+		})
+
+		Convey("module outputs should be available to the caller", func() {
+			So(result.Resources["alicloud_vswitch"]["sw"]["vpc_id"], ShouldEqual, "prod")
+		})
+
+		Convey("LocalModuleDirs should list only local module sources", func() {
+			dirs := LocalModuleDirs(dir)
+			So(dirs, ShouldHaveLength, 1)
+			So(filepath.Base(dirs[0]), ShouldEqual, "network")
+		})
+	})
+}
@@ -0,0 +1,469 @@
+package terraform
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// terraformFunctions holds the functions available in Terraform expressions: the
+// cty standard library under Terraform's names plus the Terraform-specific
+// functions. Functions that depend on the run or the filesystem (timestamp, uuid,
+// file, templatefile) are not registered, so calls to them stay UnknownValue.
+var terraformFunctions = map[string]function.Function{
+	// Numeric
+	"abs":      stdlib.AbsoluteFunc,
+	"ceil":     stdlib.CeilFunc,
+	"floor":    stdlib.FloorFunc,
+	"log":      stdlib.LogFunc,
+	"max":      stdlib.MaxFunc,
+	"min":      stdlib.MinFunc,
+	"parseint": stdlib.ParseIntFunc,
+	"pow":      stdlib.PowFunc,
+	"signum":   stdlib.SignumFunc,
+
+	// String
+	"chomp":        stdlib.ChompFunc,
+	"endswith":     endsWithFunc,
+	"format":       stdlib.FormatFunc,
+	"formatlist":   stdlib.FormatListFunc,
+	"indent":       stdlib.IndentFunc,
+	"join":         stdlib.JoinFunc,
+	"lower":        stdlib.LowerFunc,
+	"regex":        stdlib.RegexFunc,
+	"regexall":     stdlib.RegexAllFunc,
+	"replace":      replaceFunc,
+	"split":        stdlib.SplitFunc,
+	"startswith":   startsWithFunc,
+	"strcontains":  strContainsFunc,
+	"strrev":       stdlib.ReverseFunc,
+	"substr":       stdlib.SubstrFunc,
+	"title":        stdlib.TitleFunc,
+	"trim":         stdlib.TrimFunc,
+	"trimprefix":   stdlib.TrimPrefixFunc,
+	"trimspace":    stdlib.TrimSpaceFunc,
+	"trimsuffix":   stdlib.TrimSuffixFunc,
+	"upper":        stdlib.UpperFunc,
+	"urlencode":    urlEncodeFunc,
+	"base64encode": base64EncodeFunc,
+	"base64decode": base64DecodeFunc,
+
+	// Collection
+	"alltrue":         allTrueFunc,
+	"anytrue":         anyTrueFunc,
+	"chunklist":       stdlib.ChunklistFunc,
+	"coalesce":        stdlib.CoalesceFunc,
+	"coalescelist":    stdlib.CoalesceListFunc,
+	"compact":         stdlib.CompactFunc,
+	"concat":          stdlib.ConcatFunc,
+	"contains":        stdlib.ContainsFunc,
+	"distinct":        stdlib.DistinctFunc,
+	"element":         stdlib.ElementFunc,
+	"flatten":         stdlib.FlattenFunc,
+	"index":           indexFunc,
+	"keys":            stdlib.KeysFunc,
+	"length":          lengthFunc,
+	"lookup":          stdlib.LookupFunc,
+	"merge":           stdlib.MergeFunc,
+	"one":             oneFunc,
+	"range":           stdlib.RangeFunc,
+	"reverse":         stdlib.ReverseListFunc,
+	"setintersection": stdlib.SetIntersectionFunc,
+	"setproduct":      stdlib.SetProductFunc,
+	"setsubtract":     stdlib.SetSubtractFunc,
+	"setunion":        stdlib.SetUnionFunc,
+	"slice":           stdlib.SliceFunc,
+	"sort":            stdlib.SortFunc,
+	"sum":             sumFunc,
+	"values":          stdlib.ValuesFunc,
+	"zipmap":          stdlib.ZipmapFunc,
+
+	// Encoding
+	"csvdecode":  stdlib.CSVDecodeFunc,
+	"jsondecode": stdlib.JSONDecodeFunc,
+	"jsonencode": stdlib.JSONEncodeFunc,
+
+	// Date and time
+	"formatdate": stdlib.FormatDateFunc,
+	"timeadd":    stdlib.TimeAddFunc,
+
+	// Hash
+	"md5":    hashFunc(func(b []byte) []byte { s := md5.Sum(b); return s[:] }),
+	"sha1":   hashFunc(func(b []byte) []byte { s := sha1.Sum(b); return s[:] }),
+	"sha256": hashFunc(func(b []byte) []byte { s := sha256.Sum256(b); return s[:] }),
+	"sha512": hashFunc(func(b []byte) []byte { s := sha512.Sum512(b); return s[:] }),
+
+	// IP network
+	"cidrhost":    cidrHostFunc,
+	"cidrnetmask": cidrNetmaskFunc,
+	"cidrsubnet":  cidrSubnetFunc,
+	"cidrsubnets": cidrSubnetsFunc,
+
+	// Filesystem paths (no file access)
+	"basename": pathFunc(filepath.Base),
+	"dirname":  pathFunc(filepath.Dir),
+
+	// Type conversion
+	"can":          tryfunc.CanFunc,
+	"nonsensitive": identityFunc,
+	"sensitive":    identityFunc,
+	"tobool":       stdlib.MakeToFunc(cty.Bool),
+	"tolist":       stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+	"tomap":        stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+	"tonumber":     stdlib.MakeToFunc(cty.Number),
+	"toset":        stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+	"tostring":     stdlib.MakeToFunc(cty.String),
+	"try":          tryfunc.TryFunc,
+}
+
+// stringFunc builds a function of one string argument returning a string.
+func stringFunc(impl func(string) (string, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "str", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			s, err := impl(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(s), nil
+		},
+	})
+}
+
+// stringPredicateFunc builds a function of two strings returning a bool.
+func stringPredicateFunc(pred func(s, part string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "part", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.BoolVal(pred(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+func hashFunc(sum func([]byte) []byte) function.Function {
+	return stringFunc(func(s string) (string, error) {
+		return hex.EncodeToString(sum([]byte(s))), nil
+	})
+}
+
+func pathFunc(f func(string) string) function.Function {
+	return stringFunc(func(s string) (string, error) { return f(s), nil })
+}
+
+var (
+	startsWithFunc  = stringPredicateFunc(strings.HasPrefix)
+	endsWithFunc    = stringPredicateFunc(strings.HasSuffix)
+	strContainsFunc = stringPredicateFunc(strings.Contains)
+
+	base64EncodeFunc = stringFunc(func(s string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(s)), nil
+	})
+	base64DecodeFunc = stringFunc(func(s string) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64 data %q", s)
+		}
+		if !utf8.Valid(b) {
+			return "", fmt.Errorf("the result of decoding the provided string is not valid UTF-8")
+		}
+		return string(b), nil
+	})
+	urlEncodeFunc = stringFunc(func(s string) (string, error) {
+		return url.QueryEscape(s), nil
+	})
+)
+
+// identityFunc returns its argument unchanged (sensitive, nonsensitive).
+var identityFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "value", Type: cty.DynamicPseudoType, AllowNull: true}},
+	Type:   func(args []cty.Value) (cty.Type, error) { return args[0].Type(), nil },
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return args[0], nil
+	},
+})
+
+// replaceFunc is Terraform's replace: a substring wrapped in slashes is a regular expression.
+var replaceFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "str", Type: cty.String},
+		{Name: "substr", Type: cty.String},
+		{Name: "replace", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		str, substr, replace := args[0].AsString(), args[1].AsString(), args[2].AsString()
+		if len(substr) > 1 && strings.HasPrefix(substr, "/") && strings.HasSuffix(substr, "/") {
+			re, err := regexp.Compile(substr[1 : len(substr)-1])
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(re.ReplaceAllString(str, replace)), nil
+		}
+		return cty.StringVal(strings.ReplaceAll(str, substr, replace)), nil
+	},
+})
+
+// lengthFunc is Terraform's length, which also counts the characters of a string.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "value", Type: cty.DynamicPseudoType, AllowDynamicType: true}},
+	Type:   function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// indexFunc is Terraform's index: the position of the first element equal to value.
+var indexFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "list", Type: cty.DynamicPseudoType},
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		list, value := args[0], args[1]
+		if !(list.Type().IsListType() || list.Type().IsTupleType()) {
+			return cty.NilVal, fmt.Errorf("argument must be a list or tuple")
+		}
+		for it := list.ElementIterator(); it.Next(); {
+			i, v := it.Element()
+			if eq := v.Equals(value); eq.IsKnown() && eq.True() {
+				return i, nil
+			}
+		}
+		return cty.NilVal, fmt.Errorf("item not found")
+	},
+})
+
+// oneFunc returns the only element of a collection, or null if it is empty.
+var oneFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.DynamicPseudoType}},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		switch {
+		case ty.IsListType() || ty.IsSetType():
+			return ty.ElementType(), nil
+		case ty.IsTupleType():
+			if n := len(ty.TupleElementTypes()); n == 1 {
+				return ty.TupleElementTypes()[0], nil
+			} else if n == 0 {
+				return cty.DynamicPseudoType, nil
+			}
+			return cty.NilType, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+		}
+		return cty.NilType, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		val := args[0]
+		switch val.LengthInt() {
+		case 0:
+			return cty.NullVal(retType), nil
+		case 1:
+			it := val.ElementIterator()
+			it.Next()
+			_, v := it.Element()
+			return v, nil
+		}
+		return cty.NilVal, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+	},
+})
+
+// sumFunc adds up a collection of numbers.
+var sumFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.DynamicPseudoType}},
+	Type:   function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if !args[0].CanIterateElements() || args[0].LengthInt() == 0 {
+			return cty.NilVal, fmt.Errorf("cannot sum an empty list")
+		}
+		total := cty.Zero
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			n, err := convert.Convert(v, cty.Number)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			total = total.Add(n)
+		}
+		return total, nil
+	},
+})
+
+func boolReduceFunc(all bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "list", Type: cty.List(cty.Bool)}},
+		Type:   function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			for it := args[0].ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				if !v.IsKnown() {
+					return cty.UnknownVal(cty.Bool), nil
+				}
+				if !v.IsNull() && v.True() != all {
+					return cty.BoolVal(!all), nil
+				}
+				if v.IsNull() && all {
+					return cty.False, nil
+				}
+			}
+			return cty.BoolVal(all), nil
+		},
+	})
+}
+
+var (
+	allTrueFunc = boolReduceFunc(true)
+	anyTrueFunc = boolReduceFunc(false)
+)
+
+// cidrHostFunc computes a host address within a prefix; negative numbers count from the end.
+var cidrHostFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "hostnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ipnet, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		hostnum, _ := args[1].AsBigFloat().Int(nil)
+		ones, bits := ipnet.Mask.Size()
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+		if hostnum.Sign() < 0 {
+			hostnum.Add(hostnum, size)
+		}
+		if hostnum.Sign() < 0 || hostnum.Cmp(size) >= 0 {
+			return cty.UnknownVal(cty.String), fmt.Errorf("prefix of %d does not accommodate a host numbered %s", ones, args[1].AsBigFloat().String())
+		}
+		ip := new(big.Int).SetBytes(ipnet.IP)
+		ip.Add(ip, hostnum)
+		return cty.StringVal(bigToIP(ip, len(ipnet.IP)).String()), nil
+	},
+})
+
+// cidrNetmaskFunc returns the dotted netmask of an IPv4 prefix.
+var cidrNetmaskFunc = stringFunc(func(prefix string) (string, error) {
+	ipnet, err := parseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+	if len(ipnet.IP) != net.IPv4len {
+		return "", fmt.Errorf("only IPv4 networks are supported")
+	}
+	return net.IP(ipnet.Mask).String(), nil
+})
+
+// cidrSubnetFunc computes the netnum-th subnet of a prefix extended by newbits.
+var cidrSubnetFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "newbits", Type: cty.Number},
+		{Name: "netnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ipnet, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		newbits, _ := args[1].AsBigFloat().Int64()
+		netnum, _ := args[2].AsBigFloat().Int(nil)
+		ones, bits := ipnet.Mask.Size()
+		length := ones + int(newbits)
+		if newbits < 0 || length > bits {
+			return cty.UnknownVal(cty.String), fmt.Errorf("insufficient address space to extend prefix of %d by %d", ones, newbits)
+		}
+		if netnum.Sign() < 0 || netnum.BitLen() > int(newbits) {
+			return cty.UnknownVal(cty.String), fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %s", newbits, netnum.String())
+		}
+		ip := new(big.Int).SetBytes(ipnet.IP)
+		ip.Or(ip, new(big.Int).Lsh(netnum, uint(bits-length)))
+		return cty.StringVal(fmt.Sprintf("%s/%d", bigToIP(ip, len(ipnet.IP)), length)), nil
+	},
+})
+
+// cidrSubnetsFunc allocates consecutive subnets of the given sizes within a prefix.
+var cidrSubnetsFunc = function.New(&function.Spec{
+	Params:   []function.Parameter{{Name: "prefix", Type: cty.String}},
+	VarParam: &function.Parameter{Name: "newbits", Type: cty.Number},
+	Type:     function.StaticReturnType(cty.List(cty.String)),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ipnet, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if len(args) == 1 {
+			return cty.ListValEmpty(cty.String), nil
+		}
+		ones, bits := ipnet.Mask.Size()
+		base := new(big.Int).SetBytes(ipnet.IP)
+		end := new(big.Int).Add(base, new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)))
+		next := new(big.Int).Set(base)
+
+		var subnets []cty.Value
+		for _, arg := range args[1:] {
+			newbits, _ := arg.AsBigFloat().Int64()
+			length := ones + int(newbits)
+			if newbits < 1 || length > bits {
+				return cty.UnknownVal(retType), fmt.Errorf("insufficient address space to extend prefix of %d by %d", ones, newbits)
+			}
+			size := new(big.Int).Lsh(big.NewInt(1), uint(bits-length))
+			// Align the start of the next subnet to its own size.
+			if rem := new(big.Int).Mod(next, size); rem.Sign() != 0 {
+				next.Add(next, new(big.Int).Sub(size, rem))
+			}
+			if new(big.Int).Add(next, size).Cmp(end) > 0 {
+				return cty.UnknownVal(retType), fmt.Errorf("not enough remaining address space for a subnet with a prefix of %d bits", length)
+			}
+			subnets = append(subnets, cty.StringVal(fmt.Sprintf("%s/%d", bigToIP(next, len(ipnet.IP)), length)))
+			next.Add(next, size)
+		}
+		return cty.ListVal(subnets), nil
+	},
+})
+
+// parseCIDR parses a prefix, keeping IPv4 networks in their 4-byte form.
+func parseCIDR(prefix string) (*net.IPNet, error) {
+	_, ipnet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR expression: %s", err)
+	}
+	if ip4 := ipnet.IP.To4(); ip4 != nil {
+		ipnet.IP = ip4
+	}
+	return ipnet, nil
+}
+
+// bigToIP converts an integer back into an IP address of the given byte length.
+func bigToIP(n *big.Int, size int) net.IP {
+	b := n.Bytes()
+	ip := make(net.IP, size)
+	copy(ip[size-len(b):], b)
+	return ip
+}
@@ -0,0 +1,65 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEvaluateFunctions(t *testing.T) {
+	Convey("Given resources whose attributes call Terraform functions", t, func() {
+		dir := t.TempDir()
+		content := []byte(`variable "tags" {
+  default = { env = "prod" }
+}
+
+variable "vpc_cidr" {
+  default = "10.0.0.0/16"
+}
+
+locals {
+  tags = merge(var.tags, { team = "infra" })
+}
+
+resource "alicloud_vswitch" "app" {
+  cidr_block = cidrsubnet(var.vpc_cidr, 8, 1)
+  name       = format("%s-%s", lookup(local.tags, "env", "dev"), upper("app"))
+  tags       = tomap(local.tags)
+  policy     = jsonencode({ Effect = "Allow" })
+  zones      = length(distinct(["a", "a", "b"]))
+  gateway    = cidrhost(cidrsubnet(var.vpc_cidr, 8, 1), 1)
+  subnets    = cidrsubnets("10.1.0.0/16", 4, 4, 8)
+  safe       = try(var.missing.value, "fallback")
+  prefixed   = startswith(var.vpc_cidr, "10.")
+  hashed     = sha256("abc")
+  created    = timestamp()
+}
+`)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), content, 0644), ShouldBeNil)
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+		app := result.Resources["alicloud_vswitch"]["app"]
+
+		Convey("stdlib and Terraform-specific functions should be resolved", func() {
+			So(app["cidr_block"], ShouldEqual, "10.0.1.0/24")
+			So(app["gateway"], ShouldEqual, "10.0.1.1")
+			So(app["subnets"], ShouldResemble, []interface{}{"10.1.0.0/20", "10.1.16.0/20", "10.1.32.0/24"})
+			So(app["name"], ShouldEqual, "prod-APP")
+			So(app["tags"], ShouldResemble, map[string]interface{}{"env": "prod", "team": "infra"})
+			So(app["policy"], ShouldEqual, `{"Effect":"Allow"}`)
+			So(app["zones"], ShouldEqual, float64(2))
+			So(app["safe"], ShouldEqual, "fallback")
+			So(app["prefixed"], ShouldEqual, true)
+			So(app["hashed"], ShouldEqual, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad")
+		})
+
+		Convey("non-deterministic functions should stay unknown", func() {
+			So(app["created"], ShouldEqual, UnknownValue)
+		})
+	})
+}
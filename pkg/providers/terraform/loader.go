@@ -8,11 +8,30 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
 
+// LoadOptions configures LoadWithOptions.
+type LoadOptions struct {
+	// Env holds "KEY=value" environment entries whose TF_VAR_ variables set
+	// variable values, e.g. os.Environ(). It is nil for configurations that
+	// must not see the process environment, such as ones received by the
+	// server.
+	Env []string
+}
+
 // Load parses, evaluates, and converts a Terraform directory (or single .tf file)
-// into the OPA input format used by infraguard policies.
+// into the OPA input format used by infraguard policies. Variable values follow
+// Terraform's precedence: terraform.tfvars, terraform.tfvars.json, *.auto.tfvars
+// files, and finally inputVars. TF_VAR_ environment variables are not read; use
+// LoadWithOptions for that.
 func Load(path string, inputVars map[string]interface{}) (map[string]interface{}, error) {
+	return LoadWithOptions(path, inputVars, LoadOptions{})
+}
+
+// LoadWithOptions is Load with TF_VAR_ variables taken from opts.Env, between
+// the variable files and inputVars in precedence.
+func LoadWithOptions(path string, inputVars map[string]interface{}, opts LoadOptions) (map[string]interface{}, error) {
 	dir, err := resolveDir(path)
 	if err != nil {
 		return nil, err
@@ -23,11 +42,12 @@ func Load(path string, inputVars map[string]interface{}) (map[string]interface{}
 		return nil, fmt.Errorf("HCL parse error: %s", diags.Error())
 	}
 
-	if inputVars == nil {
-		inputVars = make(map[string]interface{})
+	vars, err := resolveInputVars(dir, inputVars, opts.Env)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := evaluate(parsed, inputVars)
+	result, err := evaluate(parsed, vars)
 	if err != nil {
 		return nil, fmt.Errorf("evaluation error: %w", err)
 	}
@@ -81,6 +101,41 @@ func resolveDir(path string) (string, error) {
 	return "", fmt.Errorf("not a Terraform file or directory: %s", path)
 }
 
+// LocalModuleDirs returns the absolute directories of the local modules called,
+// directly or indirectly, by the Terraform configuration in dir. Scanning dir
+// already covers these modules.
+func LocalModuleDirs(dir string) []string {
+	var dirs []string
+	visited := make(map[string]bool)
+	var walk func(string)
+	walk = func(d string) {
+		parsed, diags := parseTFDir(d)
+		if diags.HasErrors() {
+			return
+		}
+		for _, file := range parsed.Files {
+			body, ok := file.Body.(*hclsyntax.Body)
+			if !ok {
+				continue
+			}
+			for _, block := range body.Blocks {
+				if block.Type != "module" {
+					continue
+				}
+				child, ok := localModuleDir(d, block)
+				if !ok || visited[child] {
+					continue
+				}
+				visited[child] = true
+				dirs = append(dirs, child)
+				walk(child)
+			}
+		}
+	}
+	walk(dir)
+	return dirs
+}
+
 // IsTerraformFile reports whether path has a .tf extension.
 func IsTerraformFile(path string) bool {
 	return strings.HasSuffix(strings.ToLower(path), ".tf")
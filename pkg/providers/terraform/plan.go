@@ -0,0 +1,479 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// planJSON is the subset of the `terraform show -json` plan format used for scanning.
+type planJSON struct {
+	FormatVersion    string               `json:"format_version"`
+	TerraformVersion string               `json:"terraform_version"`
+	Variables        map[string]planValue `json:"variables"`
+	PlannedValues    *planValues          `json:"planned_values"`
+	ResourceChanges  []planResourceChange `json:"resource_changes"`
+	Configuration    *struct {
+		RootModule planConfigModule `json:"root_module"`
+	} `json:"configuration"`
+}
+
+type planValue struct {
+	Value interface{} `json:"value"`
+}
+
+type planValues struct {
+	Outputs    map[string]planValue `json:"outputs"`
+	RootModule planModule           `json:"root_module"`
+}
+
+type planModule struct {
+	Address      string         `json:"address"`
+	Resources    []planResource `json:"resources"`
+	ChildModules []planModule   `json:"child_modules"`
+}
+
+type planResource struct {
+	Address string                 `json:"address"`
+	Mode    string                 `json:"mode"`
+	Type    string                 `json:"type"`
+	Name    string                 `json:"name"`
+	Values  map[string]interface{} `json:"values"`
+}
+
+type planResourceChange struct {
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address"`
+	Mode          string `json:"mode"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	Change        struct {
+		Actions      []string               `json:"actions"`
+		After        map[string]interface{} `json:"after"`
+		AfterUnknown map[string]interface{} `json:"after_unknown"`
+	} `json:"change"`
+}
+
+type planConfigModule struct {
+	Resources   []planConfigResource `json:"resources"`
+	ModuleCalls map[string]struct {
+		Source string           `json:"source"`
+		Module planConfigModule `json:"module"`
+	} `json:"module_calls"`
+}
+
+// planConfigResource is a resource of the plan's configuration. In its expressions
+// a nested block of list or set nesting is an array, and an attribute is an object.
+type planConfigResource struct {
+	Address     string                     `json:"address"`
+	Expressions map[string]json.RawMessage `json:"expressions"`
+}
+
+// blockLocation is where a resource or data block is declared.
+type blockLocation struct {
+	file   string
+	line   int
+	blocks blockTypes // Nested block types of the block
+}
+
+// blockTypes holds the nested block types of a block by name, each with its own
+// nested block types.
+type blockTypes map[string]blockTypes
+
+// merge adds the block types of other to t.
+func (t blockTypes) merge(other blockTypes) {
+	for name, nested := range other {
+		if t[name] == nil {
+			t[name] = make(blockTypes)
+		}
+		t[name].merge(nested)
+	}
+}
+
+// IsPlanFile reports whether path is a Terraform plan in JSON form, as produced
+// by `terraform show -json <planfile>`.
+func IsPlanFile(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return isPlanJSON(data)
+}
+
+func isPlanJSON(data []byte) bool {
+	var probe struct {
+		FormatVersion   string          `json:"format_version"`
+		PlannedValues   json.RawMessage `json:"planned_values"`
+		ResourceChanges json.RawMessage `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	return probe.FormatVersion != "" && (probe.PlannedValues != nil || probe.ResourceChanges != nil)
+}
+
+// LoadPlan converts a Terraform plan JSON file into the same OPA input shape that
+// Load produces for HCL, so existing Terraform rules run on fully resolved values.
+// Resource locations are taken from the .tf files next to the plan (and the local
+// module sources named in its configuration) when they can be found.
+func LoadPlan(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var plan planJSON
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("plan JSON parse error: %w", err)
+	}
+	if !isPlanJSON(data) {
+		return nil, fmt.Errorf("not a Terraform plan JSON file: %s", path)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		dir = filepath.Dir(path)
+	}
+	return convertToOPAInput(planToEvalResult(&plan, dir, filepath.Base(path))), nil
+}
+
+// planToEvalResult builds an EvalResult from a plan. Resources are keyed like the
+// HCL evaluator keys them: name plus instance key, prefixed by the module address
+// for resources in child modules.
+func planToEvalResult(plan *planJSON, dir, planFile string) *EvalResult {
+	result := &EvalResult{
+		Resources:   make(map[string]map[string]map[string]interface{}),
+		Variables:   make(map[string]interface{}),
+		Locals:      make(map[string]interface{}),
+		DataSources: make(map[string]map[string]map[string]interface{}),
+		Outputs:     make(map[string]interface{}),
+	}
+
+	for name, v := range plan.Variables {
+		result.Variables[name] = map[string]interface{}{"value": v.Value}
+	}
+
+	unknownByAddr := make(map[string]map[string]interface{})
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change.AfterUnknown != nil {
+			unknownByAddr[rc.Address] = rc.Change.AfterUnknown
+		}
+	}
+
+	locator := newPlanLocator(plan, dir, planFile)
+	add := func(moduleAddr, address, mode, resType, name string, values map[string]interface{}) {
+		prefix := ""
+		if moduleAddr != "" {
+			prefix = moduleAddr + "."
+		}
+		local := resType + "." + name
+		if mode == "data" {
+			local = "data." + local
+		}
+
+		attrs := normalizePlanBlocks(values, locator.blockTypes(moduleAddr, local))
+		markPlanUnknown(attrs, unknownByAddr[address])
+		key := prefix + name
+		if strings.HasPrefix(address, prefix+local) {
+			key += address[len(prefix+local):] // Instance key, e.g. [0]
+		}
+		attrs["__meta__"] = locator.meta(moduleAddr, local, address)
+
+		target := result.Resources
+		if mode == "data" {
+			target = result.DataSources
+		}
+		if target[resType] == nil {
+			target[resType] = make(map[string]map[string]interface{})
+		}
+		target[resType][key] = attrs
+	}
+
+	if plan.PlannedValues != nil {
+		var walk func(m planModule)
+		walk = func(m planModule) {
+			for _, r := range m.Resources {
+				add(m.Address, r.Address, r.Mode, r.Type, r.Name, r.Values)
+			}
+			for _, child := range m.ChildModules {
+				walk(child)
+			}
+		}
+		walk(plan.PlannedValues.RootModule)
+		for name, out := range plan.PlannedValues.Outputs {
+			result.Outputs[name] = map[string]interface{}{"value": out.Value}
+		}
+	} else {
+		for _, rc := range plan.ResourceChanges {
+			if rc.Change.After == nil || isDeleteOnly(rc.Change.Actions) {
+				continue
+			}
+			add(rc.ModuleAddress, rc.Address, rc.Mode, rc.Type, rc.Name, rc.Change.After)
+		}
+	}
+
+	return result
+}
+
+func isDeleteOnly(actions []string) bool {
+	return len(actions) == 1 && actions[0] == "delete"
+}
+
+// normalizePlanBlocks copies plan values, turning a nested block of one of the
+// given block types into an object when it occurs once. The plan encodes nested
+// blocks as lists, while the HCL evaluator keeps a single nested block as an
+// object. Attributes keep the plan's shape, including lists of one object.
+func normalizePlanBlocks(values map[string]interface{}, types blockTypes) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		nested, isBlock := types[k]
+		if list, ok := v.([]interface{}); ok && isBlock && len(list) == 1 {
+			if obj, ok := list[0].(map[string]interface{}); ok {
+				out[k] = normalizePlanBlocks(obj, nested)
+				continue
+			}
+		}
+		out[k] = normalizePlanValue(v, nested)
+	}
+	return out
+}
+
+func normalizePlanValue(v interface{}, types blockTypes) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return normalizePlanBlocks(val, types)
+	case []interface{}:
+		items := make([]interface{}, len(val))
+		for i, item := range val {
+			items[i] = normalizePlanValue(item, types)
+		}
+		return items
+	default:
+		return v
+	}
+}
+
+// markPlanUnknown sets attributes that are only known after apply to UnknownValue,
+// following the after_unknown structure of the resource change.
+func markPlanUnknown(attrs map[string]interface{}, unknown map[string]interface{}) {
+	for k, u := range unknown {
+		switch uv := u.(type) {
+		case bool:
+			if uv {
+				attrs[k] = UnknownValue
+			}
+		case map[string]interface{}:
+			if nested, ok := attrs[k].(map[string]interface{}); ok {
+				markPlanUnknown(nested, uv)
+			}
+		case []interface{}:
+			// A single nested block was unwrapped from its list
+			if nested, ok := attrs[k].(map[string]interface{}); ok && len(uv) == 1 {
+				if nu, ok := uv[0].(map[string]interface{}); ok {
+					markPlanUnknown(nested, nu)
+				}
+			}
+		}
+	}
+}
+
+// planLocator maps plan resources back to the .tf blocks they come from.
+type planLocator struct {
+	dir          string                              // Directory of the plan file
+	planFile     string                              // Plan file name, used when a block cannot be found
+	moduleDirs   map[string]string                   // Module call path ("module.a.module.b") -> directory
+	blocks       map[string]map[string]blockLocation // Directory -> local block address -> location
+	configBlocks map[string]blockTypes               // Module call path and local block address -> nested block types
+}
+
+func newPlanLocator(plan *planJSON, dir, planFile string) *planLocator {
+	l := &planLocator{
+		dir:          dir,
+		planFile:     planFile,
+		moduleDirs:   map[string]string{"": dir},
+		blocks:       make(map[string]map[string]blockLocation),
+		configBlocks: make(map[string]blockTypes),
+	}
+	if plan.Configuration != nil {
+		l.addModule("", dir, plan.Configuration.RootModule)
+	}
+	return l
+}
+
+// addModule records the nested block types of the module's resources and the
+// directories of its local module calls, recursively.
+func (l *planLocator) addModule(path, dir string, m planConfigModule) {
+	for _, r := range m.Resources {
+		l.configBlocks[modulePrefix(path)+r.Address] = expressionBlockTypes(r.Expressions)
+	}
+	for name, call := range m.ModuleCalls {
+		childPath := modulePrefix(path) + "module." + name
+		childDir := "" // Unknown for modules that are not local to a known directory
+		if dir != "" && (strings.HasPrefix(call.Source, "./") || strings.HasPrefix(call.Source, "../")) {
+			childDir = filepath.Join(dir, call.Source)
+			l.moduleDirs[childPath] = childDir
+		}
+		l.addModule(childPath, childDir, call.Module)
+	}
+}
+
+// modulePrefix returns the address prefix of a module call path, e.g.
+// "module.a." for "module.a"; empty for the root module.
+func modulePrefix(path string) string {
+	if path == "" {
+		return ""
+	}
+	return path + "."
+}
+
+// expressionBlockTypes returns the nested block types found in the configuration
+// expressions of a block: those whose expression is an array of block bodies.
+func expressionBlockTypes(exprs map[string]json.RawMessage) blockTypes {
+	types := make(blockTypes)
+	for name, raw := range exprs {
+		var bodies []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &bodies); err != nil {
+			continue
+		}
+		nested := make(blockTypes)
+		for _, body := range bodies {
+			nested.merge(expressionBlockTypes(body))
+		}
+		types[name] = nested
+	}
+	return types
+}
+
+// blockTypes returns the nested block types of a resource or data block, from
+// the plan's configuration and from the .tf source when it can be found. The
+// source adds the block types written as dynamic blocks.
+func (l *planLocator) blockTypes(moduleAddr, local string) blockTypes {
+	path := stripInstanceKeys(moduleAddr)
+	types := make(blockTypes)
+	types.merge(l.configBlocks[modulePrefix(path)+local])
+	if dir, ok := l.moduleDirs[path]; ok {
+		types.merge(l.blocksIn(dir)[local].blocks)
+	}
+	return types
+}
+
+// meta returns the __meta__ entry for a resource. Files are relative to the plan's
+// directory; resources in child modules also record their full address.
+func (l *planLocator) meta(moduleAddr, local, address string) map[string]interface{} {
+	meta := map[string]interface{}{
+		"filename": l.planFile,
+		"line":     1,
+	}
+	if dir, ok := l.moduleDirs[stripInstanceKeys(moduleAddr)]; ok {
+		if loc, ok := l.blocksIn(dir)[local]; ok {
+			meta["line"] = loc.line
+			if rel, err := filepath.Rel(l.dir, loc.file); err == nil {
+				meta["filename"] = rel
+			}
+		}
+	}
+	if moduleAddr != "" {
+		meta["address"] = address
+	}
+	return meta
+}
+
+// blocksIn parses the .tf files of dir once and indexes their resource and data blocks.
+func (l *planLocator) blocksIn(dir string) map[string]blockLocation {
+	if blocks, ok := l.blocks[dir]; ok {
+		return blocks
+	}
+	blocks := make(map[string]blockLocation)
+	l.blocks[dir] = blocks
+
+	parsed, diags := parseTFDir(dir)
+	if parsed == nil || diags.HasErrors() {
+		return blocks
+	}
+	for filePath, file := range parsed.Files {
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if len(block.Labels) < 2 {
+				continue
+			}
+			local := block.Labels[0] + "." + block.Labels[1]
+			switch block.Type {
+			case "resource":
+			case "data":
+				local = "data." + local
+			default:
+				continue
+			}
+			abs, err := filepath.Abs(filePath)
+			if err != nil {
+				abs = filePath
+			}
+			blocks[local] = blockLocation{file: abs, line: block.DefRange().Start.Line, blocks: bodyBlockTypes(block.Body)}
+		}
+	}
+	return blocks
+}
+
+// bodyBlockTypes returns the nested block types of an HCL block body, taking a
+// dynamic block as the block type it generates.
+func bodyBlockTypes(body *hclsyntax.Body) blockTypes {
+	types := make(blockTypes)
+	for _, block := range body.Blocks {
+		name, inner := block.Type, block.Body
+		if name == "dynamic" {
+			if len(block.Labels) == 0 {
+				continue
+			}
+			name, inner = block.Labels[0], nil
+			for _, b := range block.Body.Blocks {
+				if b.Type == "content" {
+					inner = b.Body
+				}
+			}
+		}
+		if types[name] == nil {
+			types[name] = make(blockTypes)
+		}
+		if inner != nil {
+			types[name].merge(bodyBlockTypes(inner))
+		}
+	}
+	return types
+}
+
+// stripInstanceKeys removes count/for_each keys from a module address, e.g.
+// `module.a[0].module.b["x"]` -> "module.a.module.b".
+func stripInstanceKeys(addr string) string {
+	var b strings.Builder
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(addr); i++ {
+		c := addr[i]
+		switch {
+		case inQuote:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inQuote = false
+			}
+		case c == '"' && depth > 0:
+			inQuote = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testPlanJSON = `{
+  "format_version": "1.2",
+  "terraform_version": "1.9.0",
+  "variables": {"env": {"value": "prod"}},
+  "planned_values": {
+    "outputs": {"bucket": {"sensitive": false, "value": "logs-prod"}},
+    "root_module": {
+      "resources": [
+        {
+          "address": "alicloud_oss_bucket.logs",
+          "mode": "managed",
+          "type": "alicloud_oss_bucket",
+          "name": "logs",
+          "values": {
+            "bucket": "logs-prod",
+            "acl": "public-read",
+            "logging": [{"target_bucket": "audit"}],
+            "lifecycle_rule": [{"enabled": true}],
+            "cors_rule": [{"allowed_methods": ["GET"]}],
+            "tags_list": [{"key": "env"}]
+          }
+        },
+        {
+          "address": "alicloud_instance.web[0]",
+          "mode": "managed",
+          "type": "alicloud_instance",
+          "name": "web",
+          "index": 0,
+          "values": {"instance_type": "ecs.g6.large"}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.network",
+          "resources": [
+            {
+              "address": "module.network.alicloud_vpc.main",
+              "mode": "managed",
+              "type": "alicloud_vpc",
+              "name": "main",
+              "values": {"cidr_block": "10.0.0.0/8"}
+            }
+          ]
+        }
+      ]
+    }
+  },
+  "resource_changes": [
+    {
+      "address": "alicloud_instance.web[0]",
+      "mode": "managed",
+      "type": "alicloud_instance",
+      "name": "web",
+      "change": {"actions": ["create"], "after": {"instance_type": "ecs.g6.large"}, "after_unknown": {"id": true, "public_ip": true}}
+    }
+  ],
+  "configuration": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "alicloud_oss_bucket.logs",
+          "mode": "managed",
+          "type": "alicloud_oss_bucket",
+          "name": "logs",
+          "expressions": {
+            "bucket": {"references": ["var.env"]},
+            "lifecycle_rule": [{"enabled": {"constant_value": true}}],
+            "tags_list": {"constant_value": [{"key": "env"}]}
+          }
+        }
+      ],
+      "module_calls": {"network": {"source": "./modules/network", "module": {}}}
+    }
+  }
+}`
+
+func TestLoadPlan(t *testing.T) {
+	Convey("Given a Terraform plan JSON next to its configuration", t, func() {
+		dir := t.TempDir()
+		modDir := filepath.Join(dir, "modules", "network")
+		So(os.MkdirAll(modDir, 0755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "alicloud_oss_bucket" "logs" {
+  bucket = "logs-${var.env}"
+  logging {
+    target_bucket = "audit"
+  }
+  dynamic "cors_rule" {
+    for_each = ["GET"]
+    content {
+      allowed_methods = [cors_rule.value]
+    }
+  }
+  lifecycle_rule {
+    enabled = true
+  }
+  tags_list = [{ key = "env" }]
+}
+
+resource "alicloud_instance" "web" {
+  count = 1
+}
+`), 0644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(modDir, "vpc.tf"), []byte(`
+resource "alicloud_vpc" "main" {}
+`), 0644), ShouldBeNil)
+		planPath := filepath.Join(dir, "plan.json")
+		So(os.WriteFile(planPath, []byte(testPlanJSON), 0644), ShouldBeNil)
+
+		Convey("It should be recognized as a plan file", func() {
+			So(IsPlanFile(planPath), ShouldBeTrue)
+			So(IsPlanFile(filepath.Join(dir, "main.tf")), ShouldBeFalse)
+		})
+
+		input, err := LoadPlan(planPath)
+		So(err, ShouldBeNil)
+		resources := input["resources"].(map[string]interface{})
+
+		Convey("It should produce the HCL input shape with resolved values", func() {
+			So(input["format"], ShouldEqual, "terraform")
+			bucket := resources["alicloud_oss_bucket"].(map[string]interface{})["logs"].(map[string]interface{})
+			So(bucket["bucket"], ShouldEqual, "logs-prod")
+			So(bucket["acl"], ShouldEqual, "public-read")
+			// A single nested block is an object, as with HCL input
+			So(bucket["logging"].(map[string]interface{})["target_bucket"], ShouldEqual, "audit")
+			So(bucket["lifecycle_rule"].(map[string]interface{})["enabled"], ShouldEqual, true)
+			So(bucket["cors_rule"], ShouldHaveSameTypeAs, map[string]interface{}{})
+			// A list attribute stays a list, even with one element
+			So(bucket["tags_list"], ShouldResemble, []interface{}{map[string]interface{}{"key": "env"}})
+			So(input["variables"].(map[string]interface{})["env"], ShouldResemble, map[string]interface{}{"value": "prod"})
+		})
+
+		Convey("It should keep instance keys and mark values known after apply", func() {
+			web := resources["alicloud_instance"].(map[string]interface{})["web[0]"].(map[string]interface{})
+			So(web["instance_type"], ShouldEqual, "ecs.g6.large")
+			So(web["public_ip"], ShouldEqual, UnknownValue)
+		})
+
+		Convey("It should locate blocks in the .tf files of the configuration", func() {
+			bucket := resources["alicloud_oss_bucket"].(map[string]interface{})["logs"].(map[string]interface{})
+			meta := bucket["__meta__"].(map[string]interface{})
+			So(meta["filename"], ShouldEqual, "main.tf")
+			So(meta["line"], ShouldEqual, 1)
+
+			web := resources["alicloud_instance"].(map[string]interface{})["web[0]"].(map[string]interface{})
+			So(web["__meta__"].(map[string]interface{})["line"], ShouldEqual, 18)
+
+			vpc := resources["alicloud_vpc"].(map[string]interface{})["module.network.main"].(map[string]interface{})
+			vpcMeta := vpc["__meta__"].(map[string]interface{})
+			So(vpcMeta["filename"], ShouldEqual, filepath.Join("modules", "network", "vpc.tf"))
+			So(vpcMeta["line"], ShouldEqual, 2)
+			So(vpcMeta["address"], ShouldEqual, "module.network.alicloud_vpc.main")
+		})
+	})
+
+	Convey("Given a plan without its configuration on disk", t, func() {
+		dir := t.TempDir()
+		planPath := filepath.Join(dir, "tfplan.json")
+		So(os.WriteFile(planPath, []byte(testPlanJSON), 0644), ShouldBeNil)
+
+		input, err := LoadPlan(planPath)
+		So(err, ShouldBeNil)
+
+		bucket := input["resources"].(map[string]interface{})["alicloud_oss_bucket"].(map[string]interface{})["logs"].(map[string]interface{})
+
+		Convey("Violations should point to the plan file", func() {
+			So(bucket["__meta__"].(map[string]interface{})["filename"], ShouldEqual, "tfplan.json")
+		})
+
+		Convey("Nested blocks should be known from the plan's configuration", func() {
+			So(bucket["lifecycle_rule"], ShouldHaveSameTypeAs, map[string]interface{}{})
+			So(bucket["tags_list"], ShouldHaveSameTypeAs, []interface{}{})
+			So(bucket["logging"], ShouldHaveSameTypeAs, []interface{}{})
+		})
+	})
+}
+
+func TestStripInstanceKeys(t *testing.T) {
+	Convey("stripInstanceKeys should drop count and for_each keys", t, func() {
+		So(stripInstanceKeys("module.a"), ShouldEqual, "module.a")
+		So(stripInstanceKeys(`module.a[0].module.b["x.y]"]`), ShouldEqual, "module.a.module.b")
+	})
+}
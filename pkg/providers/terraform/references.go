@@ -0,0 +1,281 @@
+package terraform
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Keys of the placeholder a resource reference evaluates to, e.g.
+// {"__ref__": "alicloud_vpc.main", "attr": "id"} for alicloud_vpc.main.id.
+const (
+	refKey     = "__ref__"
+	refAttrKey = "attr"
+)
+
+// refTarget collects how one declared resource or data block is referenced.
+type refTarget struct {
+	address string          // Terraform address, including the module prefix
+	body    *hclsyntax.Body // The block body, for its count or for_each
+	used    bool            // Whether the block is referenced at all
+	attrs   map[string]bool // Attributes referenced, directly or through any instance key
+	maxIdx  int64           // Largest literal count index referenced, or -1
+	keys    map[string]bool // Literal for_each keys referenced
+}
+
+// refTargets returns the resources and data sources declared in parsed, keyed by
+// local address, with the attributes the configuration references on each.
+func refTargets(parsed *ParsedConfig, prefix string) map[string]*refTarget {
+	targets := make(map[string]*refTarget)
+	for _, file := range parsed.Files {
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if len(block.Labels) < 2 {
+				continue
+			}
+			switch block.Type {
+			case "resource":
+				addr := block.Labels[0] + "." + block.Labels[1]
+				targets[addr] = newRefTarget(prefix+addr, block.Body)
+			case "data":
+				addr := "data." + block.Labels[0] + "." + block.Labels[1]
+				targets[addr] = newRefTarget(prefix+addr, block.Body)
+			}
+		}
+	}
+	for _, file := range parsed.Files {
+		if body, ok := file.Body.(*hclsyntax.Body); ok {
+			collectRefs(body, targets)
+		}
+	}
+	return targets
+}
+
+// resourceRefs returns evaluation context variables that resolve references to
+// targets. Each referenced attribute becomes a placeholder naming the referenced
+// block instance and attribute, so rules can join resources the way ROS rules
+// follow Ref and Fn::GetAtt. Only the attributes that the configuration actually
+// references are bound. Blocks using count or for_each are expanded in ctx into
+// their instances, so computed references such as app[count.index] resolve too.
+func resourceRefs(targets map[string]*refTarget, ctx *hcl.EvalContext) map[string]cty.Value {
+	// Group referenced blocks by type (and "data" -> type) for the context.
+	resources := make(map[string]map[string]cty.Value)
+	data := make(map[string]map[string]cty.Value)
+	for local, target := range targets {
+		if !target.used {
+			continue
+		}
+		root, typ, name := splitLocalAddr(local)
+		group := resources
+		if root == "data" {
+			group = data
+		}
+		if group[typ] == nil {
+			group[typ] = make(map[string]cty.Value)
+		}
+		group[typ][name] = target.value(ctx)
+	}
+
+	vars := make(map[string]cty.Value)
+	for typ, names := range resources {
+		vars[typ] = cty.ObjectVal(names)
+	}
+	if len(data) > 0 {
+		types := make(map[string]cty.Value, len(data))
+		for typ, names := range data {
+			types[typ] = cty.ObjectVal(names)
+		}
+		vars["data"] = cty.ObjectVal(types)
+	}
+	return vars
+}
+
+func newRefTarget(address string, body *hclsyntax.Body) *refTarget {
+	return &refTarget{
+		address: address,
+		body:    body,
+		attrs:   make(map[string]bool),
+		maxIdx:  -1,
+		keys:    make(map[string]bool),
+	}
+}
+
+// splitLocalAddr splits "type.name" or "data.type.name" into its parts.
+func splitLocalAddr(local string) (root, typ, name string) {
+	parts := strings.SplitN(local, ".", 3)
+	if parts[0] == "data" {
+		return "data", parts[1], parts[2]
+	}
+	return "", parts[0], parts[1]
+}
+
+// collectRefs records the references to targets made by the attributes in body
+// and its nested blocks.
+func collectRefs(body *hclsyntax.Body, targets map[string]*refTarget) {
+	for _, attr := range body.Attributes {
+		for _, traversal := range attr.Expr.Variables() {
+			recordRef(traversal, targets)
+		}
+		hclsyntax.VisitAll(attr.Expr, func(node hclsyntax.Node) hcl.Diagnostics {
+			if traversal, ok := computedIndexRef(node); ok {
+				recordRef(traversal, targets)
+			}
+			return nil
+		})
+	}
+	for _, block := range body.Blocks {
+		collectRefs(block.Body, targets)
+	}
+}
+
+// computedIndexRef returns the traversal of a reference through a computed
+// instance key, such as alicloud_vswitch.app[count.index].id, whose attribute
+// Variables does not report. The key is left unknown.
+func computedIndexRef(node hclsyntax.Node) (hcl.Traversal, bool) {
+	rel, ok := node.(*hclsyntax.RelativeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	index, ok := rel.Source.(*hclsyntax.IndexExpr)
+	if !ok {
+		return nil, false
+	}
+	block, ok := index.Collection.(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	traversal := make(hcl.Traversal, 0, len(block.Traversal)+1+len(rel.Traversal))
+	traversal = append(traversal, block.Traversal...)
+	traversal = append(traversal, hcl.TraverseIndex{Key: cty.DynamicVal})
+	return append(traversal, rel.Traversal...), true
+}
+
+// recordRef records a traversal such as alicloud_vpc.main.id,
+// alicloud_vswitch.app[0].id, or data.alicloud_zones.default.zones.
+func recordRef(traversal hcl.Traversal, targets map[string]*refTarget) {
+	n := 2
+	if traversal.RootName() == "data" {
+		n = 3
+	}
+	if len(traversal) < n {
+		return
+	}
+	names := []string{traversal.RootName()}
+	for _, step := range traversal[1:n] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return
+		}
+		names = append(names, attr.Name)
+	}
+	local := strings.Join(names, ".")
+	rest := traversal[n:]
+
+	target, ok := targets[local]
+	if !ok {
+		return
+	}
+	target.used = true
+
+	if len(rest) > 0 {
+		if index, ok := rest[0].(hcl.TraverseIndex); ok {
+			target.recordKey(index.Key)
+			rest = rest[1:]
+		}
+	}
+	if len(rest) > 0 {
+		if attr, ok := rest[0].(hcl.TraverseAttr); ok {
+			target.attrs[attr.Name] = true
+		}
+	}
+}
+
+// recordKey records a literal instance key, a whole number or a string, used to
+// reference the block. Keys computed from count.index or each.key are not
+// literals; the block's own count or for_each gives its instances instead.
+func (t *refTarget) recordKey(key cty.Value) {
+	if !key.IsKnown() || key.IsNull() {
+		return
+	}
+	switch key.Type() {
+	case cty.Number:
+		if i, acc := key.AsBigFloat().Int64(); acc == big.Exact && i > t.maxIdx {
+			t.maxIdx = i
+		}
+	case cty.String:
+		t.keys[key.AsString()] = true
+	}
+}
+
+// value builds the context value for the block in ctx. A block using count is a
+// tuple and one using for_each an object of its instances, each carrying every
+// referenced attribute. If the count or for_each cannot be resolved, the literal
+// keys the block is referenced with stand for its instances, and without any the
+// block's value is unknown.
+func (t *refTarget) value(ctx *hcl.EvalContext) cty.Value {
+	_, hasCount := t.body.Attributes["count"]
+	_, hasForEach := t.body.Attributes["for_each"]
+	if !hasCount && !hasForEach {
+		return refInstance(t.address, t.attrs)
+	}
+
+	instances := expandInstances(t.body, ctx)
+	if len(instances) == 0 {
+		if hasCount {
+			return cty.EmptyTupleVal
+		}
+		return cty.EmptyObjectVal
+	}
+	if len(instances) > 1 || instances[0].key != "" {
+		if hasCount {
+			elems := make([]cty.Value, len(instances))
+			for i, inst := range instances {
+				elems[i] = refInstance(t.address+inst.key, t.attrs)
+			}
+			return cty.TupleVal(elems)
+		}
+		objs := make(map[string]cty.Value, len(instances))
+		for _, inst := range instances {
+			key := inst.ctx.Variables["each"].GetAttr("key").AsString()
+			objs[key] = refInstance(t.address+inst.key, t.attrs)
+		}
+		return cty.ObjectVal(objs)
+	}
+
+	switch {
+	case t.maxIdx >= 0:
+		elems := make([]cty.Value, t.maxIdx+1)
+		for i := range elems {
+			elems[i] = refInstance(fmt.Sprintf("%s[%d]", t.address, i), t.attrs)
+		}
+		return cty.TupleVal(elems)
+	case len(t.keys) > 0:
+		objs := make(map[string]cty.Value, len(t.keys))
+		for k := range t.keys {
+			objs[k] = refInstance(t.address+"["+quoteInstanceKey(k)+"]", t.attrs)
+		}
+		return cty.ObjectVal(objs)
+	}
+	return cty.DynamicVal
+}
+
+// refInstance builds the object standing for one block instance: each referenced
+// attribute is a placeholder, and __ref__ names the instance itself so a reference
+// to the whole block (e.g. in depends_on) is still identifiable.
+func refInstance(address string, attrs map[string]bool) cty.Value {
+	obj := map[string]cty.Value{refKey: cty.StringVal(address)}
+	for name := range attrs {
+		obj[name] = cty.ObjectVal(map[string]cty.Value{
+			refKey:     cty.StringVal(address),
+			refAttrKey: cty.StringVal(name),
+		})
+	}
+	return cty.ObjectVal(obj)
+}
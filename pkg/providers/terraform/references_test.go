@@ -0,0 +1,157 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEvaluateResourceReferences(t *testing.T) {
+	Convey("Given resources that reference each other", t, func() {
+		dir := t.TempDir()
+		modDir := filepath.Join(dir, "modules", "network")
+		So(os.MkdirAll(modDir, 0755), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`data "alicloud_zones" "default" {}
+
+resource "alicloud_vpc" "main" {
+  cidr_block = "10.0.0.0/8"
+}
+
+resource "alicloud_security_group" "web" {
+  count  = 2
+  vpc_id = alicloud_vpc.main.id
+}
+
+locals {
+  vpc_id = alicloud_vpc.main.id
+}
+
+resource "alicloud_vswitch" "app" {
+  vpc_id     = local.vpc_id
+  zone_id    = data.alicloud_zones.default.zones
+  depends_on = [alicloud_vpc.main]
+}
+
+resource "alicloud_instance" "web" {
+  vswitch_id      = module.network.vswitch_id
+  security_groups = [alicloud_security_group.web[0].id, alicloud_security_group.web[1].id]
+  name            = "web-${alicloud_vpc.main.id}"
+}
+
+module "network" {
+  source = "./modules/network"
+}
+`), 0644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(modDir, "main.tf"), []byte(`resource "alicloud_vswitch" "main" {}
+
+output "vswitch_id" {
+  value = alicloud_vswitch.main.id
+}
+`), 0644), ShouldBeNil)
+
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+
+		ref := func(address, attr string) map[string]interface{} {
+			return map[string]interface{}{"__ref__": address, "attr": attr}
+		}
+
+		Convey("Attribute references should become placeholders", func() {
+			So(result.Resources["alicloud_security_group"]["web[0]"]["vpc_id"], ShouldResemble, ref("alicloud_vpc.main", "id"))
+			So(result.Resources["alicloud_vswitch"]["app"]["vpc_id"], ShouldResemble, ref("alicloud_vpc.main", "id"))
+			So(result.Resources["alicloud_vswitch"]["app"]["zone_id"], ShouldResemble, ref("data.alicloud_zones.default", "zones"))
+		})
+
+		Convey("References to count instances should keep the instance key", func() {
+			So(result.Resources["alicloud_instance"]["web"]["security_groups"], ShouldResemble, []interface{}{
+				ref("alicloud_security_group.web[0]", "id"),
+				ref("alicloud_security_group.web[1]", "id"),
+			})
+		})
+
+		Convey("References through module outputs should carry the module address", func() {
+			So(result.Resources["alicloud_instance"]["web"]["vswitch_id"], ShouldResemble, ref("module.network.alicloud_vswitch.main", "id"))
+		})
+
+		Convey("A reference to a whole resource should name it", func() {
+			deps := result.Resources["alicloud_vswitch"]["app"]["depends_on"].([]interface{})
+			So(deps[0].(map[string]interface{})["__ref__"], ShouldEqual, "alicloud_vpc.main")
+		})
+
+		Convey("References inside string templates should stay unknown", func() {
+			So(result.Resources["alicloud_instance"]["web"]["name"], ShouldEqual, UnknownValue)
+		})
+	})
+}
+
+func TestEvaluateComputedIndexReferences(t *testing.T) {
+	Convey("Given references through count.index and each.key", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`locals {
+  zones = ["a", "b"]
+}
+
+resource "alicloud_vswitch" "app" {
+  count   = length(local.zones)
+  zone_id = local.zones[count.index]
+}
+
+resource "alicloud_instance" "app" {
+  count      = 2
+  vswitch_id = alicloud_vswitch.app[count.index].id
+}
+
+resource "alicloud_eip" "first" {
+  instance = alicloud_instance.app[0].id
+}
+
+resource "alicloud_eip_association" "app" {
+  count       = 2
+  instance_id = alicloud_instance.app[count.index].id
+}
+
+resource "alicloud_oss_bucket" "logs" {
+  for_each = toset(["audit", "access"])
+  bucket   = each.key
+}
+
+resource "alicloud_oss_bucket_acl" "logs" {
+  for_each = toset(["audit", "access"])
+  bucket   = alicloud_oss_bucket.logs[each.key].bucket
+  owner    = alicloud_oss_bucket.logs[each.key].id
+}
+`), 0644), ShouldBeNil)
+
+		parsed, diags := parseTFDir(dir)
+		So(diags.HasErrors(), ShouldBeFalse)
+		result, err := evaluate(parsed, map[string]interface{}{})
+		So(err, ShouldBeNil)
+
+		ref := func(address, attr string) map[string]interface{} {
+			return map[string]interface{}{"__ref__": address, "attr": attr}
+		}
+
+		Convey("count.index should select the instance of a count block", func() {
+			instances := result.Resources["alicloud_instance"]
+			So(instances["app[0]"]["vswitch_id"], ShouldResemble, ref("alicloud_vswitch.app[0]", "id"))
+			So(instances["app[1]"]["vswitch_id"], ShouldResemble, ref("alicloud_vswitch.app[1]", "id"))
+		})
+
+		Convey("A literal index should not limit the other instances", func() {
+			So(result.Resources["alicloud_eip"]["first"]["instance"], ShouldResemble, ref("alicloud_instance.app[0]", "id"))
+			associations := result.Resources["alicloud_eip_association"]
+			So(associations["app[0]"]["instance_id"], ShouldResemble, ref("alicloud_instance.app[0]", "id"))
+			So(associations["app[1]"]["instance_id"], ShouldResemble, ref("alicloud_instance.app[1]", "id"))
+		})
+
+		Convey("each.key should select the instance of a for_each block", func() {
+			acls := result.Resources["alicloud_oss_bucket_acl"]
+			So(acls[`logs["audit"]`]["bucket"], ShouldResemble, ref(`alicloud_oss_bucket.logs["audit"]`, "bucket"))
+			So(acls[`logs["access"]`]["owner"], ShouldResemble, ref(`alicloud_oss_bucket.logs["access"]`, "id"))
+		})
+	})
+}
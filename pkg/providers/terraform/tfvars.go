@@ -0,0 +1,154 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// envVarPrefix is the prefix of environment variables that set Terraform variables.
+const envVarPrefix = "TF_VAR_"
+
+// IsVarsFile reports whether path is a Terraform variable definitions file
+// (.tfvars or .tfvars.json).
+func IsVarsFile(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasSuffix(lower, ".tfvars") || strings.HasSuffix(lower, ".tfvars.json")
+}
+
+// ParseVarsFile reads a .tfvars (HCL) or .tfvars.json file into variable values.
+// Lists, maps, and objects keep their structure.
+func ParseVarsFile(path string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseVars(content, path)
+}
+
+func parseVars(content []byte, filename string) (map[string]interface{}, error) {
+	parser := hclparse.NewParser()
+	var f *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(strings.ToLower(filename), ".json") {
+		f, diags = parser.ParseJSON(content, filename)
+	} else {
+		f, diags = parser.ParseHCL(content, filename)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("tfvars parse error: %s", diags.Error())
+	}
+
+	attrs, diags := f.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("tfvars parse error: %s", diags.Error())
+	}
+
+	vars := make(map[string]interface{}, len(attrs))
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("tfvars parse error: %s", diags.Error())
+		}
+		vars[name] = ctyToGo(val)
+	}
+	return vars, nil
+}
+
+// autoVarsFiles returns the variable files Terraform loads automatically from
+// dir, in precedence order: terraform.tfvars, terraform.tfvars.json, then
+// *.auto.tfvars and *.auto.tfvars.json in lexical order.
+func autoVarsFiles(dir string) ([]string, error) {
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var auto []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json") {
+			auto = append(auto, name)
+		}
+	}
+	sort.Strings(auto)
+	for _, name := range auto {
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files, nil
+}
+
+// envVars returns the variable values set by TF_VAR_<name> environment variables.
+// Values that look like a list or map are parsed as HCL expressions; everything
+// else is taken as a literal string, as Terraform does for primitive types.
+func envVars(environ []string) map[string]interface{} {
+	vars := make(map[string]interface{})
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, envVarPrefix) {
+			continue
+		}
+		parts := strings.SplitN(kv[len(envVarPrefix):], "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		name, raw := parts[0], parts[1]
+		vars[name] = raw
+
+		trimmed := strings.TrimSpace(raw)
+		if !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "{") {
+			continue
+		}
+		expr, diags := hclsyntax.ParseExpression([]byte(trimmed), envVarPrefix+name, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+		if val, diags := expr.Value(nil); !diags.HasErrors() {
+			vars[name] = ctyToGo(val)
+		}
+	}
+	return vars
+}
+
+// resolveInputVars merges variable values in Terraform's precedence order, later
+// sources overriding earlier ones: the auto-loaded variable files in dir, TF_VAR_
+// variables of environ, then explicit inputs (--input).
+func resolveInputVars(dir string, inputVars map[string]interface{}, environ []string) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+
+	files, err := autoVarsFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		fileVars, err := ParseVarsFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	for k, v := range envVars(environ) {
+		vars[k] = v
+	}
+	for k, v := range inputVars {
+		vars[k] = v
+	}
+	return vars, nil
+}
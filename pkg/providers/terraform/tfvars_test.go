@@ -0,0 +1,94 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoadVariablePrecedence(t *testing.T) {
+	Convey("Given a Terraform directory with variable files", t, func() {
+		dir := t.TempDir()
+		write := func(name, content string) {
+			So(os.WriteFile(filepath.Join(dir, name), []byte(content), 0644), ShouldBeNil)
+		}
+		write("main.tf", `variable "env" {}
+variable "region" {}
+variable "zones" {}
+variable "tags" {}
+variable "size" {}
+variable "owner" {
+  default = "nobody"
+}
+
+resource "alicloud_instance" "web" {
+  env    = var.env
+  region = var.region
+  zone   = var.zones[1]
+  tags   = var.tags
+  size   = var.size
+  owner  = var.owner
+}
+`)
+		write("terraform.tfvars", `env    = "dev"
+region = "cn-hangzhou"
+zones  = ["a", "b"]
+tags   = { team = "infra" }
+size   = 1
+`)
+		write("terraform.tfvars.json", `{"region": "cn-beijing"}`)
+		write("b.auto.tfvars", `size = 3`)
+		write("a.auto.tfvars", `size = 2`)
+		env := []string{"TF_VAR_tags={ team = \"security\", tier = \"web\" }", "TF_VAR_env=staging", "HOME=/root"}
+
+		web := func(inputVars map[string]interface{}) map[string]interface{} {
+			input, err := LoadWithOptions(dir, inputVars, LoadOptions{Env: env})
+			So(err, ShouldBeNil)
+			resources := input["resources"].(map[string]interface{})
+			return resources["alicloud_instance"].(map[string]interface{})["web"].(map[string]interface{})
+		}
+
+		Convey("Files should load in order with lists and maps preserved", func() {
+			res := web(nil)
+			So(res["region"], ShouldEqual, "cn-beijing")
+			So(res["zone"], ShouldEqual, "b")
+			So(res["size"], ShouldEqual, float64(3))
+			So(res["owner"], ShouldEqual, "nobody")
+		})
+
+		Convey("TF_VAR_ environment variables should override files", func() {
+			res := web(nil)
+			So(res["env"], ShouldEqual, "staging")
+			So(res["tags"], ShouldResemble, map[string]interface{}{"team": "security", "tier": "web"})
+		})
+
+		Convey("Load should not read the process environment", func() {
+			t.Setenv("TF_VAR_env", "staging")
+			input, err := Load(dir, nil)
+			So(err, ShouldBeNil)
+			resources := input["resources"].(map[string]interface{})
+			res := resources["alicloud_instance"].(map[string]interface{})["web"].(map[string]interface{})
+			So(res["env"], ShouldEqual, "dev")
+		})
+
+		Convey("Explicit inputs should override everything", func() {
+			res := web(map[string]interface{}{"env": "prod", "size": float64(4)})
+			So(res["env"], ShouldEqual, "prod")
+			So(res["size"], ShouldEqual, float64(4))
+		})
+	})
+
+	Convey("Given an invalid .tfvars file", t, func() {
+		dir := t.TempDir()
+		So(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`variable "env" {}`), 0644), ShouldBeNil)
+		So(os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(`env = `), 0644), ShouldBeNil)
+
+		Convey("Load should report the parse error", func() {
+			_, err := Load(dir, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "terraform.tfvars")
+		})
+	})
+}
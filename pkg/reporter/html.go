@@ -30,6 +30,7 @@ type HTMLData struct {
 	Resource             string
 	Location             string
 	LineLabel            string
+	FingerprintLabel     string
 	Reason               string
 	Recommendation       string
 	TotalViolationsLabel string
@@ -222,6 +223,7 @@ func (r *Reporter) renderHTML(results []models.FileResult) error {
 		Resource:             msg.Report.Resource,
 		Location:             msg.Report.Location,
 		LineLabel:            msg.Report.Line,
+		FingerprintLabel:     msg.Report.Fingerprint,
 		Reason:               msg.Report.Reason,
 		Recommendation:       msg.Report.Recommendation,
 		TotalViolationsLabel: msg.Report.Total,
@@ -255,6 +257,7 @@ func generateI18nData(violationCount int) I18nData {
 			"resource":        msg.Report.Resource,
 			"location":        msg.Report.Location,
 			"line":            msg.Report.Line,
+			"fingerprint":     msg.Report.Fingerprint,
 			"recommendation":  msg.Report.Recommendation,
 			"noViolations":    msg.Scan.NoViolations,
 			"toc":             getTOCLabel(lang),
@@ -12,6 +12,7 @@ func (r *Reporter) renderJSON(results []models.FileResult) error {
 	// Calculate summary stats
 	totalViolations := 0
 	filesWithViolations := 0
+	timedOutRules := 0
 	severityCounts := map[string]int{
 		models.SeverityHigh:   0,
 		models.SeverityMedium: 0,
@@ -19,6 +20,7 @@ func (r *Reporter) renderJSON(results []models.FileResult) error {
 	}
 
 	for _, fileRes := range results {
+		timedOutRules += len(fileRes.TimedOutRules)
 		fileHasReal := false
 		for _, v := range fileRes.Violations {
 			// Suppressed (active-waived) violations are excluded from totals but
@@ -46,6 +48,14 @@ func (r *Reporter) renderJSON(results []models.FileResult) error {
 			FilesWithViolations: filesWithViolations,
 			WaivedCount:         waivedCount,
 			ExpiredWaiverCount:  expiredCount,
+			BaselineCount:       r.baselineCount(results),
+			TimedOutRuleCount:   timedOutRules,
+			FailOn:              r.failOn,
+			MinSeverity:         r.minSeverity,
+			ChangedSince:        r.changedSince,
+			ChangedLines:        r.changedLines,
+			Policies:            r.policies,
+			Paths:               r.paths,
 		},
 		Results: results,
 	}
@@ -116,6 +116,68 @@ func TestRenderJSON(t *testing.T) {
 			})
 		})
 
+		Convey("When a rule timed out", func() {
+			results := []models.FileResult{
+				{File: "test.yaml", TimedOutRules: []string{"rule:aliyun:slow"}},
+			}
+
+			var buf bytes.Buffer
+			So(New("json", &buf).Render(results), ShouldBeNil)
+
+			var report models.Report
+			So(json.Unmarshal(buf.Bytes(), &report), ShouldBeNil)
+
+			Convey("It should be listed per file and counted in the summary", func() {
+				So(report.Results[0].TimedOutRules, ShouldResemble, []string{"rule:aliyun:slow"})
+				So(report.Summary.TimedOutRuleCount, ShouldEqual, 1)
+				So(report.Summary.TotalViolations, ShouldEqual, 0)
+			})
+		})
+
+		Convey("When rendering with a minimum severity and fail threshold", func() {
+			results := []models.FileResult{
+				{
+					File:           "test.yaml",
+					EvaluatedRules: []string{"rule:a", "rule:b"},
+					Violations: []models.RichViolation{
+						{Severity: models.SeverityHigh, ID: "rule:a", ResourceID: "A"},
+						{Severity: models.SeverityLow, ID: "rule:b", ResourceID: "B"},
+					},
+				},
+			}
+
+			var buf bytes.Buffer
+			r := New("json", &buf, WithMinSeverity(models.SeverityMedium), WithFailOn(models.SeverityHigh),
+				WithChangedSince("origin/main", true), WithScope([]string{"pack:aliyun:p"}, []string{"/ws/stacks"}))
+			So(r.Render(results), ShouldBeNil)
+
+			var report models.Report
+			So(json.Unmarshal(buf.Bytes(), &report), ShouldBeNil)
+
+			Convey("It should omit violations below the minimum severity", func() {
+				So(report.Summary.TotalViolations, ShouldEqual, 1)
+				So(report.Summary.SeverityCounts[models.SeverityLow], ShouldEqual, 0)
+				So(len(report.Results[0].Violations), ShouldEqual, 1)
+				So(report.Results[0].Violations[0].ID, ShouldEqual, "rule:a")
+			})
+
+			Convey("It should record the thresholds in the summary", func() {
+				So(report.Summary.FailOn, ShouldEqual, models.SeverityHigh)
+				So(report.Summary.MinSeverity, ShouldEqual, models.SeverityMedium)
+				So(report.Summary.ChangedSince, ShouldEqual, "origin/main")
+				So(report.Summary.ChangedLines, ShouldBeTrue)
+			})
+
+			Convey("It should record the scan scope in the summary", func() {
+				So(report.Summary.Policies, ShouldResemble, []string{"pack:aliyun:p"})
+				So(report.Summary.Paths, ShouldResemble, []string{"/ws/stacks"})
+			})
+
+			Convey("It should leave the caller's results unchanged", func() {
+				So(len(results[0].Violations), ShouldEqual, 2)
+			})
+		})
+
 		Convey("When checking JSON indentation", func() {
 			violations := []models.RichViolation{
 				{Severity: "Low", ID: "LOW-001"},
@@ -0,0 +1,212 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Properties *junitProperties `xml:"properties"`
+	Failures   []junitFailure   `xml:"failure"`
+	Error      *junitError      `xml:"error"`
+	Skipped    *junitSkipped    `xml:"skipped"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitError struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// renderJUnit outputs results as JUnit XML. Each scanned file is a testsuite and
+// each evaluated rule a testcase; every unsuppressed violation of the rule adds a
+// <failure>, and its fingerprint a "fingerprint" property of the testcase.
+// A rule whose only violations are waived is reported as skipped, and
+// a rule whose evaluation timed out as an <error>.
+func (r *Reporter) renderJUnit(results []models.FileResult) error {
+	msg := i18n.Msg()
+
+	doc := junitTestSuites{
+		Name:   msg.Report.Title,
+		Suites: []junitTestSuite{},
+	}
+	for _, fr := range results {
+		suite := r.junitSuite(fr, msg)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Skipped += suite.Skipped
+		doc.Suites = append(doc.Suites, suite)
+	}
+
+	if _, err := fmt.Fprint(r.writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(r.writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(r.writer)
+	return err
+}
+
+// junitSuite builds the testsuite for a single file.
+func (r *Reporter) junitSuite(fr models.FileResult, msg *i18n.Messages) junitTestSuite {
+	byRule := make(map[string][]models.RichViolation)
+	for _, v := range fr.Violations {
+		byRule[v.ID] = append(byRule[v.ID], v)
+	}
+
+	// Evaluated rules plus any rule that reported a violation without declaring
+	// rule_meta, so no finding is lost.
+	seen := make(map[string]bool)
+	var ruleIDs []string
+	for _, id := range fr.EvaluatedRules {
+		if !seen[id] {
+			seen[id] = true
+			ruleIDs = append(ruleIDs, id)
+		}
+	}
+	for id := range byRule {
+		if !seen[id] {
+			seen[id] = true
+			ruleIDs = append(ruleIDs, id)
+		}
+	}
+	timedOut := make(map[string]bool)
+	for _, id := range fr.TimedOutRules {
+		timedOut[id] = true
+		if !seen[id] {
+			seen[id] = true
+			ruleIDs = append(ruleIDs, id)
+		}
+	}
+	sort.Strings(ruleIDs)
+
+	suite := junitTestSuite{Name: fr.File, Cases: []junitTestCase{}}
+	for _, id := range ruleIDs {
+		tc := junitTestCase{Name: id, ClassName: fr.File}
+		if timedOut[id] {
+			tc.Error = &junitError{Message: msg.Report.EvalTimedOut, Type: "timeout"}
+			suite.Errors++
+			suite.Cases = append(suite.Cases, tc)
+			continue
+		}
+		var waived []models.RichViolation
+		for _, v := range byRule[id] {
+			if v.IsSuppressed(r.failOnExpired) {
+				waived = append(waived, v)
+				continue
+			}
+			tc.Failures = append(tc.Failures, junitFailureFor(v, msg))
+			if v.Fingerprint != "" {
+				if tc.Properties == nil {
+					tc.Properties = &junitProperties{}
+				}
+				tc.Properties.Properties = append(tc.Properties.Properties, junitProperty{Name: "fingerprint", Value: v.Fingerprint})
+			}
+		}
+		if len(tc.Failures) > 0 {
+			suite.Failures++
+		} else if len(waived) > 0 {
+			if w := waived[0].Waiver; w != nil {
+				tc.Skipped = &junitSkipped{Message: fmt.Sprintf(msg.Report.Waived, w.Source, w.Reason)}
+			} else {
+				tc.Skipped = &junitSkipped{Message: msg.Report.InBaseline}
+			}
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	return suite
+}
+
+// junitFailureFor converts a violation into a <failure> carrying the reason,
+// location, recommendation and source snippet.
+func junitFailureFor(v models.RichViolation, msg *i18n.Messages) junitFailure {
+	var b strings.Builder
+	if v.Reason != "" {
+		b.WriteString(v.Reason + "\n")
+	}
+	if v.ResourceID != "" {
+		fmt.Fprintf(&b, "%s: %s\n", msg.Report.Resource, v.ResourceID)
+	}
+	if v.File != "" {
+		fmt.Fprintf(&b, "%s: %s\n", msg.Report.Location, fmt.Sprintf(msg.Report.LocationFormat, v.File, v.Line))
+	}
+	if v.Recommendation != "" {
+		fmt.Fprintf(&b, "%s: %s\n", msg.Report.Recommendation, v.Recommendation)
+	}
+
+	snippetLines := v.SnippetLines
+	if len(snippetLines) == 0 && v.Snippet != "" {
+		snippetLines = []models.SnippetLine{{LineNum: v.Line, Content: v.Snippet, Highlight: true}}
+	}
+	if len(snippetLines) > 0 {
+		b.WriteString("\n")
+		for _, line := range snippetLines {
+			prefix := msg.Report.LineNormalPrefix
+			if line.Highlight {
+				prefix = msg.Report.LineHighlightPrefix
+			}
+			fmt.Fprintf(&b, prefix+" %s\n", line.LineNum, line.Content)
+		}
+	}
+
+	message := v.Reason
+	if message == "" {
+		message = v.ID
+	}
+	return junitFailure{
+		Message: message,
+		Type:    strings.ToLower(v.Severity),
+		Text:    b.String(),
+	}
+}
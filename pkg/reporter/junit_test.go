@@ -0,0 +1,158 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/aliyun/infraguard/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	Convey("Given the JUnit renderer", t, func() {
+		Convey("When rendering evaluated rules with violations", func() {
+			results := []models.FileResult{
+				{
+					File:           "b.yaml",
+					EvaluatedRules: []string{"rule:aliyun:a", "rule:aliyun:b", "rule:aliyun:c"},
+					Violations: []models.RichViolation{
+						{
+							Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "Web", File: "b.yaml", Line: 10,
+							Reason: "Public IP is allocated", Recommendation: "Disable it", Fingerprint: "fp-web",
+							SnippetLines: []models.SnippetLine{
+								{LineNum: 9, Content: "Properties:"},
+								{LineNum: 10, Content: "AllocatePublicIP: true", Highlight: true},
+							},
+						},
+						{Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "Db", File: "b.yaml", Line: 20, Reason: "Second", Fingerprint: "fp-db"},
+						{
+							Severity: models.SeverityLow, ID: "rule:aliyun:c", ResourceID: "Bucket", File: "b.yaml", Line: 30,
+							Waiver: &models.WaiverInfo{Status: models.WaiverStatusActive, Source: "inline", Reason: "legacy"},
+						},
+					},
+				},
+				{File: "a.yaml", EvaluatedRules: []string{"rule:aliyun:a"}},
+			}
+
+			var buf bytes.Buffer
+			r := New("junit", &buf)
+			So(r.Render(results), ShouldBeNil)
+
+			var doc junitTestSuites
+			So(xml.Unmarshal(buf.Bytes(), &doc), ShouldBeNil)
+
+			Convey("It should emit one testsuite per file", func() {
+				So(strings.HasPrefix(buf.String(), "<?xml"), ShouldBeTrue)
+				So(len(doc.Suites), ShouldEqual, 2)
+				So(doc.Suites[0].Name, ShouldEqual, "a.yaml")
+				So(doc.Suites[1].Name, ShouldEqual, "b.yaml")
+				So(doc.Tests, ShouldEqual, 4)
+				So(doc.Failures, ShouldEqual, 1)
+				So(doc.Skipped, ShouldEqual, 1)
+			})
+
+			Convey("It should report passing rules as green testcases", func() {
+				So(len(doc.Suites[0].Cases), ShouldEqual, 1)
+				So(doc.Suites[0].Cases[0].Failures, ShouldBeEmpty)
+				So(doc.Suites[0].Cases[0].Skipped, ShouldBeNil)
+				So(doc.Suites[1].Cases[1].Name, ShouldEqual, "rule:aliyun:b")
+				So(doc.Suites[1].Cases[1].Failures, ShouldBeEmpty)
+			})
+
+			Convey("It should add a failure per violation", func() {
+				tc := doc.Suites[1].Cases[0]
+				So(tc.Name, ShouldEqual, "rule:aliyun:a")
+				So(tc.ClassName, ShouldEqual, "b.yaml")
+				So(len(tc.Failures), ShouldEqual, 2)
+				So(tc.Failures[0].Message, ShouldEqual, "Public IP is allocated")
+				So(tc.Failures[0].Type, ShouldEqual, "high")
+				So(tc.Failures[0].Text, ShouldContainSubstring, "Disable it")
+				So(tc.Failures[0].Text, ShouldContainSubstring, "b.yaml:10")
+				So(tc.Failures[0].Text, ShouldContainSubstring, "AllocatePublicIP: true")
+			})
+
+			Convey("It should list the fingerprints of the failures as properties", func() {
+				tc := doc.Suites[1].Cases[0]
+				So(tc.Properties, ShouldNotBeNil)
+				So(tc.Properties.Properties, ShouldResemble, []junitProperty{
+					{Name: "fingerprint", Value: "fp-web"},
+					{Name: "fingerprint", Value: "fp-db"},
+				})
+				So(doc.Suites[0].Cases[0].Properties, ShouldBeNil)
+			})
+
+			Convey("It should skip rules whose violations are all waived", func() {
+				tc := doc.Suites[1].Cases[2]
+				So(tc.Name, ShouldEqual, "rule:aliyun:c")
+				So(tc.Failures, ShouldBeEmpty)
+				So(tc.Skipped, ShouldNotBeNil)
+				So(tc.Skipped.Message, ShouldContainSubstring, "legacy")
+			})
+		})
+
+		Convey("When a violation comes from a rule that was not listed as evaluated", func() {
+			results := []models.FileResult{{
+				File:       "t.yaml",
+				Violations: []models.RichViolation{{Severity: models.SeverityMedium, ID: "custom-rule", File: "t.yaml", Line: 1}},
+			}}
+
+			var buf bytes.Buffer
+			So(New("junit", &buf).Render(results), ShouldBeNil)
+
+			var doc junitTestSuites
+			So(xml.Unmarshal(buf.Bytes(), &doc), ShouldBeNil)
+
+			Convey("It should still appear as a failing testcase", func() {
+				So(len(doc.Suites[0].Cases), ShouldEqual, 1)
+				So(doc.Suites[0].Cases[0].Failures[0].Message, ShouldEqual, "custom-rule")
+			})
+		})
+
+		Convey("When a rule timed out", func() {
+			results := []models.FileResult{{
+				File:           "t.yaml",
+				EvaluatedRules: []string{"rule:aliyun:a"},
+				TimedOutRules:  []string{"rule:aliyun:slow"},
+			}}
+
+			var buf bytes.Buffer
+			So(New("junit", &buf).Render(results), ShouldBeNil)
+
+			var doc junitTestSuites
+			So(xml.Unmarshal(buf.Bytes(), &doc), ShouldBeNil)
+
+			Convey("It should be an errored testcase, not a failure", func() {
+				So(doc.Errors, ShouldEqual, 1)
+				So(doc.Failures, ShouldEqual, 0)
+				tc := doc.Suites[0].Cases[1]
+				So(tc.Name, ShouldEqual, "rule:aliyun:slow")
+				So(tc.Error, ShouldNotBeNil)
+				So(tc.Error.Type, ShouldEqual, "timeout")
+			})
+		})
+
+		Convey("When a violation is in the baseline", func() {
+			results := []models.FileResult{{
+				File:           "t.yaml",
+				EvaluatedRules: []string{"rule:aliyun:a"},
+				Violations: []models.RichViolation{
+					{Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "Web", File: "t.yaml", Baseline: true},
+				},
+			}}
+
+			var buf bytes.Buffer
+			So(New("junit", &buf).Render(results), ShouldBeNil)
+
+			var doc junitTestSuites
+			So(xml.Unmarshal(buf.Bytes(), &doc), ShouldBeNil)
+
+			Convey("It should be a skipped testcase", func() {
+				So(doc.Failures, ShouldEqual, 0)
+				So(doc.Skipped, ShouldEqual, 1)
+				So(doc.Suites[0].Cases[0].Skipped, ShouldNotBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,245 @@
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+)
+
+// DefaultMarkdownMaxSize is the default size limit of a markdown report in
+// bytes, below the 65536-character limit of a GitHub comment.
+const DefaultMarkdownMaxSize = 60000
+
+// markdownNoteReserve is the room kept below the size limit for the
+// truncation note.
+const markdownNoteReserve = 512
+
+// markdownEscaper escapes the characters that markdown would otherwise
+// interpret in free text such as violation reasons.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "|", `\|`,
+)
+
+// renderMarkdown outputs results as GitHub-flavored markdown for pull-request
+// and merge-request comments: a collapsible summary table by severity,
+// followed by one section per file. Suppressed violations are counted in the
+// summary and only listed with --show-waived. Once the report would exceed the
+// size limit, the remaining violations are left out and a note says so.
+func (r *Reporter) renderMarkdown(results []models.FileResult) error {
+	msg := i18n.Msg()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", msg.Report.Title)
+
+	counts := make(map[string]int)
+	waivedCounts := make(map[string]int)
+	total, shown := 0, 0
+	for _, fr := range results {
+		for _, v := range fr.Violations {
+			severity := strings.ToLower(v.Severity)
+			suppressed := v.IsSuppressed(r.failOnExpired)
+			switch {
+			case !suppressed:
+				counts[severity]++
+				total++
+			case v.Waiver != nil:
+				waivedCounts[severity]++
+			}
+			if !suppressed || r.showWaived {
+				shown++
+			}
+		}
+	}
+
+	if total == 0 {
+		fmt.Fprintf(&b, "%s%s\n\n", msg.Report.NoViolationsPrefix, msg.Scan.NoViolations)
+	} else {
+		r.writeMarkdownSummary(&b, counts, waivedCounts, total, msg)
+	}
+	r.writeMarkdownNotes(&b, results)
+
+	limit := r.markdownMax
+	written := 0
+	for _, fr := range results {
+		header := false
+		for _, v := range fr.Violations {
+			if v.IsSuppressed(r.failOnExpired) && !r.showWaived {
+				continue
+			}
+			var section strings.Builder
+			if !header {
+				fmt.Fprintf(&section, "### %s\n\n", markdownCode(markdownDisplayPath(fr.File)))
+			}
+			writeMarkdownViolation(&section, v, msg)
+			if limit > 0 && b.Len()+section.Len() > limit-markdownNoteReserve {
+				fmt.Fprintf(&b, "---\n\n> ⚠️ %s\n", fmt.Sprintf(msg.Report.MarkdownTruncated, written, shown))
+				_, err := fmt.Fprint(r.writer, b.String())
+				return err
+			}
+			b.WriteString(section.String())
+			header = true
+			written++
+		}
+	}
+
+	_, err := fmt.Fprint(r.writer, b.String())
+	return err
+}
+
+// writeMarkdownSummary writes the collapsible table of violation and waiver
+// counts by severity.
+func (r *Reporter) writeMarkdownSummary(b *strings.Builder, counts, waivedCounts map[string]int, total int, msg *i18n.Messages) {
+	fmt.Fprintf(b, "<details>\n<summary><b>%s</b></summary>\n\n", fmt.Sprintf(msg.Report.TotalViolations, total))
+	fmt.Fprintf(b, "| %s | %s | %s |\n| --- | ---: | ---: |\n", msg.Report.Severity, msg.Report.Count, msg.Report.MarkdownWaived)
+	waived := 0
+	for _, severity := range models.SeverityLevels() {
+		fmt.Fprintf(b, "| %s %s | %d | %d |\n", getSeverityIcon(severity), getSeverityLabel(severity, msg), counts[severity], waivedCounts[severity])
+		waived += waivedCounts[severity]
+	}
+	fmt.Fprintf(b, "| **%s** | **%d** | **%d** |\n\n</details>\n\n", msg.Report.Total, total, waived)
+}
+
+// writeMarkdownNotes writes the waiver and baseline notes, if any.
+func (r *Reporter) writeMarkdownNotes(b *strings.Builder, results []models.FileResult) {
+	msg := i18n.Msg()
+	var notes []string
+	if waived, expired := r.waiverCounts(results); waived > 0 || expired > 0 {
+		note := fmt.Sprintf(msg.Report.WaiverSummaryActive, waived)
+		if expired > 0 {
+			note += fmt.Sprintf(msg.Report.WaiverSummaryExpired, expired)
+		}
+		notes = append(notes, note)
+	}
+	if count := r.baselineCount(results); count > 0 {
+		notes = append(notes, fmt.Sprintf(msg.Report.BaselineSummary, count))
+	}
+	for _, note := range notes {
+		fmt.Fprintf(b, "%s  \n", note)
+	}
+	if len(notes) > 0 {
+		b.WriteString("\n")
+	}
+}
+
+// writeMarkdownViolation writes one violation with its metadata and a fenced
+// code snippet.
+func writeMarkdownViolation(b *strings.Builder, v models.RichViolation, msg *i18n.Messages) {
+	reason := v.Reason
+	if reason == "" {
+		reason = v.ID
+	}
+	fmt.Fprintf(b, "#### %s %s: %s\n\n", getSeverityIcon(v.Severity), getSeverityLabel(v.Severity, msg), markdownEscaper.Replace(reason))
+
+	if v.Waiver != nil {
+		var note string
+		if v.Waiver.Status == models.WaiverStatusExpired {
+			note = fmt.Sprintf(msg.Report.WaiverExpired, v.Waiver.Expires, v.Waiver.Source, v.Waiver.Reason)
+		} else {
+			note = fmt.Sprintf(msg.Report.Waived, v.Waiver.Source, v.Waiver.Reason)
+			if v.Waiver.Expires != "" {
+				note += fmt.Sprintf(msg.Report.WaivedExpires, v.Waiver.Expires)
+			}
+		}
+		fmt.Fprintf(b, "> %s\n\n", markdownEscaper.Replace(note))
+	}
+	if v.Baseline {
+		fmt.Fprintf(b, "> %s\n\n", msg.Report.InBaseline)
+	}
+
+	fmt.Fprintf(b, "- **%s**: %s\n", msg.Report.RuleID, markdownCode(v.ID))
+	if v.ResourceID != "" {
+		fmt.Fprintf(b, "- **%s**: %s\n", msg.Report.Resource, markdownCode(v.ResourceID))
+	}
+	if v.File != "" {
+		location := fmt.Sprintf(msg.Report.LocationFormat, markdownDisplayPath(v.File), v.Line)
+		fmt.Fprintf(b, "- **%s**: %s\n", msg.Report.Location, markdownCode(location))
+	}
+	if v.Recommendation != "" {
+		fmt.Fprintf(b, "- **%s**: %s\n", msg.Report.Recommendation, markdownEscaper.Replace(v.Recommendation))
+	}
+	b.WriteString("\n")
+
+	snippetLines := v.SnippetLines
+	if len(snippetLines) == 0 && v.Snippet != "" {
+		snippetLines = []models.SnippetLine{{LineNum: v.Line, Content: v.Snippet, Highlight: true}}
+	}
+	if len(snippetLines) == 0 {
+		return
+	}
+	lines := make([]string, len(snippetLines))
+	for i, line := range snippetLines {
+		prefix := msg.Report.LineNormalPrefix
+		if line.Highlight {
+			prefix = msg.Report.LineHighlightPrefix
+		}
+		lines[i] = fmt.Sprintf(prefix+" %s", line.LineNum, line.Content)
+	}
+	fence := markdownFence(lines)
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, snippetLanguage(v.File), strings.Join(lines, "\n"), fence)
+}
+
+// markdownDisplayPath returns file relative to the working directory, as in
+// the table output.
+func markdownDisplayPath(file string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, file); err == nil {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// markdownCode formats s as inline code, using a longer delimiter when s
+// itself contains backticks.
+func markdownCode(s string) string {
+	if !strings.Contains(s, "`") {
+		return "`" + s + "`"
+	}
+	delim := strings.Repeat("`", longestRun(s, '`')+1)
+	return delim + " " + s + " " + delim
+}
+
+// markdownFence returns a code fence longer than any run of backticks in lines.
+func markdownFence(lines []string) string {
+	n := 3
+	for _, line := range lines {
+		if run := longestRun(line, '`'); run >= n {
+			n = run + 1
+		}
+	}
+	return strings.Repeat("`", n)
+}
+
+// longestRun returns the length of the longest run of c in s.
+func longestRun(s string, c byte) int {
+	longest, run := 0, 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// snippetLanguage returns the code-fence language for a template file.
+func snippetLanguage(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".tf":
+		return "hcl"
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package reporter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func markdownResults() []models.FileResult {
+	return []models.FileResult{
+		{
+			File: "b.tf",
+			Violations: []models.RichViolation{
+				{
+					Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "alicloud_instance.web", File: "b.tf", Line: 10,
+					Reason: "Public IP is *allocated*", Recommendation: "Disable it",
+					SnippetLines: []models.SnippetLine{
+						{LineNum: 9, Content: "resource \"alicloud_instance\" \"web\" {"},
+						{LineNum: 10, Content: "internet_max_bandwidth_out = 10", Highlight: true},
+					},
+				},
+				{
+					Severity: models.SeverityLow, ID: "rule:aliyun:c", ResourceID: "Bucket", File: "b.tf", Line: 30,
+					Waiver: &models.WaiverInfo{Status: models.WaiverStatusActive, Source: "inline", Reason: "legacy"},
+				},
+			},
+		},
+		{
+			File: "a.yaml",
+			Violations: []models.RichViolation{
+				{Severity: models.SeverityMedium, ID: "rule:aliyun:b", ResourceID: "Db", File: "a.yaml", Line: 5, Reason: "No backup", Snippet: "Backup: false"},
+			},
+		},
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	Convey("Given the markdown renderer", t, func() {
+		i18n.SetLanguage("en")
+
+		Convey("When rendering violations", func() {
+			var buf bytes.Buffer
+			So(New("markdown", &buf).Render(markdownResults()), ShouldBeNil)
+			output := buf.String()
+
+			Convey("It should show a collapsible summary table by severity", func() {
+				So(output, ShouldStartWith, "## InfraGuard Compliance Report\n")
+				So(output, ShouldContainSubstring, "<details>\n<summary><b>Total violations: 2</b></summary>")
+				So(output, ShouldContainSubstring, "| Severity | Count | Waived |")
+				So(output, ShouldContainSubstring, "| 🔴 High | 1 | 0 |")
+				So(output, ShouldContainSubstring, "| 🔵 Low | 0 | 1 |")
+				So(output, ShouldContainSubstring, "| **Total** | **2** | **1** |")
+				So(output, ShouldContainSubstring, "Waivers: 1 active")
+			})
+
+			Convey("It should render one section per file in path order", func() {
+				a := strings.Index(output, "### `a.yaml`")
+				b := strings.Index(output, "### `b.tf`")
+				So(a, ShouldBeGreaterThan, 0)
+				So(b, ShouldBeGreaterThan, a)
+			})
+
+			Convey("It should fence snippets with the template language", func() {
+				So(output, ShouldContainSubstring, "```hcl\n     9 resource \"alicloud_instance\" \"web\" {\n>   10 internet_max_bandwidth_out = 10\n```")
+				So(output, ShouldContainSubstring, "```yaml\n>    5 Backup: false\n```")
+			})
+
+			Convey("It should escape markdown in reasons", func() {
+				So(output, ShouldContainSubstring, `#### 🔴 High: Public IP is \*allocated\*`)
+				So(output, ShouldContainSubstring, "- **Resolution**: Disable it")
+			})
+
+			Convey("It should hide waived violations", func() {
+				So(output, ShouldNotContainSubstring, "rule:aliyun:c")
+			})
+		})
+
+		Convey("When rendering waived violations", func() {
+			var buf bytes.Buffer
+			So(New("markdown", &buf, WithShowWaived(true)).Render(markdownResults()), ShouldBeNil)
+
+			Convey("It should list them with the waiver", func() {
+				So(buf.String(), ShouldContainSubstring, "`rule:aliyun:c`")
+				So(buf.String(), ShouldContainSubstring, "> ⊘ Waived (inline): legacy")
+			})
+		})
+
+		Convey("When the report exceeds the size limit", func() {
+			var results []models.FileResult
+			for i := 0; i < 50; i++ {
+				file := fmt.Sprintf("t%02d.yaml", i)
+				results = append(results, models.FileResult{File: file, Violations: []models.RichViolation{
+					{Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "Web", File: file, Line: 1, Reason: strings.Repeat("x", 100)},
+				}})
+			}
+
+			var buf bytes.Buffer
+			So(New("markdown", &buf, WithMarkdownMaxSize(3000)).Render(results), ShouldBeNil)
+			output := buf.String()
+
+			Convey("It should truncate within the limit and say so", func() {
+				So(len(output), ShouldBeLessThanOrEqualTo, 3000)
+				So(output, ShouldContainSubstring, "Report truncated to fit the size limit")
+				So(output, ShouldContainSubstring, "of 50 violations")
+				So(output, ShouldContainSubstring, "### `t00.yaml`")
+				So(output, ShouldNotContainSubstring, "### `t49.yaml`")
+			})
+
+			Convey("It should not truncate without a limit", func() {
+				var full bytes.Buffer
+				So(New("markdown", &full, WithMarkdownMaxSize(0)).Render(results), ShouldBeNil)
+				So(full.String(), ShouldContainSubstring, "### `t49.yaml`")
+				So(full.String(), ShouldNotContainSubstring, "Report truncated")
+			})
+		})
+
+		Convey("When rendering no violations", func() {
+			var buf bytes.Buffer
+			So(New("markdown", &buf).Render(nil), ShouldBeNil)
+
+			Convey("It should say so without a summary table", func() {
+				So(buf.String(), ShouldContainSubstring, "No violations found.")
+				So(buf.String(), ShouldNotContainSubstring, "<details>")
+			})
+		})
+
+		Convey("When rendering in Chinese", func() {
+			i18n.SetLanguage("zh")
+			defer i18n.SetLanguage("en")
+
+			var buf bytes.Buffer
+			So(New("markdown", &buf).Render(markdownResults()), ShouldBeNil)
+
+			Convey("It should localize the report", func() {
+				So(buf.String(), ShouldContainSubstring, "已豁免")
+				So(buf.String(), ShouldNotContainSubstring, "Waived")
+			})
+		})
+	})
+}
+
+func TestMarkdownFence(t *testing.T) {
+	Convey("Given snippet lines", t, func() {
+		Convey("It should use a fence longer than any backtick run", func() {
+			So(markdownFence([]string{"plain"}), ShouldEqual, "```")
+			So(markdownFence([]string{"a ```` b"}), ShouldEqual, "`````")
+			So(markdownCode("a`b"), ShouldEqual, "`` a`b ``")
+		})
+	})
+}
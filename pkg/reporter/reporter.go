@@ -12,8 +12,17 @@ import (
 type Reporter struct {
 	format        string
 	writer        io.Writer
-	showWaived    bool // Render violations suppressed by an active waiver
-	failOnExpired bool // Treat expired waivers as real violations
+	showWaived    bool                    // Render violations suppressed by an active waiver
+	failOnExpired bool                    // Treat expired waivers as real violations
+	rules         map[string]*models.Rule // Rule metadata by ID, for formats that describe rules
+	toolVersion   string                  // InfraGuard version reported by machine-readable formats
+	minSeverity   string                  // Lowest severity rendered; empty renders all
+	failOn        string                  // Fail threshold recorded in the JSON summary
+	changedSince  string                  // Git ref of an incremental scan, recorded in the JSON summary
+	changedLines  bool                    // Whether an incremental scan reported changed lines only
+	policies      []string                // Policy specifications, recorded in the JSON summary
+	paths         []string                // Scanned paths, recorded in the JSON summary
+	markdownMax   int                     // Size limit of the markdown report in bytes; 0 disables it
 }
 
 // Option configures a Reporter.
@@ -25,12 +34,52 @@ func WithShowWaived(v bool) Option { return func(r *Reporter) { r.showWaived = v
 // WithFailOnExpired controls whether expired waivers count as real violations.
 func WithFailOnExpired(v bool) Option { return func(r *Reporter) { r.failOnExpired = v } }
 
+// WithRules supplies rule metadata (name, description, recommendation) used by
+// formats that describe the rules behind each finding, such as SARIF.
+func WithRules(rules []*models.Rule) Option {
+	return func(r *Reporter) {
+		r.rules = make(map[string]*models.Rule, len(rules))
+		for _, rule := range rules {
+			if rule != nil {
+				r.rules[rule.ID] = rule
+			}
+		}
+	}
+}
+
+// WithToolVersion sets the InfraGuard version reported by machine-readable formats.
+func WithToolVersion(v string) Option { return func(r *Reporter) { r.toolVersion = v } }
+
+// WithMinSeverity hides violations less severe than the given severity.
+func WithMinSeverity(severity string) Option { return func(r *Reporter) { r.minSeverity = severity } }
+
+// WithFailOn sets the fail threshold recorded in the JSON summary.
+func WithFailOn(severity string) Option { return func(r *Reporter) { r.failOn = severity } }
+
+// WithChangedSince records in the JSON summary that the scan only covered
+// changes since the given git ref, and with lines, only changed lines.
+func WithChangedSince(ref string, lines bool) Option {
+	return func(r *Reporter) { r.changedSince, r.changedLines = ref, lines }
+}
+
+// WithScope records in the JSON summary the policy specifications the scan
+// evaluated and the paths it scanned.
+func WithScope(policies, paths []string) Option {
+	return func(r *Reporter) { r.policies, r.paths = policies, paths }
+}
+
+// WithMarkdownMaxSize sets the size limit of the markdown report in bytes.
+// Longer reports are truncated with a note; 0 disables the limit.
+func WithMarkdownMaxSize(n int) Option { return func(r *Reporter) { r.markdownMax = n } }
+
 // New creates a new Reporter.
 func New(format string, writer io.Writer, opts ...Option) *Reporter {
 	r := &Reporter{
 		format:        format,
 		writer:        writer,
 		failOnExpired: true,
+		failOn:        models.SeverityLow,
+		markdownMax:   DefaultMarkdownMaxSize,
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -56,8 +105,58 @@ func (r *Reporter) waiverCounts(results []models.FileResult) (waived, expired in
 	return waived, expired
 }
 
+// filterBySeverity returns copies of the results without the violations less
+// severe than minSeverity. Rules whose violations were all dropped are removed
+// from EvaluatedRules, so they are not reported as passing.
+func filterBySeverity(results []models.FileResult, minSeverity string) []models.FileResult {
+	filtered := make([]models.FileResult, len(results))
+	for i, fr := range results {
+		hidden := make(map[string]bool)
+		violations := make([]models.RichViolation, 0, len(fr.Violations))
+		for _, v := range fr.Violations {
+			if models.SeverityAtLeast(v.Severity, minSeverity) {
+				violations = append(violations, v)
+			} else {
+				hidden[v.ID] = true
+			}
+		}
+		for _, v := range violations {
+			delete(hidden, v.ID)
+		}
+		fr.Violations = violations
+		if len(hidden) > 0 {
+			rules := make([]string, 0, len(fr.EvaluatedRules))
+			for _, id := range fr.EvaluatedRules {
+				if !hidden[id] {
+					rules = append(rules, id)
+				}
+			}
+			fr.EvaluatedRules = rules
+		}
+		filtered[i] = fr
+	}
+	return filtered
+}
+
+// baselineCount returns the number of violations suppressed by the baseline.
+func (r *Reporter) baselineCount(results []models.FileResult) int {
+	count := 0
+	for _, fr := range results {
+		for _, v := range fr.Violations {
+			if v.Baseline {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 // Render outputs the violations in the specified format.
 func (r *Reporter) Render(results []models.FileResult) error {
+	if r.minSeverity != "" {
+		results = filterBySeverity(results, r.minSeverity)
+	}
+
 	// Sort results by file path
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].File < results[j].File
@@ -75,6 +174,12 @@ func (r *Reporter) Render(results []models.FileResult) error {
 		return r.renderJSON(results)
 	case "html":
 		return r.renderHTML(results)
+	case "sarif":
+		return r.renderSARIF(results)
+	case "junit":
+		return r.renderJUnit(results)
+	case "markdown":
+		return r.renderMarkdown(results)
 	default:
 		return r.renderTable(results)
 	}
@@ -0,0 +1,365 @@
+package reporter
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/models"
+)
+
+// SARIF constants for the 2.1.0 log format.
+const (
+	sarifVersion    = "2.1.0"
+	sarifSchema     = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName   = "InfraGuard"
+	sarifToolURI    = "https://github.com/aliyun/infraguard"
+	sarifSrcRootKey = "%SRCROOT%"
+)
+
+// sarifLog is the top-level SARIF document.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                        `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLocation `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                    `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []sarifReportingDescr `json:"rules"`
+}
+
+type sarifReportingDescr struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name,omitempty"`
+	ShortDescription     *sarifMessage          `json:"shortDescription,omitempty"`
+	FullDescription      *sarifMessage          `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage          `json:"help,omitempty"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints,omitempty"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	BaselineState       string                 `json:"baselineState,omitempty"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+// sarifFingerprintKey names the violation fingerprint in partialFingerprints,
+// so code-scanning dashboards track a finding across line moves.
+const sarifFingerprintKey = "infraguard/v1"
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+	ContextRegion    *sarifRegion          `json:"contextRegion,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int           `json:"startLine"`
+	EndLine   int           `json:"endLine,omitempty"`
+	Snippet   *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string                 `json:"kind"`
+	Status        string                 `json:"status"`
+	Justification string                 `json:"justification,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+}
+
+// renderSARIF outputs violations as a SARIF 2.1.0 log. Waived violations are
+// kept and carry a suppression so code-scanning dashboards can show them as
+// dismissed instead of losing them.
+func (r *Reporter) renderSARIF(results []models.FileResult) error {
+	lang := i18n.GetLanguage()
+	cwd, _ := os.Getwd()
+
+	ruleIndex := make(map[string]int)
+	var descriptors []sarifReportingDescr
+	var sarifResults []sarifResult
+
+	// Collect rule IDs first so the rules array is sorted and stable.
+	var ruleIDs []string
+	firstByRule := make(map[string]models.RichViolation)
+	for _, fr := range results {
+		for _, v := range fr.Violations {
+			if _, ok := firstByRule[v.ID]; !ok {
+				firstByRule[v.ID] = v
+				ruleIDs = append(ruleIDs, v.ID)
+			}
+		}
+	}
+	sort.Strings(ruleIDs)
+	for i, id := range ruleIDs {
+		ruleIndex[id] = i
+		descriptors = append(descriptors, r.toSARIFRule(id, firstByRule[id], lang))
+	}
+
+	for _, fr := range results {
+		for _, v := range fr.Violations {
+			sarifResults = append(sarifResults, r.toSARIFResult(v, ruleIndex[v.ID], cwd))
+		}
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           sarifToolName,
+			Version:        strings.TrimPrefix(r.toolVersion, "v"),
+			InformationURI: sarifToolURI,
+			Rules:          descriptors,
+		}},
+		Results: sarifResults,
+	}
+	if cwd != "" {
+		run.OriginalURIBaseIDs = map[string]sarifArtifactLocation{
+			sarifSrcRootKey: {URI: fileURI(cwd) + "/"},
+		}
+	}
+	// Keep empty arrays as [] rather than null; some SARIF consumers reject null.
+	if run.Tool.Driver.Rules == nil {
+		run.Tool.Driver.Rules = []sarifReportingDescr{}
+	}
+	if run.Results == nil {
+		run.Results = []sarifResult{}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(r.writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// toSARIFRule builds the reportingDescriptor for a rule, preferring metadata from
+// the policy index and falling back to what the violation itself carries.
+func (r *Reporter) toSARIFRule(id string, sample models.RichViolation, lang string) sarifReportingDescr {
+	desc := sarifReportingDescr{
+		ID:                   id,
+		Name:                 extractShortID(id),
+		DefaultConfiguration: sarifRuleConfiguration{Level: sarifLevel(sample.Severity)},
+		Properties: map[string]interface{}{
+			"severity": strings.ToLower(sample.Severity),
+		},
+	}
+	if sample.Recommendation != "" {
+		desc.Help = &sarifMessage{Text: sample.Recommendation}
+	}
+
+	rule := r.rules[id]
+	if rule == nil {
+		return desc
+	}
+	// "name" stays the stable short ID; the localized title goes in shortDescription.
+	if name := rule.Name.Get(lang); name != "" {
+		desc.ShortDescription = &sarifMessage{Text: name}
+	}
+	if d := rule.Description.Get(lang); d != "" {
+		desc.FullDescription = &sarifMessage{Text: d}
+	}
+	if rec := rule.Recommendation.Get(lang); rec != "" {
+		desc.Help = &sarifMessage{Text: rec}
+	}
+	if rule.Severity != "" {
+		desc.DefaultConfiguration.Level = sarifLevel(rule.Severity)
+		desc.Properties["severity"] = strings.ToLower(rule.Severity)
+	}
+	if len(rule.IaCTypes) > 0 {
+		desc.Properties["iac_types"] = rule.IaCTypes
+	}
+	if len(rule.ResourceTypes) > 0 {
+		desc.Properties["resource_types"] = rule.ResourceTypes
+	}
+	return desc
+}
+
+// toSARIFResult converts a single violation into a SARIF result.
+func (r *Reporter) toSARIFResult(v models.RichViolation, ruleIndex int, cwd string) sarifResult {
+	res := sarifResult{
+		RuleID:    v.ID,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(v.Severity),
+		Message:   sarifMessage{Text: v.Reason},
+		Properties: map[string]interface{}{
+			"severity": strings.ToLower(v.Severity),
+		},
+	}
+	if res.Message.Text == "" {
+		res.Message.Text = v.ID
+	}
+	if v.Recommendation != "" {
+		res.Properties["recommendation"] = v.Recommendation
+	}
+	if len(v.ViolationPath) > 0 {
+		res.Properties["violation_path"] = FormatPath(v.ViolationPath)
+	}
+
+	loc := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifact(v.File, cwd),
+		},
+	}
+	if v.Line > 0 {
+		region := &sarifRegion{StartLine: v.Line}
+		if v.Snippet != "" {
+			region.Snippet = &sarifMessage{Text: v.Snippet}
+		}
+		loc.PhysicalLocation.Region = region
+	}
+	if n := len(v.SnippetLines); n > 0 {
+		lines := make([]string, n)
+		for i, l := range v.SnippetLines {
+			lines[i] = l.Content
+		}
+		loc.PhysicalLocation.ContextRegion = &sarifRegion{
+			StartLine: v.SnippetLines[0].LineNum,
+			EndLine:   v.SnippetLines[n-1].LineNum,
+			Snippet:   &sarifMessage{Text: strings.Join(lines, "\n")},
+		}
+	}
+	if v.ResourceID != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{Name: v.ResourceID, Kind: "resource"}}
+	}
+	res.Locations = []sarifLocation{loc}
+	if v.Fingerprint != "" {
+		res.PartialFingerprints = map[string]string{sarifFingerprintKey: v.Fingerprint}
+	}
+
+	if v.Waiver != nil {
+		res.Suppressions = []sarifSuppression{r.toSARIFSuppression(v)}
+	}
+	if v.Baseline {
+		res.BaselineState = "unchanged"
+	}
+	return res
+}
+
+// toSARIFSuppression maps a waiver to a SARIF suppression. Waivers that still
+// suppress the finding are "accepted"; expired waivers that no longer do are
+// "rejected" so the result surfaces again.
+func (r *Reporter) toSARIFSuppression(v models.RichViolation) sarifSuppression {
+	w := v.Waiver
+	kind := "external"
+	if w.Source == "inline" {
+		kind = "inSource"
+	}
+	status := "rejected"
+	if v.IsSuppressed(r.failOnExpired) {
+		status = "accepted"
+	}
+	props := map[string]interface{}{"waiver_status": w.Status}
+	if w.Owner != "" {
+		props["owner"] = w.Owner
+	}
+	if w.Expires != "" {
+		props["expires"] = w.Expires
+	}
+	if w.ApprovedBy != "" {
+		props["approved_by"] = w.ApprovedBy
+	}
+	if w.Ticket != "" {
+		props["ticket"] = w.Ticket
+	}
+	if w.Created != "" {
+		props["created"] = w.Created
+	}
+	return sarifSuppression{
+		Kind:          kind,
+		Status:        status,
+		Justification: w.Reason,
+		Properties:    props,
+	}
+}
+
+// sarifArtifact returns the artifact location for a file, relative to the
+// working directory (%SRCROOT%) when possible, otherwise as an absolute URI.
+func sarifArtifact(file, cwd string) sarifArtifactLocation {
+	if file == "" {
+		return sarifArtifactLocation{}
+	}
+	if cwd != "" && filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(cwd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: sarifSrcRootKey}
+		}
+		return sarifArtifactLocation{URI: fileURI(file)}
+	}
+	return sarifArtifactLocation{URI: filepath.ToSlash(file), URIBaseID: sarifSrcRootKey}
+}
+
+// fileURI converts an absolute filesystem path into a file:// URI.
+func fileURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive paths, e.g. C:/x -> /C:/x
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// sarifLevel maps an InfraGuard severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case models.SeverityHigh:
+		return "error"
+	case models.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// extractShortID returns the last segment of a "rule:<provider>:<name>" ID.
+func extractShortID(id string) string {
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
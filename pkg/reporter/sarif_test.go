@@ -0,0 +1,148 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aliyun/infraguard/pkg/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderSARIF(t *testing.T) {
+	Convey("Given the SARIF renderer", t, func() {
+		rules := []*models.Rule{{
+			ID:             "rule:aliyun:ecs-no-public-ip",
+			Name:           models.I18nString{"en": "ECS No Public IP", "zh": "ECS 不分配公网 IP"},
+			Description:    models.I18nString{"en": "ECS instances should not have a public IP."},
+			Recommendation: models.I18nString{"en": "Set AllocatePublicIP to false."},
+			Severity:       models.SeverityHigh,
+			IaCTypes:       []string{"ros"},
+		}}
+
+		Convey("When rendering violations with rule metadata", func() {
+			results := []models.FileResult{{
+				File: "test.yaml",
+				Violations: []models.RichViolation{
+					{
+						Severity:   models.SeverityHigh,
+						ID:         "rule:aliyun:ecs-no-public-ip",
+						ResourceID: "WebServer",
+						File:       "test.yaml",
+						Line:       10,
+						Snippet:    "AllocatePublicIP: true",
+						SnippetLines: []models.SnippetLine{
+							{LineNum: 9, Content: "Properties:"},
+							{LineNum: 10, Content: "AllocatePublicIP: true", Highlight: true},
+						},
+						Reason:      "Public IP is allocated",
+						Fingerprint: "0123456789abcdef",
+					},
+					{
+						Severity:   models.SeverityLow,
+						ID:         "rule:custom:low-rule",
+						ResourceID: "Bucket",
+						File:       "test.yaml",
+						Line:       20,
+						Reason:     "Low reason",
+					},
+				},
+			}}
+
+			var buf bytes.Buffer
+			r := New("sarif", &buf, WithRules(rules), WithToolVersion("v1.2.3"))
+			err := r.Render(results)
+			So(err, ShouldBeNil)
+
+			var log sarifLog
+			So(json.Unmarshal(buf.Bytes(), &log), ShouldBeNil)
+
+			Convey("It should be a SARIF 2.1.0 log with one run", func() {
+				So(log.Version, ShouldEqual, "2.1.0")
+				So(log.Schema, ShouldNotBeEmpty)
+				So(len(log.Runs), ShouldEqual, 1)
+				So(log.Runs[0].Tool.Driver.Name, ShouldEqual, "InfraGuard")
+				So(log.Runs[0].Tool.Driver.Version, ShouldEqual, "1.2.3")
+			})
+
+			Convey("It should describe each referenced rule", func() {
+				driverRules := log.Runs[0].Tool.Driver.Rules
+				So(len(driverRules), ShouldEqual, 2)
+				So(driverRules[0].ID, ShouldEqual, "rule:aliyun:ecs-no-public-ip")
+				So(driverRules[0].ShortDescription.Text, ShouldEqual, "ECS No Public IP")
+				So(driverRules[0].FullDescription.Text, ShouldEqual, "ECS instances should not have a public IP.")
+				So(driverRules[0].Help.Text, ShouldEqual, "Set AllocatePublicIP to false.")
+				So(driverRules[0].DefaultConfiguration.Level, ShouldEqual, "error")
+				// Rules not in the index fall back to the violation's data.
+				So(driverRules[1].ID, ShouldEqual, "rule:custom:low-rule")
+				So(driverRules[1].DefaultConfiguration.Level, ShouldEqual, "note")
+			})
+
+			Convey("It should map locations and snippets", func() {
+				res := log.Runs[0].Results
+				So(len(res), ShouldEqual, 2)
+				So(res[0].RuleIndex, ShouldEqual, 0)
+				So(res[0].Level, ShouldEqual, "error")
+				So(res[0].Message.Text, ShouldEqual, "Public IP is allocated")
+				loc := res[0].Locations[0]
+				So(loc.PhysicalLocation.ArtifactLocation.URI, ShouldEqual, "test.yaml")
+				So(loc.PhysicalLocation.Region.StartLine, ShouldEqual, 10)
+				So(loc.PhysicalLocation.Region.Snippet.Text, ShouldEqual, "AllocatePublicIP: true")
+				So(loc.PhysicalLocation.ContextRegion.StartLine, ShouldEqual, 9)
+				So(loc.PhysicalLocation.ContextRegion.EndLine, ShouldEqual, 10)
+				So(loc.LogicalLocations[0].Name, ShouldEqual, "WebServer")
+			})
+
+			Convey("It should carry the fingerprint as a partial fingerprint", func() {
+				res := log.Runs[0].Results
+				So(res[0].PartialFingerprints, ShouldResemble, map[string]string{"infraguard/v1": "0123456789abcdef"})
+				So(res[1].PartialFingerprints, ShouldBeNil)
+			})
+		})
+
+		Convey("When rendering waived violations", func() {
+			results := []models.FileResult{{
+				File: "test.yaml",
+				Violations: []models.RichViolation{
+					{
+						Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "A", File: "test.yaml", Line: 1,
+						Waiver: &models.WaiverInfo{Status: models.WaiverStatusActive, Source: "inline", Reason: "legacy"},
+					},
+					{
+						Severity: models.SeverityHigh, ID: "rule:aliyun:a", ResourceID: "B", File: "test.yaml", Line: 2,
+						Waiver: &models.WaiverInfo{Status: models.WaiverStatusExpired, Source: "file", Reason: "old", Owner: "ops", Expires: "2020-01-01"},
+					},
+				},
+			}}
+
+			var buf bytes.Buffer
+			r := New("sarif", &buf)
+			So(r.Render(results), ShouldBeNil)
+
+			var log sarifLog
+			So(json.Unmarshal(buf.Bytes(), &log), ShouldBeNil)
+
+			Convey("It should keep them as results with suppressions", func() {
+				res := log.Runs[0].Results
+				So(len(res), ShouldEqual, 2)
+				So(res[0].Suppressions[0].Kind, ShouldEqual, "inSource")
+				So(res[0].Suppressions[0].Status, ShouldEqual, "accepted")
+				So(res[0].Suppressions[0].Justification, ShouldEqual, "legacy")
+				So(res[1].Suppressions[0].Kind, ShouldEqual, "external")
+				So(res[1].Suppressions[0].Status, ShouldEqual, "rejected")
+				So(res[1].Suppressions[0].Properties["owner"], ShouldEqual, "ops")
+			})
+		})
+
+		Convey("When rendering no violations", func() {
+			var buf bytes.Buffer
+			r := New("sarif", &buf)
+			So(r.Render([]models.FileResult{}), ShouldBeNil)
+
+			Convey("It should emit empty arrays rather than null", func() {
+				So(buf.String(), ShouldContainSubstring, `"results": []`)
+				So(buf.String(), ShouldContainSubstring, `"rules": []`)
+			})
+		})
+	})
+}
@@ -102,6 +102,7 @@ func (r *Reporter) renderTable(results []models.FileResult) error {
 			}
 		}
 		r.renderWaiverNote(waived, expired, isTTY)
+		r.renderBaselineNote(r.baselineCount(results), isTTY)
 		return nil
 	}
 
@@ -110,6 +111,7 @@ func (r *Reporter) renderTable(results []models.FileResult) error {
 	// Print summary statistics as table
 	r.renderSummary(realViolations, isTTY, msg)
 	r.renderWaiverNote(waived, expired, isTTY)
+	r.renderBaselineNote(r.baselineCount(results), isTTY)
 
 	return nil
 }
@@ -136,6 +138,19 @@ func (r *Reporter) renderWaiverNote(waived, expired int, isTTY bool) {
 	}
 }
 
+// renderBaselineNote prints a one-line note about violations in the baseline.
+func (r *Reporter) renderBaselineNote(count int, isTTY bool) {
+	if count == 0 {
+		return
+	}
+	note := fmt.Sprintf(i18n.Msg().Report.BaselineSummary, count)
+	if isTTY {
+		dimColor.Fprintln(r.writer, note)
+	} else {
+		fmt.Fprintln(r.writer, note)
+	}
+}
+
 // renderViolationCard renders a single violation with code snippet table.
 func (r *Reporter) renderViolationCard(num int, v models.RichViolation, isTTY bool, msg *i18n.Messages) {
 	// Header line with number, severity and reason (bold)
@@ -169,6 +184,13 @@ func (r *Reporter) renderViolationCard(num int, v models.RichViolation, isTTY bo
 			fmt.Fprintln(r.writer, note)
 		}
 	}
+	if v.Baseline {
+		if isTTY {
+			dimColor.Fprintln(r.writer, msg.Report.InBaseline)
+		} else {
+			fmt.Fprintln(r.writer, msg.Report.InBaseline)
+		}
+	}
 
 	fmt.Fprintln(r.writer)
 
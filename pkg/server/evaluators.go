@@ -0,0 +1,65 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/aliyun/infraguard/pkg/engine"
+	"github.com/aliyun/infraguard/pkg/policy"
+)
+
+// maxCachedEvaluators bounds the number of compiled policy sets kept by the server.
+const maxCachedEvaluators = 32
+
+// policies returns the policy index, loaded on first use and kept for the
+// lifetime of the server. A failed load is retried on the next call.
+func (s *Server) policies() (*policy.Loader, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loader != nil {
+		return s.loader, nil
+	}
+	loader, err := policy.LoadWithFallback()
+	if err != nil {
+		return nil, err
+	}
+	s.loader = loader
+	return loader, nil
+}
+
+// libModules returns the helper library modules of the policy index.
+func (s *Server) libModules() (map[string]string, error) {
+	loader, err := s.policies()
+	if err != nil {
+		return nil, err
+	}
+	return loader.GetLibModules(), nil
+}
+
+// scanEvaluator returns the compiled policies for a set of policy specs and an
+// IaC type, compiling them on first use. It returns nil if no policy applies.
+func (s *Server) scanEvaluator(loader *policy.Loader, specs []string, iac string) (*engine.Evaluator, error) {
+	key := iac + "\x00" + strings.Join(specs, "\x00")
+
+	s.mu.Lock()
+	evaluator, ok := s.evaluators[key]
+	s.mu.Unlock()
+	if ok {
+		return evaluator, nil
+	}
+
+	opts := resolveEvalOptions(loader, specs, iac)
+	if len(opts.Modules) > 0 {
+		var err error
+		if evaluator, err = engine.NewEvaluator(opts); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.evaluators == nil || len(s.evaluators) >= maxCachedEvaluators {
+		s.evaluators = make(map[string]*engine.Evaluator)
+	}
+	s.evaluators[key] = evaluator
+	return evaluator, nil
+}
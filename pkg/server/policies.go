@@ -27,7 +27,7 @@ type packSummary struct {
 }
 
 func (s *Server) handlePoliciesList(w http.ResponseWriter, r *http.Request) {
-	loader, err := policy.LoadWithFallback()
+	loader, err := s.policies()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to load policies: "+err.Error())
 		return
@@ -147,7 +147,7 @@ type policyDetail struct {
 
 func (s *Server) handlePolicyDetail(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
-	loader, err := policy.LoadWithFallback()
+	loader, err := s.policies()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to load policies: "+err.Error())
 		return
@@ -201,7 +201,7 @@ type frameworkCoverage struct {
 }
 
 func (s *Server) handleCoverage(w http.ResponseWriter, r *http.Request) {
-	loader, err := policy.LoadWithFallback()
+	loader, err := s.policies()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to load policies: "+err.Error())
 		return
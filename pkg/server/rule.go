@@ -7,7 +7,6 @@ import (
 
 	"github.com/aliyun/infraguard/pkg/engine"
 	"github.com/aliyun/infraguard/pkg/models"
-	"github.com/aliyun/infraguard/pkg/policy"
 )
 
 // ruleEvalRequest evaluates ad-hoc Rego against a template.
@@ -29,22 +28,30 @@ func (s *Server) handleRuleEval(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "rego and content are required")
 		return
 	}
-	lib, err := libModules()
+	lib, err := s.libModules()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	iac := detectIaC(req.IaC, "", req.Content)
-	opts := &engine.EvalOptions{
-		Modules:    map[string]string{"studio.rego": req.Rego},
-		LibModules: lib,
+	evaluator, err := engine.NewEvaluator(&engine.EvalOptions{
+		Modules:     map[string]string{"studio.rego": req.Rego},
+		LibModules:  lib,
+		RuleTimeout: engine.DefaultRuleTimeout,
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 	lang := req.Lang
 	if lang == "" {
 		lang = "en"
 	}
-	rich, err := scanContent(iac, req.Content, req.Inputs, opts, lang)
+	rich, timedOut, err := scanContent(r.Context(), iac, req.Content, req.Inputs, evaluator, lang)
 	if err != nil {
+		if r.Context().Err() != nil {
+			return // The client went away; there is no one to answer
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -54,7 +61,11 @@ func (s *Server) handleRuleEval(w http.ResponseWriter, r *http.Request) {
 	for i := range rich {
 		rich[i].File = "template"
 	}
-	writeJSON(w, http.StatusOK, map[string]interface{}{"iac": iac, "violations": rich})
+	resp := map[string]interface{}{"iac": iac, "violations": rich}
+	if len(timedOut) > 0 {
+		resp["timed_out_rules"] = timedOut
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // ruleTestRequest runs compliant/violation fixtures against ad-hoc Rego.
@@ -82,7 +93,7 @@ func (s *Server) handleRuleTest(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "rego is required")
 		return
 	}
-	lib, err := libModules()
+	lib, err := s.libModules()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -92,18 +103,27 @@ func (s *Server) handleRuleTest(w http.ResponseWriter, r *http.Request) {
 	if lang == "" {
 		lang = "en"
 	}
-	newOpts := func() *engine.EvalOptions {
-		return &engine.EvalOptions{Modules: map[string]string{"studio.rego": req.Rego}, LibModules: lib}
-	}
+	// Both fixtures share one compilation; a compile error fails each case.
+	evaluator, compileErr := engine.NewEvaluator(&engine.EvalOptions{
+		Modules:     map[string]string{"studio.rego": req.Rego},
+		LibModules:  lib,
+		RuleTimeout: engine.DefaultRuleTimeout,
+	})
 
 	run := func(content string, expectViolation bool) caseOutcome {
 		if strings.TrimSpace(content) == "" {
 			return caseOutcome{Pass: false, Error: "fixture is empty"}
 		}
-		rich, err := scanContent(iac, content, nil, newOpts(), lang)
+		if compileErr != nil {
+			return caseOutcome{Pass: false, Error: compileErr.Error()}
+		}
+		rich, timedOut, err := scanContent(r.Context(), iac, content, nil, evaluator, lang)
 		if err != nil {
 			return caseOutcome{Pass: false, Error: err.Error()}
 		}
+		if len(timedOut) > 0 {
+			return caseOutcome{Pass: false, Error: "rule evaluation timed out"}
+		}
 		n := len(rich)
 		pass := n == 0
 		if expectViolation {
@@ -121,11 +141,3 @@ func (s *Server) handleRuleTest(w http.ResponseWriter, r *http.Request) {
 		"pass":      compliant.Pass && violation.Pass,
 	})
 }
-
-func libModules() (map[string]string, error) {
-	loader, err := policy.LoadWithFallback()
-	if err != nil {
-		return nil, err
-	}
-	return loader.GetLibModules(), nil
-}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -27,9 +28,10 @@ type scanRequest struct {
 
 // scanResponse is returned by POST /api/scan.
 type scanResponse struct {
-	IaC        string                 `json:"iac"`
-	Summary    models.ReportSummary   `json:"summary"`
-	Violations []models.RichViolation `json:"violations"`
+	IaC           string                 `json:"iac"`
+	Summary       models.ReportSummary   `json:"summary"`
+	Violations    []models.RichViolation `json:"violations"`
+	TimedOutRules []string               `json:"timed_out_rules,omitempty"` // Rules whose evaluation timed out
 }
 
 func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
@@ -48,19 +50,26 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 		lang = "en"
 	}
 
-	loader, err := policy.LoadWithFallback()
+	loader, err := s.policies()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to load policies: "+err.Error())
 		return
 	}
-	opts := resolveEvalOptions(loader, req.Policies, iac)
-	if len(opts.Modules) == 0 {
+	evaluator, err := s.scanEvaluator(loader, req.Policies, iac)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to compile policies: "+err.Error())
+		return
+	}
+	if evaluator == nil {
 		writeJSON(w, http.StatusOK, scanResponse{IaC: iac, Violations: []models.RichViolation{}, Summary: emptySummary()})
 		return
 	}
 
-	rich, err := scanContent(iac, req.Content, req.Inputs, opts, lang)
+	rich, timedOut, err := scanContent(r.Context(), iac, req.Content, req.Inputs, evaluator, lang)
 	if err != nil {
+		if r.Context().Err() != nil {
+			return // The client went away; there is no one to answer
+		}
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -75,57 +84,63 @@ func (s *Server) handleScan(w http.ResponseWriter, r *http.Request) {
 	}
 	for i := range rich {
 		rich[i].File = display
+		rich[i].Fingerprint = models.Fingerprint(rich[i].ID, iac, display, rich[i].ResourceID, rich[i].ViolationPath)
 	}
 
-	writeJSON(w, http.StatusOK, scanResponse{IaC: iac, Violations: rich, Summary: summarize(rich)})
+	summary := summarize(rich)
+	summary.TimedOutRuleCount = len(timedOut)
+	writeJSON(w, http.StatusOK, scanResponse{IaC: iac, Violations: rich, Summary: summary, TimedOutRules: timedOut})
 }
 
-// scanContent writes the template to a temp location and evaluates it.
-func scanContent(iac, content string, inputs map[string]interface{}, opts *engine.EvalOptions, lang string) ([]models.RichViolation, error) {
+// scanContent writes the template to a temp location and evaluates it. It
+// returns the violations and the IDs of the rules that timed out. Evaluation
+// stops when ctx is done.
+func scanContent(ctx context.Context, iac, content string, inputs map[string]interface{}, evaluator *engine.Evaluator, lang string) ([]models.RichViolation, []string, error) {
 	dir, err := os.MkdirTemp("", "infraguard-scan-*")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer os.RemoveAll(dir)
 
 	if iac == "terraform" {
 		path := filepath.Join(dir, "main.tf")
 		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		data, err := terraform.Load(dir, inputs)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		res, err := engine.EvaluateWithOpts(opts, data)
+		res, err := evaluator.Evaluate(ctx, data)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		return mapper.MapTerraformViolations(res.Violations, data, dir, lang), nil
+		return mapper.MapTerraformViolations(res.Violations, data, dir, lang), res.TimedOutRules, nil
 	}
 
 	// ROS (YAML/JSON)
 	path := filepath.Join(dir, "template.yaml")
 	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	yamlRoot, data, err := ros.Load(ros.ModeStatic, path, inputs)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	res, err := engine.EvaluateWithOpts(opts, data)
+	res, err := evaluator.Evaluate(ctx, data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return mapper.MapViolationsWithLang(res.Violations, yamlRoot, path, lang), nil
+	return mapper.MapViolationsWithLang(res.Violations, yamlRoot, path, lang), res.TimedOutRules, nil
 }
 
 // resolveEvalOptions builds engine options from policy specs filtered to one IaC.
 func resolveEvalOptions(loader *policy.Loader, specs []string, iac string) *engine.EvalOptions {
 	opts := &engine.EvalOptions{
-		Modules:    map[string]string{},
-		LibModules: loader.GetLibModules(),
-		IDMapping:  map[string]string{},
+		Modules:     map[string]string{},
+		LibModules:  loader.GetLibModules(),
+		IDMapping:   map[string]string{},
+		RuleTimeout: engine.DefaultRuleTimeout,
 	}
 	for _, r := range loader.GetAllRules() {
 		short := shortID(r.ID)
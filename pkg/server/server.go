@@ -9,9 +9,12 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/aliyun/infraguard/pkg/engine"
 	"github.com/aliyun/infraguard/pkg/i18n"
+	"github.com/aliyun/infraguard/pkg/policy"
 )
 
 // Options configures the server.
@@ -26,6 +29,10 @@ type Options struct {
 type Server struct {
 	opts Options
 	mux  *http.ServeMux
+
+	mu         sync.Mutex
+	loader     *policy.Loader               // Policy index, loaded on first use
+	evaluators map[string]*engine.Evaluator // Compiled policies by IaC type and policy specs
 }
 
 // New creates a server with all routes registered.
@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/aliyun/infraguard/pkg/models"
 )
 
 func TestIsLoopback(t *testing.T) {
@@ -83,3 +88,66 @@ func TestMetaAndAuth(t *testing.T) {
 		t.Fatalf("health = %d, want 200", rec.Code)
 	}
 }
+
+func TestScanReusesCompiledPolicies(t *testing.T) {
+	s := New(Options{Host: "127.0.0.1"})
+	body := `{"iac": "terraform", "policies": ["rule:aliyun:oss-bucket-logging-enabled"], "content": "resource \"alicloud_oss_bucket\" \"b\" {\n  bucket = \"b\"\n}\n"}`
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/scan", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("scan #%d = %d, want 200: %s", i, rec.Code, rec.Body.String())
+		}
+		var resp scanResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode scan response: %v", err)
+		}
+		if len(resp.Violations) != 1 || resp.Violations[0].ID != "rule:aliyun:oss-bucket-logging-enabled" {
+			t.Fatalf("scan #%d violations = %+v, want one oss-bucket-logging-enabled", i, resp.Violations)
+		}
+		v := resp.Violations[0]
+		if want := models.Fingerprint(v.ID, "terraform", "main.tf", v.ResourceID, v.ViolationPath); v.Fingerprint != want {
+			t.Errorf("scan #%d fingerprint = %q, want %q", i, v.Fingerprint, want)
+		}
+	}
+	if len(s.evaluators) != 1 {
+		t.Errorf("cached evaluators = %d, want 1", len(s.evaluators))
+	}
+}
+
+func TestRuleEvalIgnoresProcessEnvironment(t *testing.T) {
+	t.Setenv("TF_VAR_db_password", "s3cret")
+	s := New(Options{Host: "127.0.0.1"})
+	rego := "package infraguard.rules.studio.leak\n\ndeny contains {\"id\": \"leak\", \"resource_id\": input.variables.db_password.value, \"violation_path\": []} if {\n  input.variables.db_password.value\n}\n"
+	body, _ := json.Marshal(map[string]string{"rego": rego, "iac": "terraform", "content": "variable \"db_password\" {}\n"})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rule/eval", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rule eval = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "s3cret") {
+		t.Errorf("rule eval exposed a TF_VAR_ environment variable: %s", rec.Body.String())
+	}
+}
+
+func TestRuleEvalStopsWhenRequestIsCancelled(t *testing.T) {
+	s := New(Options{Host: "127.0.0.1"})
+	rego := "package infraguard.rules.studio.slow\n\ndeny contains \"never\" if {\n  some i in numbers.range(1, 100000)\n  some j in numbers.range(1, 100000)\n  i * j < 0\n}\n"
+	body, _ := json.Marshal(map[string]string{"rego": rego, "content": "ROSTemplateFormatVersion: '2015-09-01'\nResources: {}\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	req := httptest.NewRequest(http.MethodPost, "/api/rule/eval", strings.NewReader(string(body))).WithContext(ctx)
+	rec := httptest.NewRecorder()
+
+	start := time.Now()
+	s.Handler().ServeHTTP(rec, req)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("rule eval took %v after the request was cancelled", elapsed)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("cancelled request got a response: %s", rec.Body.String())
+	}
+}
@@ -1,7 +1,12 @@
 package waiver
 
 import (
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/aliyun/infraguard/pkg/models"
 )
 
 // Issue severity levels for lint findings.
@@ -12,18 +17,31 @@ const (
 
 // Issue codes (localized by the caller).
 const (
-	CodeMissingRule    = "missing_rule"
-	CodeMissingReason  = "missing_reason"
-	CodeUnknownRule    = "unknown_rule"
-	CodeInvalidExpires = "invalid_expires"
-	CodeExpired        = "expired"
-	CodePermanent      = "permanent"
+	CodeMissingRule        = "missing_rule"
+	CodeMissingReason      = "missing_reason"
+	CodeUnknownRule        = "unknown_rule"
+	CodeInvalidExpires     = "invalid_expires"
+	CodeExpired            = "expired"
+	CodePermanent          = "permanent"
+	CodeInvalidCreated     = "invalid_created"
+	CodeInvalidCeiling     = "invalid_severity_ceiling"
+	CodeInvalidMaxSeverity = "invalid_max_severity"
+	CodeUnknownPack        = "unknown_pack"
+	CodePackHigh           = "pack_covers_high"
+
+	// Governance codes, reported by Govern.
+	CodeMissingApprover = "missing_approver"
+	CodeMissingTicket   = "missing_ticket"
+	CodeLifetime        = "lifetime_exceeded"
 )
 
+// maxListedRules is the number of rules a lint issue names before eliding the rest.
+const maxListedRules = 5
+
 // Issue is a problem found while linting a waiver set.
 type Issue struct {
 	Index    int    // Index of the waiver in the file (0-based)
-	Rule     string // Rule the waiver targets
+	Rule     string // Rule or fingerprint the waiver targets
 	Severity string // error | warning
 	Code     string // One of the Code* constants
 	Detail   string // Extra context (e.g. the rule name or date), may be empty
@@ -31,17 +49,19 @@ type Issue struct {
 
 // Lint validates a waiver set. knownRules is the set of valid short rule IDs; pass
 // nil to skip the unknown-rule check. now is used to detect expired waivers.
+// Pack waivers are checked against the set's policy index, if any.
 func (s *Set) Lint(knownRules map[string]bool, now time.Time) []Issue {
 	var issues []Issue
 	if s == nil {
 		return issues
 	}
 	for i, w := range s.Waivers {
+		rule := w.Target()
 		add := func(sev, code, detail string) {
-			issues = append(issues, Issue{Index: i, Rule: w.Rule, Severity: sev, Code: code, Detail: detail})
+			issues = append(issues, Issue{Index: i, Rule: rule, Severity: sev, Code: code, Detail: detail})
 		}
 
-		if w.Rule == "" {
+		if rule == "" {
 			add(IssueError, CodeMissingRule, "")
 		}
 		if w.Reason == "" {
@@ -52,6 +72,27 @@ func (s *Set) Lint(knownRules map[string]bool, now time.Time) []Issue {
 				add(IssueWarning, CodeUnknownRule, w.Rule)
 			}
 		}
+		if w.Created != "" {
+			if _, err := time.Parse(dateLayout, w.Created); err != nil {
+				add(IssueError, CodeInvalidCreated, w.Created)
+			}
+		}
+		if w.SeverityCeiling != "" && !isSeverity(w.SeverityCeiling) {
+			add(IssueError, CodeInvalidCeiling, w.SeverityCeiling)
+		}
+		if w.MaxSeverity != "" && !isSeverity(w.MaxSeverity) {
+			add(IssueError, CodeInvalidMaxSeverity, w.MaxSeverity)
+		}
+		if w.Pack != "" && w.Fingerprint == "" {
+			if !s.HasPack(w.Pack) {
+				add(IssueWarning, CodeUnknownPack, w.Pack)
+			} else if high := s.highRules(w); len(high) > 0 {
+				if len(high) > maxListedRules {
+					high = append(high[:maxListedRules], "…")
+				}
+				add(IssueWarning, CodePackHigh, strings.Join(high, ", "))
+			}
+		}
 		if w.Expires != "" {
 			t, err := time.Parse(dateLayout, w.Expires)
 			if err != nil {
@@ -66,6 +107,121 @@ func (s *Set) Lint(knownRules map[string]bool, now time.Time) []Issue {
 	return issues
 }
 
+// Requirement is a governance rule for the waivers that cover one severity.
+type Requirement struct {
+	Approver bool `yaml:"approver,omitempty"` // approved_by must be set
+	Ticket   bool `yaml:"ticket,omitempty"`   // ticket must be set
+	MaxDays  int  `yaml:"max_days,omitempty"` // Longest lifetime from created (or today) to expires; 0 means no limit
+}
+
+// Govern checks the waivers against governance requirements keyed by severity.
+// A waiver's severity is its severity ceiling or maximum severity, or else the
+// highest severity of the rules in its packs, or else the severity of its rule
+// in ruleSeverities (keyed by short rule ID). A waiver of unknown severity, such
+// as one for all rules, is held to the requirements for high severity.
+func (s *Set) Govern(require map[string]Requirement, ruleSeverities map[string]string, now time.Time) []Issue {
+	var issues []Issue
+	if s == nil || len(require) == 0 {
+		return issues
+	}
+	today := now.Truncate(24 * time.Hour)
+	for i, w := range s.Waivers {
+		severity := w.SeverityCeiling
+		if severity == "" {
+			severity = w.MaxSeverity
+		}
+		if severity == "" && w.Pack != "" && w.Rule == "" {
+			severity = s.packSeverity(w.Pack)
+		}
+		if severity == "" {
+			severity = ruleSeverities[ShortRuleID(w.Rule)]
+		}
+		if severity == "" {
+			severity = models.SeverityHigh
+		}
+		req, ok := require[severity]
+		if !ok {
+			continue
+		}
+		rule := w.Target()
+		add := func(code, detail string) {
+			issues = append(issues, Issue{Index: i, Rule: rule, Severity: IssueError, Code: code, Detail: detail})
+		}
+
+		if req.Approver && w.ApprovedBy == "" {
+			add(CodeMissingApprover, severity)
+		}
+		if req.Ticket && w.Ticket == "" {
+			add(CodeMissingTicket, severity)
+		}
+		if req.MaxDays > 0 {
+			start := today
+			if t, err := time.Parse(dateLayout, w.Created); err == nil {
+				start = t
+			}
+			expires, err := time.Parse(dateLayout, w.Expires)
+			if w.Expires == "" || (err == nil && expires.Sub(start) > time.Duration(req.MaxDays)*24*time.Hour) {
+				add(CodeLifetime, strconv.Itoa(req.MaxDays))
+			}
+		}
+	}
+	return issues
+}
+
+// Target describes what a waiver targets for lint output: its rule, pack or
+// fingerprint, or "" if it has none.
+func (w Waiver) Target() string {
+	switch {
+	case w.Fingerprint != "":
+		return w.Fingerprint
+	case w.Pack != "" && (w.Rule == "" || w.Rule == "*"):
+		return w.Pack
+	case w.Rule == "" && w.MaxSeverity != "":
+		return "*"
+	}
+	return w.Rule
+}
+
+// highRules returns the sorted short IDs of the high-severity rules a pack
+// waiver covers, after its rule and severity limits.
+func (s *Set) highRules(w Waiver) []string {
+	if w.MaxSeverity != "" && w.MaxSeverity != models.SeverityHigh ||
+		w.SeverityCeiling != "" && w.SeverityCeiling != models.SeverityHigh {
+		return nil
+	}
+	var high []string
+	for _, rule := range s.packRules(w.Pack) {
+		short := ShortRuleID(rule.ID)
+		if ruleMatches([]string{w.rulePattern()}, short) && models.NormalizeSeverity(rule.Severity) == models.SeverityHigh {
+			high = append(high, short)
+		}
+	}
+	sort.Strings(high)
+	return high
+}
+
+// packSeverity returns the highest severity among the rules of the packs
+// matching pattern, or "" if none is known.
+func (s *Set) packSeverity(pattern string) string {
+	severity := ""
+	for _, rule := range s.packRules(pattern) {
+		sev := models.NormalizeSeverity(rule.Severity)
+		if severity == "" || models.SeverityAtLeast(sev, severity) {
+			severity = sev
+		}
+	}
+	return severity
+}
+
+func isSeverity(s string) bool {
+	for _, level := range models.SeverityLevels() {
+		if s == level {
+			return true
+		}
+	}
+	return false
+}
+
 // Status summarizes a single waiver for listing.
 type Status struct {
 	Waiver  Waiver
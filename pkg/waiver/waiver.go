@@ -10,12 +10,14 @@
 package waiver
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aliyun/infraguard/pkg/models"
@@ -30,12 +32,19 @@ const dateLayout = "2006-01-02"
 
 // Waiver is a single entry in the central waiver file.
 type Waiver struct {
-	Rule     string   `yaml:"rule"`               // Short rule ID, or "*" for all rules
-	Resource string   `yaml:"resource,omitempty"` // Resource ID glob; empty matches any resource
-	Files    []string `yaml:"files,omitempty"`    // File path globs; empty matches any file
-	Reason   string   `yaml:"reason"`             // Required justification
-	Expires  string   `yaml:"expires,omitempty"`  // YYYY-MM-DD; empty means permanent
-	Owner    string   `yaml:"owner,omitempty"`    // Responsible person
+	Rule            string   `yaml:"rule,omitempty"`             // Short rule ID, or "*" for all rules
+	Pack            string   `yaml:"pack,omitempty"`             // Pack ID or glob; limits the waiver to the rules of matching packs
+	MaxSeverity     string   `yaml:"max_severity,omitempty"`     // Highest severity of the violations to waive
+	Fingerprint     string   `yaml:"fingerprint,omitempty"`      // Violation fingerprint; matched instead of rule, pack, resource and files
+	Resource        string   `yaml:"resource,omitempty"`         // Resource ID glob; empty matches any resource
+	Files           []string `yaml:"files,omitempty"`            // File path globs; empty matches any file
+	Reason          string   `yaml:"reason"`                     // Required justification
+	Expires         string   `yaml:"expires,omitempty"`          // YYYY-MM-DD; empty means permanent
+	Owner           string   `yaml:"owner,omitempty"`            // Responsible person
+	ApprovedBy      string   `yaml:"approved_by,omitempty"`      // Person who approved the exception
+	Ticket          string   `yaml:"ticket,omitempty"`           // Tracking ticket, ID or URL
+	Created         string   `yaml:"created,omitempty"`          // YYYY-MM-DD the waiver was granted
+	SeverityCeiling string   `yaml:"severity_ceiling,omitempty"` // Highest severity the waiver may cover
 }
 
 // fileDoc is the on-disk structure of the waiver file.
@@ -48,6 +57,9 @@ type fileDoc struct {
 type Set struct {
 	Path    string
 	Waivers []Waiver
+	// Index resolves the rules of pack waivers. Without it, pack waivers match
+	// no violations.
+	Index *models.PolicyIndex
 }
 
 // Inline is a parsed inline-comment directive with its source line.
@@ -106,6 +118,154 @@ func Save(path string, waivers []Waiver) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+// Append adds waivers to the end of the waiver file at path, creating the file
+// if it does not exist. Existing entries keep their order and comments. A
+// waiver that targets the same violations as an entry already in the file is
+// skipped; Append returns the waivers it added.
+func Append(path string, waivers []Waiver) ([]Waiver, error) {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse waiver file %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		// New or empty file.
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+		if err := doc.Content[0].Encode(fileDoc{Version: 1}); err != nil {
+			return nil, err
+		}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse waiver file %s: top level is not a mapping", path)
+	}
+
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "waivers" {
+			list = root.Content[i+1]
+		}
+	}
+	if list == nil {
+		list = &yaml.Node{}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "waivers"}, list)
+	}
+	var existing []Waiver
+	if list.Kind == yaml.SequenceNode {
+		if err := list.Decode(&existing); err != nil {
+			return nil, fmt.Errorf("parse waiver file %s: %w", path, err)
+		}
+	} else {
+		// "waivers:" with no entries, or null.
+		*list = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	list.Style = 0
+
+	var added []Waiver
+	for _, w := range waivers {
+		if containsTarget(existing, w) {
+			continue
+		}
+		node := &yaml.Node{}
+		if err := node.Encode(w); err != nil {
+			return nil, err
+		}
+		plainDates(node)
+		list.Content = append(list.Content, node)
+		existing = append(existing, w)
+		added = append(added, w)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	return added, writeNode(path, &doc)
+}
+
+// Remove deletes the waivers at the given indexes from the waiver file at
+// path. The other entries keep their order and comments.
+func Remove(path string, indexes []int) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse waiver file %s: %w", path, err)
+	}
+	if doc.Kind != yaml.DocumentNode || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("parse waiver file %s: top level is not a mapping", path)
+	}
+	root := doc.Content[0]
+	remove := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		remove[i] = true
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if list := root.Content[i+1]; root.Content[i].Value == "waivers" && list.Kind == yaml.SequenceNode {
+			kept := list.Content[:0]
+			for j, item := range list.Content {
+				if !remove[j] {
+					kept = append(kept, item)
+				}
+			}
+			list.Content = kept
+		}
+	}
+	return writeNode(path, &doc)
+}
+
+// writeNode writes a YAML document to path with the indentation of the
+// documented waiver file format.
+func writeNode(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// plainDates writes the dates of an encoded waiver unquoted, as in
+// hand-written files. It still loads as a string.
+func plainDates(node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i].Value; key == "expires" || key == "created" {
+			node.Content[i+1].Tag = "!!timestamp"
+			node.Content[i+1].Style = 0
+		}
+	}
+}
+
+// containsTarget reports whether one of waivers matches the same violations as w.
+func containsTarget(waivers []Waiver, w Waiver) bool {
+	for _, o := range waivers {
+		if w.Fingerprint != "" || o.Fingerprint != "" {
+			if w.Fingerprint == o.Fingerprint {
+				return true
+			}
+			continue
+		}
+		if ShortRuleID(w.Rule) == ShortRuleID(o.Rule) && w.Pack == o.Pack && w.MaxSeverity == o.MaxSeverity &&
+			w.Resource == o.Resource &&
+			strings.Join(w.Files, "\x00") == strings.Join(o.Files, "\x00") {
+			return true
+		}
+	}
+	return false
+}
+
 // Load reads and parses a waiver file.
 func Load(path string) (*Set, error) {
 	data, err := os.ReadFile(path)
@@ -194,6 +354,8 @@ type ResourceLine struct {
 // AttributeInline maps each inline directive to the resource it governs, returning
 // resourceID -> directives. A directive placed 1-2 lines above a resource (head
 // comment) attaches to that resource; otherwise it attaches to the enclosing block.
+// Resources that start on the same line, such as the instances of a Terraform
+// block using count or for_each, all receive the directive.
 func AttributeInline(inlines []Inline, resources []ResourceLine) map[string][]Inline {
 	out := make(map[string][]Inline)
 	if len(resources) == 0 {
@@ -204,96 +366,381 @@ func AttributeInline(inlines []Inline, resources []ResourceLine) map[string][]In
 	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Line < sorted[j].Line })
 
 	for _, in := range inlines {
-		id := attributeOne(in.Line, sorted)
-		if id != "" {
-			out[id] = append(out[id], in)
+		line := attributeLine(in.Line, sorted)
+		if line == 0 {
+			continue
+		}
+		for _, r := range sorted {
+			if r.Line == line {
+				out[r.ID] = append(out[r.ID], in)
+			}
 		}
 	}
 	return out
 }
 
-func attributeOne(line int, sorted []ResourceLine) string {
+// attributeLine returns the start line of the resource block a directive on the
+// given line governs, or 0 if none.
+func attributeLine(line int, sorted []ResourceLine) int {
 	// Head-comment intent: a resource starts 1-2 lines below the directive.
 	for _, r := range sorted {
 		if r.Line == line+1 || r.Line == line+2 {
-			return r.ID
+			return r.Line
 		}
 	}
 	// Enclosing block: the resource with the largest start line <= directive line.
-	id := ""
+	found := 0
 	for _, r := range sorted {
 		if r.Line <= line {
-			id = r.ID
+			found = r.Line
 		} else {
 			break
 		}
 	}
-	return id
+	return found
+}
+
+// Usage records the waivers and directives that match at least one violation,
+// including those shadowed by the one Annotate applied: a shadowed waiver is
+// still needed if the one that shadows it is removed.
+type Usage struct {
+	File   map[int]bool            // Indexes into Set.Waivers
+	Inline map[string]map[int]bool // File path -> directive line
 }
 
 // Annotate sets the Waiver field on every violation in results that matches a waiver.
 // inlineByFile maps file path -> resourceID -> directives (from AttributeInline).
-// now is used to determine expiry (inject for deterministic tests).
-func (s *Set) Annotate(results []models.FileResult, inlineByFile map[string]map[string][]Inline, now time.Time) {
+// now is used to determine expiry (inject for deterministic tests). The returned
+// Usage records which waivers and directives matched.
+func (s *Set) Annotate(results []models.FileResult, inlineByFile map[string]map[string][]Inline, now time.Time) *Usage {
+	usage := &Usage{File: make(map[int]bool), Inline: make(map[string]map[int]bool)}
+	packs := s.resolvePacks()
 	for fi := range results {
 		fr := &results[fi]
 		for vi := range fr.Violations {
 			v := &fr.Violations[vi]
+			// Directives live in the file that declares the resource, which for
+			// Terraform may be another file in the directory or a module.
+			file := v.File
+			if file == "" {
+				file = fr.File
+			}
+			inlines := matchingInline(v, inlineByFile[file])
+			for _, in := range inlines {
+				if usage.Inline[in.File] == nil {
+					usage.Inline[in.File] = make(map[int]bool)
+				}
+				usage.Inline[in.File][in.Line] = true
+			}
+			indexes := s.matchingFile(v, packs)
+			for _, i := range indexes {
+				usage.File[i] = true
+			}
 			// Inline directives take precedence over central-file waivers.
-			if info := matchInline(v, inlineByFile[fr.File], now); info != nil {
-				v.Waiver = info
-				continue
+			switch {
+			case len(inlines) > 0:
+				v.Waiver = inlineInfo(inlines[0], now)
+			case len(indexes) > 0:
+				v.Waiver = fileInfo(s.Waivers[indexes[0]], now)
 			}
-			if info := s.matchFile(v, now); info != nil {
-				v.Waiver = info
+		}
+	}
+	return usage
+}
+
+// Unused returns the indexes of the waivers that u does not record as applied.
+func (s *Set) Unused(u *Usage) []int {
+	var unused []int
+	if s == nil {
+		return unused
+	}
+	for i := range s.Waivers {
+		if !u.File[i] {
+			unused = append(unused, i)
+		}
+	}
+	return unused
+}
+
+// Scope is the part of the workspace a scan covered. A waiver that reaches
+// outside it may match violations the scan did not look for.
+type Scope struct {
+	// Rules reports whether the scan evaluated the rule with the given short
+	// ID; nil means it evaluated every rule.
+	Rules func(shortID string) bool
+	// Roots holds the absolute paths of the scanned files and directories; nil
+	// means the scan covered the whole workspace.
+	Roots []string
+}
+
+// InScope reports whether a scan with the given scope looked for every
+// violation the waiver can match, so that matching none of them makes it
+// unused. Waivers by fingerprint or for all rules or files are only in a
+// scope that does not limit them.
+func (s *Set) InScope(w Waiver, scope Scope) bool {
+	if scope.Rules != nil {
+		if w.Fingerprint != "" {
+			return false
+		}
+		var rules []string
+		switch pattern := w.rulePattern(); {
+		case w.Pack != "":
+			for _, rule := range s.packRules(w.Pack) {
+				if short := ShortRuleID(rule.ID); ruleMatches([]string{pattern}, short) {
+					rules = append(rules, short)
+				}
+			}
+		case pattern != "*":
+			rules = []string{pattern}
+		}
+		if len(rules) == 0 {
+			return false
+		}
+		for _, rule := range rules {
+			if !scope.Rules(rule) {
+				return false
 			}
 		}
 	}
+	if scope.Roots != nil {
+		if w.Fingerprint != "" || len(w.Files) == 0 {
+			return false
+		}
+		for _, pattern := range w.Files {
+			if !underRoots(globRoot(pattern), scope.Roots) {
+				return false
+			}
+		}
+	}
+	return true
 }
 
-func matchInline(v *models.RichViolation, byResource map[string][]Inline, now time.Time) *models.WaiverInfo {
-	if byResource == nil {
-		return nil
+// globRoot returns the absolute directory below which every path matching a
+// file glob lies: the glob itself if it has no wildcard.
+func globRoot(pattern string) string {
+	pattern = filepath.ToSlash(pattern)
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		pattern = pattern[:i]
+		if j := strings.LastIndex(pattern, "/"); j >= 0 {
+			pattern = pattern[:j+1]
+		} else {
+			pattern = "."
+		}
 	}
+	if abs, err := filepath.Abs(filepath.FromSlash(pattern)); err == nil {
+		return abs
+	}
+	return pattern
+}
+
+// underRoots reports whether path is one of roots or lies below one of them.
+func underRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnusedInline returns the directives that u does not record as applied, sorted
+// by file and line.
+func UnusedInline(inlines []Inline, u *Usage) []Inline {
+	var unused []Inline
+	for _, in := range inlines {
+		if !u.Inline[in.File][in.Line] {
+			unused = append(unused, in)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		if unused[i].File != unused[j].File {
+			return unused[i].File < unused[j].File
+		}
+		return unused[i].Line < unused[j].Line
+	})
+	return unused
+}
+
+// matchingInline returns the directives that match a violation, in file order.
+func matchingInline(v *models.RichViolation, byResource map[string][]Inline) []Inline {
+	var matched []Inline
 	short := ShortRuleID(v.ID)
 	for _, in := range byResource[v.ResourceID] {
-		if !ruleMatches(in.Rules, short) {
-			continue
-		}
-		return &models.WaiverInfo{
-			Status:  statusFor(in.Expires, now),
-			Source:  "inline",
-			Reason:  in.Reason,
-			Expires: in.Expires,
+		if ruleMatches(in.Rules, short) {
+			matched = append(matched, in)
 		}
 	}
-	return nil
+	return matched
 }
 
-func (s *Set) matchFile(v *models.RichViolation, now time.Time) *models.WaiverInfo {
+// matchingFile returns the indexes of the waivers that match a violation, in
+// file order.
+func (s *Set) matchingFile(v *models.RichViolation, packs []map[string]bool) []int {
 	if s == nil {
 		return nil
 	}
+	var matched []int
 	short := ShortRuleID(v.ID)
-	for _, w := range s.Waivers {
-		if !ruleMatches([]string{ShortRuleID(w.Rule)}, short) {
-			continue
+	for i, w := range s.Waivers {
+		if w.matches(v, short, packs[i]) {
+			matched = append(matched, i)
+		}
+	}
+	return matched
+}
+
+func inlineInfo(in Inline, now time.Time) *models.WaiverInfo {
+	return &models.WaiverInfo{
+		Status:  statusFor(in.Expires, now),
+		Source:  "inline",
+		Reason:  in.Reason,
+		Expires: in.Expires,
+	}
+}
+
+func fileInfo(w Waiver, now time.Time) *models.WaiverInfo {
+	return &models.WaiverInfo{
+		Status:          statusFor(w.Expires, now),
+		Source:          "file",
+		Reason:          w.Reason,
+		Owner:           w.Owner,
+		Expires:         w.Expires,
+		ApprovedBy:      w.ApprovedBy,
+		Ticket:          w.Ticket,
+		Created:         w.Created,
+		SeverityCeiling: w.SeverityCeiling,
+	}
+}
+
+// matches reports whether the waiver applies to a violation. A waiver with a
+// fingerprint matches that violation only; rule, pack, resource and files are
+// ignored. A resource pattern matches a Terraform instance address with or
+// without its instance keys. A violation above the severity ceiling is never matched. packRules
+// holds the short IDs of the rules in the waiver's packs.
+func (w Waiver) matches(v *models.RichViolation, shortID string, packRules map[string]bool) bool {
+	if w.SeverityCeiling != "" && !models.SeverityAtLeast(w.SeverityCeiling, v.Severity) {
+		return false
+	}
+	if w.Fingerprint != "" {
+		return w.Fingerprint == v.Fingerprint
+	}
+	if !ruleMatches([]string{w.rulePattern()}, shortID) {
+		return false
+	}
+	if w.Pack != "" && !packRules[shortID] {
+		return false
+	}
+	if w.MaxSeverity != "" && !models.SeverityAtLeast(w.MaxSeverity, v.Severity) {
+		return false
+	}
+	if w.Resource != "" && !GlobMatch(w.Resource, v.ResourceID) && !GlobMatch(w.Resource, blockAddress(v.ResourceID)) {
+		return false
+	}
+	return len(w.Files) == 0 || AnyFileMatch(w.Files, v.File)
+}
+
+// blockAddress strips the count and for_each instance keys from a Terraform
+// resource address, e.g. `module.a[0].alicloud_instance.web["x"]` ->
+// "module.a.alicloud_instance.web", so a waiver for the block covers all of its
+// instances. Other resource IDs are returned unchanged.
+func blockAddress(id string) string {
+	if !strings.Contains(id, "[") {
+		return id
+	}
+	var b strings.Builder
+	depth := 0
+	inQuote := false
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case inQuote:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inQuote = false
+			}
+		case c == '"' && depth > 0:
+			inQuote = true
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case depth == 0:
+			b.WriteByte(c)
 		}
-		if w.Resource != "" && !globMatch(w.Resource, v.ResourceID) {
+	}
+	return b.String()
+}
+
+// rulePattern returns the short rule ID the waiver targets. A waiver scoped by
+// pack or maximum severity alone targets every rule in that scope.
+func (w Waiver) rulePattern() string {
+	if w.Rule == "" && (w.Pack != "" || w.MaxSeverity != "") {
+		return "*"
+	}
+	return ShortRuleID(w.Rule)
+}
+
+// resolvePacks returns, for each waiver, the short IDs of the rules in the
+// packs its pack pattern matches. Entries are nil for waivers without a pack.
+func (s *Set) resolvePacks() []map[string]bool {
+	if s == nil {
+		return nil
+	}
+	packs := make([]map[string]bool, len(s.Waivers))
+	for i, w := range s.Waivers {
+		if w.Pack == "" {
 			continue
 		}
-		if len(w.Files) > 0 && !anyFileMatch(w.Files, v.File) {
+		packs[i] = make(map[string]bool)
+		for _, rule := range s.packRules(w.Pack) {
+			packs[i][ShortRuleID(rule.ID)] = true
+		}
+	}
+	return packs
+}
+
+// packRules returns the rules of the packs in the index that match pattern,
+// a full ("pack:aliyun:foo") or short ("foo") pack ID glob.
+func (s *Set) packRules(pattern string) []*models.Rule {
+	if s.Index == nil {
+		return nil
+	}
+	var rules []*models.Rule
+	seen := make(map[string]bool)
+	for _, pack := range s.Index.PackList {
+		if !PackMatches(pattern, pack.ID) {
 			continue
 		}
-		return &models.WaiverInfo{
-			Status:  statusFor(w.Expires, now),
-			Source:  "file",
-			Reason:  w.Reason,
-			Owner:   w.Owner,
-			Expires: w.Expires,
+		for _, rule := range s.Index.GetRulesForPack(pack.ID) {
+			if !seen[rule.ID] {
+				seen[rule.ID] = true
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+// HasPack reports whether a pack in the index matches pattern. It returns
+// true if there is no index to check against.
+func (s *Set) HasPack(pattern string) bool {
+	if s.Index == nil {
+		return true
+	}
+	for _, pack := range s.Index.PackList {
+		if PackMatches(pattern, pack.ID) {
+			return true
 		}
 	}
-	return nil
+	return false
+}
+
+// PackMatches reports whether a pack ID matches a pack waiver pattern, a glob
+// of the full ("pack:aliyun:foo") or short ("foo") pack ID.
+func PackMatches(pattern, id string) bool {
+	return GlobMatch(pattern, id) || GlobMatch(pattern, ShortRuleID(id))
 }
 
 func ruleMatches(patterns []string, shortID string) bool {
@@ -321,14 +768,14 @@ func statusFor(expires string, now time.Time) string {
 	return models.WaiverStatusActive
 }
 
-// anyFileMatch reports whether path matches any of the file globs. Patterns are
+// AnyFileMatch reports whether path matches any of the file globs. Patterns are
 // matched against the path relative to the current working directory (falling back
 // to the absolute path).
-func anyFileMatch(patterns []string, path string) bool {
+func AnyFileMatch(patterns []string, path string) bool {
 	candidates := fileCandidates(path)
 	for _, p := range patterns {
 		for _, c := range candidates {
-			if globMatch(p, c) {
+			if GlobMatch(p, c) {
 				return true
 			}
 		}
@@ -349,16 +796,21 @@ func fileCandidates(path string) []string {
 	return out
 }
 
-// globMatch matches a glob pattern supporting "*" (within a path segment) and
+// GlobMatch matches a glob pattern supporting "*" (within a path segment) and
 // "**" (across segments) against a slash-separated string.
-func globMatch(pattern, s string) bool {
+func GlobMatch(pattern, s string) bool {
 	re := globToRegexp(filepath.ToSlash(pattern))
 	return re.MatchString(filepath.ToSlash(s))
 }
 
-var globCache = map[string]*regexp.Regexp{}
+var (
+	globCacheMu sync.Mutex
+	globCache   = map[string]*regexp.Regexp{}
+)
 
 func globToRegexp(pattern string) *regexp.Regexp {
+	globCacheMu.Lock()
+	defer globCacheMu.Unlock()
 	if re, ok := globCache[pattern]; ok {
 		return re
 	}
@@ -392,12 +844,5 @@ func globToRegexp(pattern string) *regexp.Regexp {
 
 // ShortRuleID reduces a full rule ID ("rule:aliyun:foo") to its short form ("foo").
 func ShortRuleID(id string) string {
-	if !strings.HasPrefix(id, "rule:") && !strings.HasPrefix(id, "pack:") {
-		return id
-	}
-	parts := strings.Split(id, ":")
-	if len(parts) >= 3 {
-		return parts[len(parts)-1]
-	}
-	return id
+	return models.ShortRuleID(id)
 }
@@ -1,6 +1,9 @@
 package waiver
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,8 +52,8 @@ func TestGlobMatch(t *testing.T) {
 		{"a/*/c", "a/b/d/c", false},
 	}
 	for _, c := range cases {
-		if got := globMatch(c.pattern, c.s); got != c.want {
-			t.Errorf("globMatch(%q,%q) = %v, want %v", c.pattern, c.s, got, c.want)
+		if got := GlobMatch(c.pattern, c.s); got != c.want {
+			t.Errorf("GlobMatch(%q,%q) = %v, want %v", c.pattern, c.s, got, c.want)
 		}
 	}
 }
@@ -95,6 +98,23 @@ func TestAttributeInline(t *testing.T) {
 	}
 }
 
+func TestAttributeInlineSharedBlock(t *testing.T) {
+	// Terraform count instances share the start line of their block.
+	inlines := []Inline{{Line: 1, Rules: []string{"r1"}}}
+	resources := []ResourceLine{
+		{ID: "alicloud_instance.web[0]", Line: 2},
+		{ID: "alicloud_instance.web[1]", Line: 2},
+		{ID: "alicloud_instance.other", Line: 8},
+	}
+	got := AttributeInline(inlines, resources)
+	if len(got["alicloud_instance.web[0]"]) != 1 || len(got["alicloud_instance.web[1]"]) != 1 {
+		t.Errorf("every instance should receive the directive: %+v", got)
+	}
+	if len(got["alicloud_instance.other"]) != 0 {
+		t.Errorf("other block should not receive the directive: %+v", got)
+	}
+}
+
 func TestAnnotateFileWaiver(t *testing.T) {
 	now := mustDate("2026-06-22")
 	set := &Set{Waivers: []Waiver{
@@ -124,6 +144,65 @@ func TestAnnotateFileWaiver(t *testing.T) {
 	}
 }
 
+func TestAnnotateInstanceAddress(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Waivers: []Waiver{
+		{Rule: "needs-tag", Resource: "alicloud_instance.web", Reason: "whole block"},
+		{Rule: "needs-tag", Resource: "module.net.alicloud_vpc.main", Reason: "module block"},
+		{Rule: "needs-tag", Resource: "alicloud_oss_bucket.logs[*]", Reason: "any instance"},
+		{Rule: "needs-tag", Resource: "alicloud_vswitch.app[1]", Reason: "one instance"},
+	}}
+	results := []models.FileResult{{
+		File: "main.tf",
+		Violations: []models.RichViolation{
+			{ID: "needs-tag", ResourceID: "alicloud_instance.web[0]"},
+			{ID: "needs-tag", ResourceID: `alicloud_instance.web["a.b"]`},
+			{ID: "needs-tag", ResourceID: `module.net[0].alicloud_vpc.main["x"]`},
+			{ID: "needs-tag", ResourceID: `alicloud_oss_bucket.logs["audit"]`},
+			{ID: "needs-tag", ResourceID: "alicloud_vswitch.app[1]"},
+			{ID: "needs-tag", ResourceID: "alicloud_vswitch.app[0]"},
+			{ID: "needs-tag", ResourceID: "alicloud_instance.webserver[0]"},
+		},
+	}}
+
+	set.Annotate(results, nil, now)
+
+	want := []bool{true, true, true, true, true, false, false}
+	for i, v := range results[0].Violations {
+		if got := v.Waiver != nil; got != want[i] {
+			t.Errorf("%s waived = %v, want %v", v.ResourceID, got, want[i])
+		}
+	}
+}
+
+func TestAnnotateFingerprintWaiver(t *testing.T) {
+	now := mustDate("2026-06-22")
+	// A fingerprint waiver ignores rule and resource, which it does not set.
+	set := &Set{Waivers: []Waiver{
+		{Fingerprint: "abc", Reason: "accepted", Expires: "2026-12-31"},
+	}}
+	results := []models.FileResult{{
+		File: "t.yaml",
+		Violations: []models.RichViolation{
+			{ID: "rule:aliyun:needs-tag", ResourceID: "Foo", Fingerprint: "abc"},
+			{ID: "rule:aliyun:needs-tag", ResourceID: "Foo", Fingerprint: "def"},
+		},
+	}}
+
+	set.Annotate(results, nil, now)
+
+	v := results[0].Violations
+	if v[0].Waiver == nil || v[0].Waiver.Status != models.WaiverStatusActive {
+		t.Errorf("matching fingerprint should be active-waived: %+v", v[0].Waiver)
+	}
+	if v[1].Waiver != nil {
+		t.Errorf("other fingerprint should have no waiver: %+v", v[1].Waiver)
+	}
+	if issues := set.Lint(nil, now); len(issues) != 0 {
+		t.Errorf("fingerprint waiver without rule should lint clean, got %+v", issues)
+	}
+}
+
 func TestAnnotateInlinePrecedence(t *testing.T) {
 	now := mustDate("2026-06-22")
 	// File waiver would match, but an inline directive should take precedence.
@@ -145,6 +224,28 @@ func TestAnnotateInlinePrecedence(t *testing.T) {
 	}
 }
 
+func TestAnnotateInlineInModuleFile(t *testing.T) {
+	now := mustDate("2026-06-22")
+	// A Terraform scan result is keyed by the scanned file, but the directive
+	// sits in the child module file that declares the resource.
+	results := []models.FileResult{{
+		File: "/p/main.tf",
+		Violations: []models.RichViolation{{
+			ID:         "needs-tag",
+			ResourceID: "module.logs.alicloud_oss_bucket.this",
+			File:       "/p/modules/bucket/main.tf",
+		}},
+	}}
+	inlineByFile := map[string]map[string][]Inline{
+		"/p/modules/bucket/main.tf": {"module.logs.alicloud_oss_bucket.this": {{Rules: []string{"needs-tag"}, Reason: "module"}}},
+	}
+	(&Set{}).Annotate(results, inlineByFile, now)
+
+	if w := results[0].Violations[0].Waiver; w == nil || w.Reason != "module" {
+		t.Errorf("expected inline waiver from the module file, got %+v", w)
+	}
+}
+
 func TestLint(t *testing.T) {
 	now := mustDate("2026-06-22")
 	set := &Set{Waivers: []Waiver{
@@ -170,3 +271,381 @@ func TestLint(t *testing.T) {
 		t.Errorf("expected >=2 warnings (unknown rule, expired), got %d: %+v", warns, issues)
 	}
 }
+
+func TestLintGovernanceFields(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Waivers: []Waiver{
+		{Rule: "known", Reason: "ok", Expires: "2026-12-31", Created: "2026-06-01", SeverityCeiling: "medium"},
+		{Rule: "known", Reason: "ok", Expires: "2026-12-31", Created: "June 1st"},
+		{Rule: "known", Reason: "ok", Expires: "2026-12-31", SeverityCeiling: "urgent"},
+	}}
+	issues := set.Lint(map[string]bool{"known": true}, now)
+
+	codes := map[int]string{}
+	for _, i := range issues {
+		codes[i.Index] = i.Code
+	}
+	if _, ok := codes[0]; ok {
+		t.Errorf("valid created and ceiling should lint clean, got %+v", issues)
+	}
+	if codes[1] != CodeInvalidCreated {
+		t.Errorf("expected %s for waiver 1, got %+v", CodeInvalidCreated, issues)
+	}
+	if codes[2] != CodeInvalidCeiling {
+		t.Errorf("expected %s for waiver 2, got %+v", CodeInvalidCeiling, issues)
+	}
+}
+
+func TestGovern(t *testing.T) {
+	now := mustDate("2026-06-22")
+	require := map[string]Requirement{
+		models.SeverityHigh: {Approver: true, Ticket: true, MaxDays: 90},
+	}
+	severities := map[string]string{"risky": models.SeverityHigh, "minor": models.SeverityLow}
+	set := &Set{Waivers: []Waiver{
+		// 0: compliant high-severity waiver.
+		{Rule: "risky", Reason: "ok", ApprovedBy: "alice", Ticket: "SEC-1", Created: "2026-06-01", Expires: "2026-08-01"},
+		// 1: missing approver and ticket, lifetime over 90 days.
+		{Rule: "risky", Reason: "ok", Created: "2026-06-01", Expires: "2027-06-01"},
+		// 2: low-severity rule has no requirement.
+		{Rule: "minor", Reason: "ok"},
+		// 3: a low ceiling lowers the level governance applies.
+		{Rule: "risky", Reason: "ok", SeverityCeiling: models.SeverityLow},
+		// 4: unknown rule defaults to high; permanent exceeds the lifetime.
+		{Rule: "ghost", Reason: "ok", ApprovedBy: "bob", Ticket: "SEC-2"},
+	}}
+	issues := set.Govern(require, severities, now)
+
+	got := map[int][]string{}
+	for _, i := range issues {
+		if i.Severity != IssueError {
+			t.Errorf("governance issues should be errors: %+v", i)
+		}
+		got[i.Index] = append(got[i.Index], i.Code)
+	}
+	if len(got[0]) != 0 || len(got[2]) != 0 || len(got[3]) != 0 {
+		t.Errorf("waivers 0, 2 and 3 should pass, got %+v", got)
+	}
+	want := []string{CodeMissingApprover, CodeMissingTicket, CodeLifetime}
+	if strings.Join(got[1], ",") != strings.Join(want, ",") {
+		t.Errorf("waiver 1: got %v, want %v", got[1], want)
+	}
+	if strings.Join(got[4], ",") != CodeLifetime {
+		t.Errorf("waiver 4: got %v, want [%s]", got[4], CodeLifetime)
+	}
+	if issues := set.Govern(nil, severities, now); len(issues) != 0 {
+		t.Errorf("no requirements should yield no issues, got %+v", issues)
+	}
+}
+
+func TestAnnotateSeverityCeiling(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Waivers: []Waiver{
+		{Rule: "needs-tag", Reason: "ok", ApprovedBy: "alice", Ticket: "SEC-1", Created: "2026-06-01", SeverityCeiling: models.SeverityMedium},
+	}}
+	results := []models.FileResult{{
+		File: "t.yaml",
+		Violations: []models.RichViolation{
+			{ID: "rule:aliyun:needs-tag", ResourceID: "A", Severity: models.SeverityLow},
+			{ID: "rule:aliyun:needs-tag", ResourceID: "B", Severity: models.SeverityHigh},
+		},
+	}}
+
+	set.Annotate(results, nil, now)
+
+	v := results[0].Violations
+	w := v[0].Waiver
+	if w == nil || w.ApprovedBy != "alice" || w.Ticket != "SEC-1" || w.Created != "2026-06-01" || w.SeverityCeiling != models.SeverityMedium {
+		t.Errorf("low violation should be waived with governance fields: %+v", w)
+	}
+	if v[1].Waiver != nil {
+		t.Errorf("high violation exceeds the ceiling and should not be waived: %+v", v[1].Waiver)
+	}
+}
+
+// testIndex returns a policy index with a "sandbox" pack of one rule per
+// severity and an "other" pack.
+func testIndex() *models.PolicyIndex {
+	index := &models.PolicyIndex{Rules: map[string]*models.Rule{}, Packs: map[string]*models.Pack{}}
+	for _, r := range []struct{ id, severity string }{
+		{"rule:aliyun:risky", "high"}, {"rule:aliyun:middling", "medium"}, {"rule:aliyun:minor", "low"}, {"rule:aliyun:elsewhere", "low"},
+	} {
+		index.AddRule(&models.Rule{ID: r.id, Severity: r.severity})
+	}
+	index.AddPack(&models.Pack{ID: "pack:aliyun:sandbox", RuleIDs: []string{"rule:aliyun:risky", "rule:aliyun:middling", "rule:aliyun:minor"}})
+	index.AddPack(&models.Pack{ID: "pack:aliyun:other", RuleIDs: []string{"rule:aliyun:elsewhere"}})
+	return index
+}
+
+func TestAnnotatePackAndSeverityScope(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Index: testIndex(), Waivers: []Waiver{
+		{Pack: "sandbox", MaxSeverity: models.SeverityMedium, Files: []string{"sandbox/**"}, Reason: "sandbox"},
+		{Pack: "pack:aliyun:oth*", Resource: "Shared", Reason: "shared"},
+	}}
+	results := []models.FileResult{{
+		File: "sandbox/t.yaml",
+		Violations: []models.RichViolation{
+			{ID: "rule:aliyun:minor", ResourceID: "A", Severity: models.SeverityLow, File: "sandbox/t.yaml"},
+			{ID: "rule:aliyun:middling", ResourceID: "A", Severity: models.SeverityMedium, File: "sandbox/t.yaml"},
+			{ID: "rule:aliyun:risky", ResourceID: "A", Severity: models.SeverityHigh, File: "sandbox/t.yaml"},
+			{ID: "rule:aliyun:elsewhere", ResourceID: "A", Severity: models.SeverityLow, File: "sandbox/t.yaml"},
+			{ID: "rule:aliyun:elsewhere", ResourceID: "Shared", Severity: models.SeverityLow, File: "sandbox/t.yaml"},
+		},
+	}}
+
+	usage := set.Annotate(results, nil, now)
+
+	v := results[0].Violations
+	for i, want := range []bool{true, true, false, false, true} {
+		if got := v[i].Waiver != nil; got != want {
+			t.Errorf("violation %d (%s): waived=%v, want %v", i, v[i].ID, got, want)
+		}
+	}
+	if !usage.File[0] || !usage.File[1] {
+		t.Errorf("both waivers should be used, got %+v", usage.File)
+	}
+
+	// Without an index, pack waivers match nothing.
+	for i := range v {
+		v[i].Waiver = nil
+	}
+	set.Index = nil
+	set.Annotate(results, nil, now)
+	for i := range v {
+		if v[i].Waiver != nil {
+			t.Errorf("violation %d should not be waived without an index: %+v", i, v[i].Waiver)
+		}
+	}
+}
+
+func TestLintPackWaivers(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Index: testIndex(), Waivers: []Waiver{
+		{Pack: "sandbox", Reason: "ok", Expires: "2026-12-31"},                                     // covers risky
+		{Pack: "sandbox", MaxSeverity: models.SeverityMedium, Reason: "ok", Expires: "2026-12-31"}, // clean
+		{Pack: "sandbox", Rule: "minor", Reason: "ok", Expires: "2026-12-31"},                      // clean
+		{Pack: "ghost", Reason: "ok", Expires: "2026-12-31"},                                       // unknown pack
+		{MaxSeverity: "urgent", Reason: "ok", Expires: "2026-12-31"},                               // invalid
+	}}
+	issues := set.Lint(nil, now)
+
+	codes := map[int][]string{}
+	for _, i := range issues {
+		codes[i.Index] = append(codes[i.Index], i.Code)
+	}
+	if len(codes[0]) != 1 || codes[0][0] != CodePackHigh {
+		t.Errorf("waiver 0: got %v, want [%s]", codes[0], CodePackHigh)
+	}
+	for _, i := range issues {
+		if i.Index == 0 && i.Detail != "risky" {
+			t.Errorf("waiver 0 should name the high-severity rule, got %q", i.Detail)
+		}
+	}
+	if len(codes[1]) != 0 || len(codes[2]) != 0 {
+		t.Errorf("waivers 1 and 2 should lint clean, got %v", codes)
+	}
+	if len(codes[3]) != 1 || codes[3][0] != CodeUnknownPack {
+		t.Errorf("waiver 3: got %v, want [%s]", codes[3], CodeUnknownPack)
+	}
+	if len(codes[4]) != 1 || codes[4][0] != CodeInvalidMaxSeverity {
+		t.Errorf("waiver 4: got %v, want [%s]", codes[4], CodeInvalidMaxSeverity)
+	}
+}
+
+func TestGovernPackWaivers(t *testing.T) {
+	now := mustDate("2026-06-22")
+	require := map[string]Requirement{models.SeverityHigh: {Approver: true}}
+	set := &Set{Index: testIndex(), Waivers: []Waiver{
+		{Pack: "sandbox", Reason: "ok"},                                  // highest rule is high
+		{Pack: "other", Reason: "ok"},                                    // only low rules
+		{Pack: "sandbox", MaxSeverity: models.SeverityLow, Reason: "ok"}, // limited to low
+		{Rule: "*", MaxSeverity: models.SeverityHigh, Reason: "ok"},      // high by scope
+	}}
+	got := map[int]bool{}
+	for _, i := range set.Govern(require, nil, now) {
+		got[i.Index] = true
+	}
+	for i, want := range []bool{true, false, false, true} {
+		if got[i] != want {
+			t.Errorf("waiver %d: governed=%v, want %v", i, got[i], want)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".infraguard", "waivers.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	original := `# Reviewed by the platform team.
+version: 1
+waivers:
+  # Legacy bucket, see CAB-1234.
+  - rule: needs-tag
+    resource: Legacy
+    reason: legacy
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := Append(path, []Waiver{
+		{Rule: "rule:aliyun:needs-tag", Resource: "Legacy", Reason: "dup"}, // same target as the existing entry
+		{Fingerprint: "abc", Rule: "other", Reason: "new", Expires: "2026-12-31"},
+	})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if len(added) != 1 || added[0].Fingerprint != "abc" {
+		t.Fatalf("added = %+v, want only the fingerprint waiver", added)
+	}
+
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"# Reviewed by the platform team.", "# Legacy bucket, see CAB-1234."} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("comment %q was lost:\n%s", want, data)
+		}
+	}
+	set, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(set.Waivers) != 2 || set.Waivers[0].Resource != "Legacy" || set.Waivers[1].Fingerprint != "abc" {
+		t.Errorf("waivers = %+v, want the existing entry followed by the new one", set.Waivers)
+	}
+}
+
+func TestAppendCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".infraguard", "waivers.yaml")
+	if _, err := Append(path, []Waiver{{Rule: "needs-tag", Reason: "ok"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	set, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(set.Waivers) != 1 || set.Waivers[0].Rule != "needs-tag" {
+		t.Errorf("waivers = %+v, want the new entry", set.Waivers)
+	}
+}
+
+func TestAnnotateUsage(t *testing.T) {
+	now := mustDate("2026-06-22")
+	set := &Set{Waivers: []Waiver{
+		{Rule: "needs-tag", Resource: "Foo", Reason: "used"},
+		{Rule: "needs-tag", Resource: "Gone", Reason: "resource was deleted"},
+		{Rule: "needs-tag", Resource: "Bar", Reason: "shadowed by the inline directive"},
+		{Rule: "*", Resource: "Foo", Reason: "shadowed by the first waiver"},
+	}}
+	used := Inline{File: "t.yaml", Line: 3, Rules: []string{"needs-tag"}, Reason: "inline"}
+	shadowed := Inline{File: "t.yaml", Line: 4, Rules: []string{"*"}, Reason: "shadowed by line 3"}
+	stale := Inline{File: "t.yaml", Line: 9, Rules: []string{"other-rule"}, Reason: "fixed"}
+	results := []models.FileResult{{
+		File: "t.yaml",
+		Violations: []models.RichViolation{
+			{ID: "needs-tag", ResourceID: "Foo", File: "t.yaml"},
+			{ID: "needs-tag", ResourceID: "Bar", File: "t.yaml"},
+		},
+	}}
+	inlineByFile := map[string]map[string][]Inline{"t.yaml": {"Bar": {used, shadowed}, "Baz": {stale}}}
+
+	usage := set.Annotate(results, inlineByFile, now)
+
+	if w := results[0].Violations[1].Waiver; w == nil || w.Source != "inline" || w.Reason != "inline" {
+		t.Errorf("Bar waiver = %+v, want the first inline directive", w)
+	}
+	// Shadowed waivers and directives are used: removing the one that shadows
+	// them would make them apply.
+	if got := set.Unused(usage); len(got) != 1 || got[0] != 1 {
+		t.Errorf("Unused = %v, want [1]", got)
+	}
+	if got := UnusedInline([]Inline{stale, used, shadowed}, usage); len(got) != 1 || got[0].Line != 9 {
+		t.Errorf("UnusedInline = %+v, want the directive on line 9", got)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "waivers.yaml")
+	original := `version: 1
+waivers:
+  # Keep this one.
+  - rule: a
+    reason: keep
+  - rule: b
+    reason: stale
+  - rule: c
+    reason: keep too
+`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(path, []int{1}); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "# Keep this one.") {
+		t.Errorf("comment was lost:\n%s", data)
+	}
+	set, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(set.Waivers) != 2 || set.Waivers[0].Rule != "a" || set.Waivers[1].Rule != "c" {
+		t.Errorf("waivers = %+v, want a and c", set.Waivers)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	tests := []struct {
+		waiver Waiver
+		want   string
+	}{
+		{Waiver{Rule: "a", Fingerprint: "0123"}, "0123"},
+		{Waiver{Pack: "quick-start-compliance-pack"}, "quick-start-compliance-pack"},
+		{Waiver{Pack: "p", Rule: "a"}, "a"},
+		{Waiver{MaxSeverity: "low"}, "*"},
+		{Waiver{Rule: "a"}, "a"},
+	}
+	for _, tt := range tests {
+		if got := tt.waiver.Target(); got != tt.want {
+			t.Errorf("Target(%+v) = %q, want %q", tt.waiver, got, tt.want)
+		}
+	}
+}
+
+func TestInScope(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &Set{Index: testIndex()}
+	sandbox := Scope{
+		Rules: func(shortID string) bool { return shortID != "elsewhere" },
+		Roots: []string{filepath.Join(cwd, "sandbox")},
+	}
+	tests := []struct {
+		name   string
+		waiver Waiver
+		scope  Scope
+		want   bool
+	}{
+		{"unlimited scope", Waiver{Fingerprint: "0123"}, Scope{}, true},
+		{"scanned rule and files", Waiver{Rule: "risky", Files: []string{"sandbox/**"}}, sandbox, true},
+		{"file below a scanned directory", Waiver{Rule: "risky", Files: []string{"sandbox/a/t.yaml"}}, sandbox, true},
+		{"rule not scanned", Waiver{Rule: "elsewhere", Files: []string{"sandbox/**"}}, sandbox, false},
+		{"pack with a rule not scanned", Waiver{Pack: "*", Files: []string{"sandbox/**"}}, sandbox, false},
+		{"pack with scanned rules", Waiver{Pack: "sandbox", Files: []string{"sandbox/**"}}, sandbox, true},
+		{"all rules", Waiver{Rule: "*", Files: []string{"sandbox/**"}}, sandbox, false},
+		{"fingerprint", Waiver{Fingerprint: "0123"}, sandbox, false},
+		{"any file", Waiver{Rule: "risky"}, sandbox, false},
+		{"file outside the scanned directories", Waiver{Rule: "risky", Files: []string{"**/t.yaml"}}, sandbox, false},
+		{"sibling directory", Waiver{Rule: "risky", Files: []string{"sandbox2/t.yaml"}}, sandbox, false},
+		{"any file with every path scanned", Waiver{Rule: "risky"}, Scope{Rules: sandbox.Rules}, true},
+	}
+	for _, tt := range tests {
+		if got := set.InScope(tt.waiver, tt.scope); got != tt.want {
+			t.Errorf("%s: InScope(%+v) = %v, want %v", tt.name, tt.waiver, got, tt.want)
+		}
+	}
+}
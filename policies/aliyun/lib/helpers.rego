@@ -32,6 +32,18 @@ includes(list, elem) if {
 	list[_] == elem
 }
 
+# ============================================================================
+# Rule Parameter Helpers
+# ============================================================================
+
+# Get a rule parameter: the value configured for the project, which InfraGuard
+# provides as data.infraguard.params[<rule-id>], or else the default declared in
+# rule_meta.params
+# Usage: helpers.param(rule_meta, "max_prefix_length")
+param(meta, name) := value if {
+	value := data.infraguard.params[meta.id][name]
+} else := meta.params[name]["default"]
+
 # ============================================================================
 # Network Helpers
 # ============================================================================
@@ -46,6 +58,9 @@ is_public_cidr(cidr) if {
 	cidr == "::/0"
 }
 
+# Get the prefix length of a CIDR (e.g., 24 for "10.0.0.0/24")
+cidr_prefix_length(cidr) := to_number(split(cidr, "/")[1])
+
 # Private IPv4 CIDR ranges (RFC 1918 and others)
 private_cidrs := [
 	"10.0.0.0/8", # Class A private
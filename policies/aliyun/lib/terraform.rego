@@ -18,3 +18,59 @@ get_attribute(resource, attr, default_value) := value if {
 is_unknown(value) if {
 	value == "<unknown>"
 }
+
+# References to other resources are evaluated to placeholders such as
+# {"__ref__": "alicloud_vpc.main", "attr": "id"} for `alicloud_vpc.main.id`,
+# the Terraform counterpart of ROS Ref and Fn::GetAtt.
+
+# Check if a value is a reference to another resource or data source
+is_reference(v) if {
+	is_object(v)
+	is_string(v.__ref__)
+}
+
+# Resolve a reference to the address of the referenced resource
+# If v is {"__ref__": "alicloud_vpc.main", "attr": "id"}, returns "alicloud_vpc.main"
+# Otherwise returns v
+resolve_ref(v) := v.__ref__ if {
+	is_reference(v)
+} else := v
+
+# Get the referenced attribute, e.g. "id"
+ref_attribute(v) := v.attr if {
+	is_reference(v)
+}
+
+# Get the Terraform address of a resource from its type and name in input.resources
+# Resources in child modules carry their full address in __meta__
+resource_address(resource_type, name) := address if {
+	address := input.resources[resource_type][name].__meta__.address
+} else := sprintf("%s.%s", [resource_type, name])
+
+# Check if a value refers to a specific resource
+is_referencing(v, resource_type, name) if {
+	is_reference(v)
+	v.__ref__ == resource_address(resource_type, name)
+}
+
+# Get the resource a reference points to
+referenced_resource(v) := resource if {
+	is_reference(v)
+	some resource_type, name
+	resource := input.resources[resource_type][name]
+	resource_address(resource_type, name) == v.__ref__
+}
+
+# Check if a resource is referenced by attribute attr of any resource of ref_type
+# attr may hold a single reference or a list of references
+is_referenced_by(resource_type, name, ref_type, attr) if {
+	some ref_resource in resources_by_type(ref_type)
+	is_referencing(ref_resource[attr], resource_type, name)
+}
+
+is_referenced_by(resource_type, name, ref_type, attr) if {
+	some ref_resource in resources_by_type(ref_type)
+	is_array(ref_resource[attr])
+	some v in ref_resource[attr]
+	is_referencing(v, resource_type, name)
+}
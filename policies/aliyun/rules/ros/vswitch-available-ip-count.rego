@@ -44,19 +44,29 @@ rule_meta := {
 		"fr": "Assurez-vous que le VSwitch a suffisamment d'adresses IP disponibles ou créez un VSwitch plus grand.",
 		"pt": "Garanta que o VSwitch tenha endereços IP disponíveis suficientes ou crie um VSwitch maior."
 	},
-	"resource_types": ["ALIYUN::ECS::VSwitch"]
+	"resource_types": ["ALIYUN::ECS::VSwitch"],
+	"params": {"max_prefix_length": {
+		"type": "number",
+		"default": 28,
+		"description": {
+			"en": "Largest allowed CIDR prefix length. VSwitches with a longer prefix (a smaller subnet) are reported.",
+			"zh": "允许的最大 CIDR 前缀长度。前缀更长（子网更小）的 VSwitch 将被报告。",
+			"ja": "許可される CIDR プレフィックス長の最大値。これより長いプレフィックス（より小さいサブネット）の VSwitch が報告されます。",
+			"de": "Größte zulässige CIDR-Präfixlänge. VSwitches mit längerem Präfix (kleinerem Subnetz) werden gemeldet.",
+			"es": "Longitud máxima permitida del prefijo CIDR. Se informan los VSwitch con un prefijo más largo (una subred más pequeña).",
+			"fr": "Longueur maximale autorisée du préfixe CIDR. Les VSwitch dont le préfixe est plus long (sous-réseau plus petit) sont signalés.",
+			"pt": "Comprimento máximo permitido do prefixo CIDR. VSwitches com prefixo mais longo (sub-rede menor) são relatados."
+		},
+	}}
 }
 
 deny contains result if {
 	some name, resource in helpers.resources_by_type("ALIYUN::ECS::VSwitch")
 
-	# Conceptual check for available IPs
-	# Since it's runtime info, in template we might check CIDR size
+	# Available IPs are runtime information; in a template we check the CIDR
+	# size and report subnets smaller than the configured limit.
 	cidr := helpers.get_property(resource, "CidrBlock", "")
-
-	# This is a bit complex to calculate accurately in Rego without helpers,
-	# but we can detect very small subnets.
-	endswith(cidr, "/29") # Example: Too small
+	helpers.cidr_prefix_length(cidr) > helpers.param(rule_meta, "max_prefix_length")
 	result := {
 		"id": rule_meta.id,
 		"resource_id": name,
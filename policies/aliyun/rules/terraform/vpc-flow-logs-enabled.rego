@@ -47,17 +47,26 @@ rule_meta := {
 	"iac_type": "terraform"
 }
 
-vpc_count := count(tf.resources_by_type("alicloud_vpc"))
+vpc_flow_logs := [flow_log |
+	some flow_log in tf.resources_by_type("alicloud_vpc_flow_log")
+	tf.get_attribute(flow_log, "resource_type", "VPC") == "VPC"
+]
 
-vpc_flow_log_count := count([name |
-	some name, flow_log in tf.resources_by_type("alicloud_vpc_flow_log")
-	resource_type := tf.get_attribute(flow_log, "resource_type", "VPC")
-	resource_type == "VPC"
+# VPCs not referenced by the resource_id of any flow log
+unreferenced_vpcs := {name |
+	some name, _ in tf.resources_by_type("alicloud_vpc")
+	not tf.is_referenced_by("alicloud_vpc", name, "alicloud_vpc_flow_log", "resource_id")
+}
+
+# Flow logs with a literal resource_id cannot be matched to a VPC, so they are counted instead
+literal_flow_log_count := count([flow_log |
+	some flow_log in vpc_flow_logs
+	not tf.is_reference(flow_log.resource_id)
 ])
 
 deny contains violation if {
-	some name, resource in tf.resources_by_type("alicloud_vpc")
-	vpc_flow_log_count < vpc_count
+	some name in unreferenced_vpcs
+	literal_flow_log_count < count(unreferenced_vpcs)
 	violation := {
 		"id": rule_meta.id,
 		"resource_id": sprintf("alicloud_vpc.%s", [name]),
@@ -2,6 +2,7 @@ package infraguard.rules.terraform.vswitch_available_ip_count
 
 import rego.v1
 
+import data.infraguard.helpers
 import data.infraguard.helpers.terraform as tf
 
 rule_meta := {
@@ -44,14 +45,27 @@ rule_meta := {
 		"pt": "Use um cidr_block com comprimento de prefixo menor que /29 para garantir endereços IP disponíveis suficientes."
 	},
 	"resource_types": ["alicloud_vswitch"],
-	"iac_type": "terraform"
+	"iac_type": "terraform",
+	"params": {"max_prefix_length": {
+		"type": "number",
+		"default": 28,
+		"description": {
+			"en": "Largest allowed CIDR prefix length. VSwitches with a longer prefix (a smaller subnet) are reported.",
+			"zh": "允许的最大 CIDR 前缀长度。前缀更长（子网更小）的 VSwitch 将被报告。",
+			"ja": "許可される CIDR プレフィックス長の最大値。これより長いプレフィックス（より小さいサブネット）の VSwitch が報告されます。",
+			"de": "Größte zulässige CIDR-Präfixlänge. VSwitches mit längerem Präfix (kleinerem Subnetz) werden gemeldet.",
+			"es": "Longitud máxima permitida del prefijo CIDR. Se informan los VSwitch con un prefijo más largo (una subred más pequeña).",
+			"fr": "Longueur maximale autorisée du préfixe CIDR. Les VSwitch dont le préfixe est plus long (sous-réseau plus petit) sont signalés.",
+			"pt": "Comprimento máximo permitido do prefixo CIDR. VSwitches com prefixo mais longo (sub-rede menor) são relatados."
+		},
+	}}
 }
 
 deny contains violation if {
 	some name, resource in tf.resources_by_type("alicloud_vswitch")
 	cidr := tf.get_attribute(resource, "cidr_block", "")
 	not tf.is_unknown(cidr)
-	endswith(cidr, "/29")
+	helpers.cidr_prefix_length(cidr) > helpers.param(rule_meta, "max_prefix_length")
 	violation := {
 		"id": rule_meta.id,
 		"resource_id": sprintf("alicloud_vswitch.%s", [name]),
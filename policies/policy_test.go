@@ -1,6 +1,7 @@
 package policies_test
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"github.com/aliyun/infraguard/pkg/engine"
 	"github.com/aliyun/infraguard/pkg/models"
 	"github.com/aliyun/infraguard/pkg/policy"
+	"github.com/aliyun/infraguard/pkg/policytest"
 	"github.com/aliyun/infraguard/pkg/providers/terraform"
 	. "github.com/smartystreets/goconvey/convey"
 	"gopkg.in/yaml.v3"
@@ -244,7 +246,7 @@ func TestPolicyRules(t *testing.T) {
 
 						opts := buildEvalOpts(loader, ruleID, testIaCType, ruleFile, libModules)
 
-						evalResult, err := engine.EvaluateWithOpts(opts, template)
+						evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 						violations := []models.OPAViolation{}
 						if err == nil {
 							violations = evalResult.Violations
@@ -272,7 +274,7 @@ func TestPolicyRules(t *testing.T) {
 
 						opts := buildEvalOpts(loader, ruleID, testIaCType, ruleFile, libModules)
 
-						evalResult, err := engine.EvaluateWithOpts(opts, template)
+						evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 						violations := []models.OPAViolation{}
 						if err == nil {
 							violations = evalResult.Violations
@@ -303,32 +305,33 @@ func TestPolicyRules(t *testing.T) {
 
 			t.Run(ruleName+"/terraform", func(t *testing.T) {
 				Convey("Given the Terraform "+ruleName+" rule", t, func() {
-					Convey("When evaluating compliant terraform config", func() {
-						compliantDir := filepath.Join(tfDir, "compliant")
-						template, err := terraform.Load(compliantDir, nil)
-						So(err, ShouldBeNil)
-
-						opts := buildEvalOpts(loader, ruleID, testIaCType, ruleFile, libModules)
+					for _, compliantDir := range policytest.TerraformCompliantDirs(tfDir) {
+						Convey("When evaluating "+filepath.Base(compliantDir)+" terraform config", func() {
+							template, err := terraform.Load(compliantDir, nil)
+							So(err, ShouldBeNil)
 
-						evalResult, err := engine.EvaluateWithOpts(opts, template)
-						violations := []models.OPAViolation{}
-						if err == nil {
-							violations = evalResult.Violations
-						}
+							opts := buildEvalOpts(loader, ruleID, testIaCType, ruleFile, libModules)
 
-						Convey("It should return no violations for this rule", func() {
-							So(err, ShouldBeNil)
-							filtered := filterByRuleID(violations, ruleID)
-							if len(filtered) == 0 {
-								filtered = filterByRuleID(violations, ruleName)
-							}
-							if len(filtered) > 0 {
-								t.Logf("Template dir: %s", compliantDir)
-								t.Logf("Unexpected violations found: %+v", filtered)
+							evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
+							violations := []models.OPAViolation{}
+							if err == nil {
+								violations = evalResult.Violations
 							}
-							So(filtered, ShouldBeEmpty)
+
+							Convey("It should return no violations for this rule", func() {
+								So(err, ShouldBeNil)
+								filtered := filterByRuleID(violations, ruleID)
+								if len(filtered) == 0 {
+									filtered = filterByRuleID(violations, ruleName)
+								}
+								if len(filtered) > 0 {
+									t.Logf("Template dir: %s", compliantDir)
+									t.Logf("Unexpected violations found: %+v", filtered)
+								}
+								So(filtered, ShouldBeEmpty)
+							})
 						})
-					})
+					}
 
 					Convey("When evaluating violation terraform config", func() {
 						violationDir := filepath.Join(tfDir, "violation")
@@ -337,7 +340,7 @@ func TestPolicyRules(t *testing.T) {
 
 						opts := buildEvalOpts(loader, ruleID, testIaCType, ruleFile, libModules)
 
-						evalResult, err := engine.EvaluateWithOpts(opts, template)
+						evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 						violations := []models.OPAViolation{}
 						if err == nil {
 							violations = evalResult.Violations
@@ -457,7 +460,7 @@ func TestPolicyPacks(t *testing.T) {
 						opts.PolicyPaths = []string{filepath.Join(rulesDir, "ros")}
 					}
 
-					evalResult, err := engine.EvaluateWithOpts(opts, template)
+					evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 					So(err, ShouldBeNil)
 					violations := evalResult.Violations
 
@@ -508,7 +511,7 @@ func TestPolicyPacks(t *testing.T) {
 						opts.PolicyPaths = []string{filepath.Join(rulesDir, "ros")}
 					}
 
-					evalResult, err := engine.EvaluateWithOpts(opts, template)
+					evalResult, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 					So(err, ShouldBeNil)
 					violations := evalResult.Violations
 
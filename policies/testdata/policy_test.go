@@ -1,6 +1,7 @@
 package policies_test
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
@@ -119,7 +120,7 @@ func TestPolicyRules(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{ruleFile, rosLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, template)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 
 					Convey("It should return no violations for this rule", func() {
 						So(err, ShouldBeNil)
@@ -136,7 +137,7 @@ func TestPolicyRules(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{ruleFile, rosLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, template)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 
 					Convey("It should return violations for this rule", func() {
 						So(err, ShouldBeNil)
@@ -235,7 +236,7 @@ func TestTerraformPolicyRules(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{ruleFile, tfLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, opaInput)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, opaInput)
 
 					Convey("It should return no violations for this rule", func() {
 						So(err, ShouldBeNil)
@@ -252,7 +253,7 @@ func TestTerraformPolicyRules(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{ruleFile, tfLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, opaInput)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, opaInput)
 
 					Convey("It should return violations for this rule", func() {
 						So(err, ShouldBeNil)
@@ -307,7 +308,7 @@ func TestPolicyPacks(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{rosRulesDir, rosLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, template)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 
 					Convey("It should return no violations", func() {
 						So(err, ShouldBeNil)
@@ -323,7 +324,7 @@ func TestPolicyPacks(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{rosRulesDir, rosLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, template)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, template)
 
 					Convey("It should return violations", func() {
 						So(err, ShouldBeNil)
@@ -370,7 +371,7 @@ func TestTerraformPolicyPacks(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{tfRulesDir, tfLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, opaInput)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, opaInput)
 
 					Convey("It should return no violations", func() {
 						So(err, ShouldBeNil)
@@ -386,7 +387,7 @@ func TestTerraformPolicyPacks(t *testing.T) {
 					opts := &engine.EvalOptions{
 						PolicyPaths: []string{tfRulesDir, tfLibFile},
 					}
-					result, err := engine.EvaluateWithOpts(opts, opaInput)
+					result, err := engine.EvaluateWithOpts(context.Background(), opts, opaInput)
 
 					Convey("It should return violations", func() {
 						So(err, ShouldBeNil)
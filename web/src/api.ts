@@ -39,12 +39,14 @@ export interface Summary {
   files_with_violations: number
   waived_count: number
   expired_waiver_count: number
+  timed_out_rule_count: number
 }
 
 export interface ScanResult {
   iac: string
   summary: Summary
   violations: Violation[]
+  timed_out_rules?: string[]
 }
 
 export interface RuleSummary {